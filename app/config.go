@@ -0,0 +1,20 @@
+package app
+
+import "os"
+
+// Env reads a setting from the environment, fallback is used when it isn't set.
+func Env(name, fallback string) string {
+	if value, ok := os.LookupEnv(name); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// MustEnv reads a setting that has no safe default, like a secret, the app doesn't start without it.
+func MustEnv(name string) string {
+	value := os.Getenv(name)
+	if value == "" {
+		panic("environment variable " + name + " must be set")
+	}
+	return value
+}
@@ -0,0 +1,47 @@
+package app
+
+import (
+	"context"
+	"go-api/logger"
+)
+
+// Keys of values the middlewares keep on the gin context, gin.Context hands them out through Value
+// so services given the handler's context can read them too.
+const (
+	UserIDKey    = "user_id"
+	RequestIDKey = "request_id"
+	// LastWriteKey holds the client's *LastWrite, writes made for it send its reads to the primary for StickyWindow.
+	LastWriteKey = "last_write"
+)
+
+// requestIDKey carries the request ID on plain contexts, like the request's own one that
+// statements run with, or the one of a background job run.
+type requestIDKey struct{}
+
+// UserIDFrom returns the user the request is made for, empty when there's none.
+func UserIDFrom(ctx context.Context) string {
+	userID, _ := ctx.Value(UserIDKey).(string)
+	return userID
+}
+
+// WithRequestID returns a copy of ctx logged under requestID.
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFrom returns the ID the request is logged under, empty outside of a request.
+func RequestIDFrom(ctx context.Context) string {
+	if requestID, ok := ctx.Value(RequestIDKey).(string); ok {
+		return requestID
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Logger is the shared logger, adding the request ID of ctx to every line when there's one.
+func Logger(ctx context.Context) *logger.Logger {
+	if requestID := RequestIDFrom(ctx); requestID != "" {
+		return logger.Default().With("request_id", requestID)
+	}
+	return logger.Default()
+}
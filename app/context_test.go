@@ -0,0 +1,37 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go-api/logger"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestIDFrom(t *testing.T) {
+	t.Run("gin handler should read the ID the middleware set", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Set(RequestIDKey, "request")
+		assert.Equal(t, "request", RequestIDFrom(c))
+	})
+
+	t.Run("plain context should read the ID it was given", func(t *testing.T) {
+		assert.Equal(t, "request", RequestIDFrom(WithRequestID(context.Background(), "request")))
+		assert.Equal(t, "", RequestIDFrom(context.Background()))
+	})
+}
+
+func TestLogger(t *testing.T) {
+	defer logger.SetDefault(logger.Default())
+	out := &bytes.Buffer{}
+	logger.SetDefault(logger.New(out, logger.LevelInfo))
+
+	Logger(WithRequestID(context.Background(), "request")).Warn("cache get failed")
+	assert.Contains(t, out.String(), `"request_id":"request"`)
+
+	out.Reset()
+	Logger(context.Background()).Warn("cache get failed")
+	assert.NotContains(t, out.String(), "request_id")
+}
@@ -33,6 +33,8 @@ func Init() *gorm.DB {
 	sqlDB.SetConnMaxLifetime(60 * time.Minute)
 
 	//db.LogMode(true)
+	registerWriteTracking(db)
+	registerQueryMetrics(db)
 	DB = db
 	return DB
 }
@@ -52,6 +54,8 @@ func TestDBInit() *gorm.DB {
 	sqlDB, err := testDB.DB()
 	sqlDB.SetMaxIdleConns(5)
 
+	registerWriteTracking(testDB)
+	registerQueryMetrics(testDB)
 	DB = testDB
 	return DB
 }
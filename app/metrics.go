@@ -0,0 +1,88 @@
+package app
+
+import (
+	"database/sql"
+	"go-api/metrics"
+	"gorm.io/gorm"
+	"time"
+)
+
+const queryStartedKey = "app:query_started"
+
+var queryDuration = metrics.NewHistogram("db_query_duration_seconds",
+	"Time gorm statements took by operation.", metrics.DefaultBuckets, "operation")
+
+// the pool stats are read from the primary configured in Init each time they're scraped
+func init() {
+	gauge := func(name, help string, value func(s sql.DBStats) int) {
+		metrics.NewGaugeFunc(name, help, func() float64 { return float64(value(poolStats())) })
+	}
+	counter := func(name, help string, value func(s sql.DBStats) int64) {
+		metrics.NewCounterFunc(name, help, func() float64 { return float64(value(poolStats())) })
+	}
+
+	gauge("db_pool_max_open_connections", "Maximum number of open connections to the database.",
+		func(s sql.DBStats) int { return s.MaxOpenConnections })
+	gauge("db_pool_open_connections", "Established connections, in use and idle.",
+		func(s sql.DBStats) int { return s.OpenConnections })
+	gauge("db_pool_in_use_connections", "Connections currently in use.",
+		func(s sql.DBStats) int { return s.InUse })
+	gauge("db_pool_idle_connections", "Idle connections.",
+		func(s sql.DBStats) int { return s.Idle })
+	counter("db_pool_wait_count_total", "Connections waited for.",
+		func(s sql.DBStats) int64 { return s.WaitCount })
+	metrics.NewCounterFunc("db_pool_wait_duration_seconds_total", "Time spent waiting for a connection.",
+		func() float64 { return poolStats().WaitDuration.Seconds() })
+	counter("db_pool_max_idle_closed_total", "Connections closed because of SetMaxIdleConns.",
+		func(s sql.DBStats) int64 { return s.MaxIdleClosed })
+	counter("db_pool_max_idle_time_closed_total", "Connections closed because of SetConnMaxIdleTime.",
+		func(s sql.DBStats) int64 { return s.MaxIdleTimeClosed })
+	counter("db_pool_max_lifetime_closed_total", "Connections closed because of SetConnMaxLifetime.",
+		func(s sql.DBStats) int64 { return s.MaxLifetimeClosed })
+}
+
+func poolStats() sql.DBStats {
+	if DB == nil {
+		return sql.DBStats{}
+	}
+	sqlDB, err := DB.DB()
+	if err != nil {
+		return sql.DBStats{}
+	}
+	return sqlDB.Stats()
+}
+
+// registerQueryMetrics times every statement db runs.
+func registerQueryMetrics(db *gorm.DB) {
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().Before("gorm:create").Register("app:start_query", startQuery),
+		callbacks.Create().After("gorm:create").Register("app:observe_query", observeQuery("create")),
+		callbacks.Query().Before("gorm:query").Register("app:start_query", startQuery),
+		callbacks.Query().After("gorm:query").Register("app:observe_query", observeQuery("query")),
+		callbacks.Update().Before("gorm:update").Register("app:start_query", startQuery),
+		callbacks.Update().After("gorm:update").Register("app:observe_query", observeQuery("update")),
+		callbacks.Delete().Before("gorm:delete").Register("app:start_query", startQuery),
+		callbacks.Delete().After("gorm:delete").Register("app:observe_query", observeQuery("delete")),
+		callbacks.Row().Before("gorm:row").Register("app:start_query", startQuery),
+		callbacks.Row().After("gorm:row").Register("app:observe_query", observeQuery("row")),
+		callbacks.Raw().Before("gorm:raw").Register("app:start_query", startQuery),
+		callbacks.Raw().After("gorm:raw").Register("app:observe_query", observeQuery("raw")),
+	} {
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func startQuery(db *gorm.DB) {
+	db.InstanceSet(queryStartedKey, time.Now())
+}
+
+func observeQuery(operation string) func(db *gorm.DB) {
+	return func(db *gorm.DB) {
+		if started, ok := db.InstanceGet(queryStartedKey); ok {
+			queryDuration.Observe(time.Since(started.(time.Time)).Seconds(), operation)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package app
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+// BaseURL is prepended to stored paths when building public links.
+const BaseURL = "localhost:3000/"
+
+type Storage interface {
+	Save(path string, r io.Reader) error
+	// Append writes r at the end of path, creating it when missing, and returns the bytes written.
+	Append(path string, r io.Reader) (int64, error)
+	Delete(path string) error
+	Open(path string) (*Object, error)
+	// List returns every file under prefix with paths relative to the storage root.
+	List(prefix string) ([]ObjectInfo, error)
+	URL(path string) string
+}
+
+type ObjectInfo struct {
+	Path    string
+	Size    int64
+	ModTime time.Time
+}
+
+type File interface {
+	io.ReadSeeker
+	io.ReaderAt
+	io.Closer
+}
+
+// Object is an opened stored file, the caller has to close it.
+type Object struct {
+	File
+	Size    int64
+	ModTime time.Time
+}
+
+type localStorage struct {
+	root string
+}
+
+var storage Storage
+
+// InitStorage Saves uploaded media under root, paths given to the storage are relative to it.
+func InitStorage(root string) Storage {
+	storage = &localStorage{root: root}
+	return storage
+}
+
+// GetStorage Using this function to reach the configured media storage.
+func GetStorage() Storage {
+	return storage
+}
+
+// fullPath keeps the stored path inside root even when it comes from a request.
+func (s *localStorage) fullPath(p string) string {
+	return filepath.Join(s.root, filepath.FromSlash(path.Clean("/"+p)))
+}
+
+func (s *localStorage) Save(path string, r io.Reader) error {
+	fullPath := s.fullPath(path)
+	err := os.MkdirAll(filepath.Dir(fullPath), 0755)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(fullPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = io.Copy(file, r)
+	return err
+}
+
+func (s *localStorage) Append(path string, r io.Reader) (int64, error) {
+	fullPath := s.fullPath(path)
+	err := os.MkdirAll(filepath.Dir(fullPath), 0755)
+	if err != nil {
+		return 0, err
+	}
+
+	file, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	return io.Copy(file, r)
+}
+
+func (s *localStorage) Delete(path string) error {
+	err := os.Remove(s.fullPath(path))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *localStorage) Open(path string) (*Object, error) {
+	file, err := os.Open(s.fullPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if stat.IsDir() {
+		file.Close()
+		return nil, os.ErrNotExist
+	}
+	return &Object{File: file, Size: stat.Size(), ModTime: stat.ModTime()}, nil
+}
+
+func (s *localStorage) List(prefix string) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	err := filepath.Walk(s.fullPath(prefix), func(fullPath string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		if err != nil || info.IsDir() {
+			return err
+		}
+
+		rel, err := filepath.Rel(s.root, fullPath)
+		if err != nil {
+			return err
+		}
+		objects = append(objects, ObjectInfo{Path: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime()})
+		return nil
+	})
+	return objects, err
+}
+
+func (s *localStorage) URL(path string) string {
+	return BaseURL + "res/" + path
+}
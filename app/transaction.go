@@ -0,0 +1,220 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// StickyWindow has to outlast replica lag, reads within it would miss the user's own writes.
+	StickyWindow = 10 * time.Second
+	// replicaRetryAfter is how long a replica that failed to begin a transaction is left out.
+	replicaRetryAfter = 30 * time.Second
+)
+
+var (
+	replicas    []*replica
+	nextReplica uint32
+)
+
+type replica struct {
+	db        *gorm.DB
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func (r *replica) isUp(now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return !now.Before(r.downUntil)
+}
+
+func (r *replica) markDown(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.downUntil = now.Add(replicaRetryAfter)
+}
+
+// InitReplicas routes read-only transactions to the databases at dsns. They're connected lazily,
+// an unreachable replica is skipped until it answers again.
+func InitReplicas(dsns ...string) {
+	replicas = nil
+	for _, dsn := range dsns {
+		db, err := gorm.Open(mysql.New(mysql.Config{
+			DSN:                       dsn,
+			SkipInitializeWithVersion: true,
+		}), &gorm.Config{
+			SkipDefaultTransaction: true,
+			DisableAutomaticPing:   true,
+		})
+		if err != nil {
+			panic(err)
+		}
+
+		sqlDB, err := db.DB()
+		if err != nil {
+			panic(err)
+		}
+		sqlDB.SetMaxIdleConns(5)
+		sqlDB.SetMaxOpenConns(20)
+		sqlDB.SetConnMaxIdleTime(10 * time.Minute)
+		sqlDB.SetConnMaxLifetime(60 * time.Minute)
+
+		registerQueryMetrics(db)
+		replicas = append(replicas, &replica{db: db})
+	}
+}
+
+// WriteTx begins a read-write transaction on the primary.
+func WriteTx(ctx context.Context) *gorm.DB {
+	return DB.WithContext(txContext(ctx)).Begin()
+}
+
+// ReadTx begins a read-only transaction on a replica. It falls back to the primary when no
+// replica is up or the client wrote within StickyWindow, so they always read their own writes.
+func ReadTx(ctx context.Context) *gorm.DB {
+	lastWrite := LastWriteFrom(ctx)
+	if lastWrite == nil || !lastWrite.Since(time.Now().Add(-StickyWindow)) {
+		if tx := beginOnReplica(txContext(ctx)); tx != nil {
+			return tx
+		}
+	}
+	return ReadPrimaryTx(ctx)
+}
+
+// ReadPrimaryTx begins a read-only transaction on the primary, for reads that can't be behind it,
+// like access checks or values that get cached beyond the request.
+func ReadPrimaryTx(ctx context.Context) *gorm.DB {
+	return DB.WithContext(txContext(ctx)).Begin(&sql.TxOptions{ReadOnly: true})
+}
+
+func beginOnReplica(ctx context.Context) *gorm.DB {
+	now := time.Now()
+	for i := 0; i < len(replicas); i++ {
+		r := replicas[int(atomic.AddUint32(&nextReplica, 1))%len(replicas)]
+		if !r.isUp(now) {
+			continue
+		}
+
+		tx := r.db.WithContext(ctx).Begin(&sql.TxOptions{ReadOnly: true})
+		if tx.Error == nil {
+			return tx
+		}
+		Logger(ctx).Warn("replica unavailable, reading from another one", "error", tx.Error)
+		r.markDown(now)
+	}
+	return nil
+}
+
+type (
+	writerKey      struct{}
+	afterCommitKey struct{}
+)
+
+// txContext keeps the client's LastWrite with the transaction for trackWrites, and gives it its own
+// list of AfterCommit work. gin.Context is never done but its request is, so when a handler is given
+// the transaction is bound to the request and database/sql rolls it back if nothing ended it by the
+// time the request is over.
+func txContext(ctx context.Context) context.Context {
+	lastWrite := LastWriteFrom(ctx)
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		ctx = c.Request.Context()
+	}
+	if lastWrite != nil {
+		ctx = context.WithValue(ctx, writerKey{}, lastWrite)
+	}
+	return context.WithValue(ctx, afterCommitKey{}, &afterCommit{})
+}
+
+type afterCommit struct {
+	fns []func()
+}
+
+// AfterCommit runs fn once tx is committed, in the order the functions were added, and never when
+// it's rolled back. It's for changes outside of the database, like the search index, that must not
+// show what a failed transaction did.
+func AfterCommit(tx *gorm.DB, fn func()) {
+	hooks, ok := tx.Statement.Context.Value(afterCommitKey{}).(*afterCommit)
+	if !ok {
+		panic("app: AfterCommit called with a transaction not begun by app")
+	}
+	hooks.fns = append(hooks.fns, fn)
+}
+
+// Committed runs the AfterCommit work of tx, it's called by whatever committed it.
+func Committed(tx *gorm.DB) {
+	hooks, ok := tx.Statement.Context.Value(afterCommitKey{}).(*afterCommit)
+	if !ok {
+		return
+	}
+	fns := hooks.fns
+	hooks.fns = nil
+	for _, fn := range fns {
+		fn()
+	}
+}
+
+// registerWriteTracking marks the client a statement changed rows for, which keeps their reads on the primary.
+func registerWriteTracking(db *gorm.DB) {
+	callbacks := db.Callback()
+	for _, err := range []error{
+		callbacks.Create().After("gorm:create").Register("app:track_writes", trackWrites),
+		callbacks.Update().After("gorm:update").Register("app:track_writes", trackWrites),
+		callbacks.Delete().After("gorm:delete").Register("app:track_writes", trackWrites),
+		callbacks.Raw().After("gorm:raw").Register("app:track_writes", trackWrites),
+	} {
+		if err != nil {
+			panic(err)
+		}
+	}
+}
+
+func trackWrites(db *gorm.DB) {
+	if db.Error != nil || db.Statement.RowsAffected == 0 {
+		return
+	}
+	if lastWrite, ok := db.Statement.Context.Value(writerKey{}).(*LastWrite); ok {
+		lastWrite.Mark(time.Now())
+	}
+}
+
+// LastWrite is when a client last wrote. The client carries it from request to request, see
+// middleware.StickyReads, so every instance routes its reads the same way without sharing any state.
+type LastWrite struct {
+	mu sync.Mutex
+	at time.Time
+}
+
+func NewLastWrite(at time.Time) *LastWrite {
+	return &LastWrite{at: at}
+}
+
+// LastWriteFrom returns the last write of the client the request is made by, nil outside of a request.
+func LastWriteFrom(ctx context.Context) *LastWrite {
+	lastWrite, _ := ctx.Value(LastWriteKey).(*LastWrite)
+	return lastWrite
+}
+
+func (w *LastWrite) Mark(at time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if at.After(w.at) {
+		w.at = at
+	}
+}
+
+func (w *LastWrite) At() time.Time {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.at
+}
+
+func (w *LastWrite) Since(since time.Time) bool {
+	return w.At().After(since)
+}
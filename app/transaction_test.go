@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestLastWrite(t *testing.T) {
+	now := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+	lastWrite := NewLastWrite(now)
+
+	assert.True(t, lastWrite.Since(now.Add(-StickyWindow)))
+	assert.False(t, lastWrite.Since(now.Add(time.Second)))
+	assert.False(t, NewLastWrite(time.Time{}).Since(now.Add(-StickyWindow)))
+
+	// an older write doesn't move it back
+	lastWrite.Mark(now.Add(-time.Minute))
+	assert.Equal(t, now, lastWrite.At())
+	lastWrite.Mark(now.Add(time.Minute))
+	assert.Equal(t, now.Add(time.Minute), lastWrite.At())
+}
+
+func TestReplicaRetry(t *testing.T) {
+	now := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+	r := &replica{}
+	assert.True(t, r.isUp(now))
+
+	r.markDown(now)
+	assert.False(t, r.isUp(now.Add(replicaRetryAfter-time.Second)))
+	assert.True(t, r.isUp(now.Add(replicaRetryAfter)))
+}
+
+func TestTxContext(t *testing.T) {
+	t.Run("gin handler should hand over the request context and the last write", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		request := httptest.NewRequest("GET", "/", nil)
+		requestCtx, cancel := context.WithCancel(request.Context())
+		c.Request = request.WithContext(requestCtx)
+		lastWrite := NewLastWrite(time.Time{})
+		c.Set(LastWriteKey, lastWrite)
+
+		ctx := txContext(c)
+		assert.Same(t, lastWrite, ctx.Value(writerKey{}))
+		cancel()
+		assert.Error(t, ctx.Err())
+	})
+
+	t.Run("context outside of a request should not be tracked", func(t *testing.T) {
+		ctx := txContext(context.Background())
+		assert.Nil(t, ctx.Value(writerKey{}))
+		assert.Nil(t, LastWriteFrom(ctx))
+	})
+}
+
+func TestAfterCommit(t *testing.T) {
+	t.Run("work should run in order once committed and only once", func(t *testing.T) {
+		tx := &gorm.DB{Statement: &gorm.Statement{Context: txContext(context.Background())}}
+		var ran []int
+		AfterCommit(tx, func() { ran = append(ran, 1) })
+		AfterCommit(tx, func() { ran = append(ran, 2) })
+		assert.Empty(t, ran)
+
+		Committed(tx)
+		assert.Equal(t, []int{1, 2}, ran)
+		Committed(tx)
+		assert.Equal(t, []int{1, 2}, ran)
+	})
+
+	t.Run("transactions should not share their work", func(t *testing.T) {
+		ctx := context.Background()
+		tx := &gorm.DB{Statement: &gorm.Statement{Context: txContext(ctx)}}
+		other := &gorm.DB{Statement: &gorm.Statement{Context: txContext(ctx)}}
+		ran := false
+		AfterCommit(tx, func() { ran = true })
+
+		Committed(other)
+		assert.False(t, ran)
+	})
+}
@@ -0,0 +1,166 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"go-api/app"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Cache stores encoded values by key. Backends treat their own failures as misses, TTL bounds how
+// long a value that couldn't be invalidated stays around.
+// ctx only carries the request the call is made for, into the logs of failures.
+type Cache interface {
+	Get(ctx context.Context, key string) ([]byte, bool)
+	// Set stores value for ttl, 0 keeps it until it's evicted or deleted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration)
+	Delete(ctx context.Context, keys ...string)
+}
+
+func PostKey(postID string) string {
+	return "post:" + postID
+}
+
+// PostCountsKey holds the engagement counts of a post apart from the post so likes and comments
+// don't throw the rest away.
+func PostCountsKey(postID string) string {
+	return "post:" + postID + ":counts"
+}
+
+func ProfileKey(username string) string {
+	return "profile:" + strings.ToLower(username)
+}
+
+// LoadTimeout bounds a shared load, which doesn't end with the request that started it.
+const LoadTimeout = 10 * time.Second
+
+// Loader reads through a Cache. Concurrent misses of the same key share a single load, and a load
+// that's still running when its key is invalidated isn't stored.
+// Other instances sharing the cache can't see that a load is stale, so Invalidate deletes the keys
+// again once any load that started before it has either stored its value or given up. Only when
+// the instance stops in between does a stale value stay, until its TTL.
+type Loader struct {
+	cache Cache
+	mu    sync.Mutex
+	calls map[string]*call
+	// redeleteAfter is longer than a load can take, stores of loads that outlive LoadTimeout are dropped.
+	redeleteAfter time.Duration
+}
+
+type call struct {
+	wg        sync.WaitGroup
+	value     []byte
+	recovered interface{}
+	stale     bool
+}
+
+func NewLoader(cache Cache) *Loader {
+	return &Loader{cache: cache, calls: map[string]*call{}, redeleteAfter: LoadTimeout + time.Second}
+}
+
+// Fetch decodes the cached value of key into v. On a miss it calls load, caches the result for
+// ttl and decodes that instead. A panic in load, like a not found error, reaches every waiting
+// caller and nothing is cached.
+// load is given ctx without its cancelation and with LoadTimeout instead, since other callers
+// wait on it, the first caller going away must not fail them all.
+func (l *Loader) Fetch(ctx context.Context, key string, ttl time.Duration, v interface{}, load func(ctx context.Context) interface{}) {
+	if data, ok := l.cache.Get(ctx, key); ok && json.Unmarshal(data, v) == nil {
+		return
+	}
+
+	err := json.Unmarshal(l.load(ctx, key, ttl, load), v)
+	if err != nil {
+		panic(err)
+	}
+}
+
+// Invalidate drops the keys, callers run it once the change is committed.
+func (l *Loader) Invalidate(ctx context.Context, keys ...string) {
+	l.mu.Lock()
+	for _, key := range keys {
+		if c, ok := l.calls[key]; ok {
+			c.stale = true
+		}
+	}
+	l.mu.Unlock()
+
+	l.cache.Delete(ctx, keys...)
+	// the request is over by then, only its ID is kept
+	later := app.WithRequestID(context.Background(), app.RequestIDFrom(ctx))
+	time.AfterFunc(l.redeleteAfter, func() {
+		l.cache.Delete(later, keys...)
+	})
+}
+
+func (l *Loader) load(ctx context.Context, key string, ttl time.Duration, load func(ctx context.Context) interface{}) []byte {
+	l.mu.Lock()
+	if c, ok := l.calls[key]; ok {
+		l.mu.Unlock()
+		c.wg.Wait()
+		if c.recovered != nil {
+			panic(c.recovered)
+		}
+		return c.value
+	}
+
+	c := &call{}
+	c.wg.Add(1)
+	l.calls[key] = c
+	l.mu.Unlock()
+
+	// a load past its timeout may predate an invalidation whose second delete already ran
+	timedOut := false
+	func() {
+		ctx, cancel := context.WithTimeout(detached{ctx}, LoadTimeout)
+		defer cancel()
+		defer func() {
+			c.recovered = recover()
+		}()
+
+		data, err := json.Marshal(load(ctx))
+		if err != nil {
+			panic(err)
+		}
+		c.value = data
+		timedOut = ctx.Err() != nil
+	}()
+
+	if c.recovered == nil && !timedOut {
+		l.cache.Set(ctx, key, c.value, ttl)
+	}
+
+	l.mu.Lock()
+	delete(l.calls, key)
+	stale := c.stale
+	l.mu.Unlock()
+	c.wg.Done()
+
+	// invalidated while loading, what was just stored may predate the change
+	if stale && c.recovered == nil {
+		l.cache.Delete(ctx, key)
+	}
+
+	if c.recovered != nil {
+		panic(c.recovered)
+	}
+	return c.value
+}
+
+// detached keeps the values of a context, like the request ID, but not its deadline or cancelation.
+type detached struct {
+	context.Context
+}
+
+func (detached) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detached) Done() <-chan struct{} {
+	return nil
+}
+
+func (detached) Err() error {
+	return nil
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	ctx := context.Background()
+	t.Run("least recently used value should be evicted first", func(t *testing.T) {
+		c := NewLRU(2)
+		c.Set(ctx, "a", []byte("1"), 0)
+		c.Set(ctx, "b", []byte("2"), 0)
+		c.Get(ctx, "a")
+		c.Set(ctx, "c", []byte("3"), 0)
+
+		_, ok := c.Get(ctx, "b")
+		assert.False(t, ok)
+		value, ok := c.Get(ctx, "a")
+		assert.True(t, ok)
+		assert.Equal(t, []byte("1"), value)
+	})
+
+	t.Run("expired value should be a miss", func(t *testing.T) {
+		now := time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC)
+		c := NewLRU(2).(*lruCache)
+		c.now = func() time.Time { return now }
+		c.Set(ctx, "a", []byte("1"), time.Minute)
+
+		_, ok := c.Get(ctx, "a")
+		assert.True(t, ok)
+		now = now.Add(time.Minute)
+		_, ok = c.Get(ctx, "a")
+		assert.False(t, ok)
+	})
+}
+
+func TestLoader(t *testing.T) {
+	ctx := context.Background()
+	t.Run("concurrent misses should share one load", func(t *testing.T) {
+		loader := NewLoader(NewLRU(10))
+		release := make(chan struct{})
+		var loads int32
+
+		var wg sync.WaitGroup
+		results := make([]string, 10)
+		for i := range results {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				loader.Fetch(ctx, "key", time.Minute, &results[i], func(ctx context.Context) interface{} {
+					atomic.AddInt32(&loads, 1)
+					<-release
+					return "value"
+				})
+			}(i)
+		}
+
+		time.Sleep(20 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		assert.Equal(t, int32(1), loads)
+		for _, result := range results {
+			assert.Equal(t, "value", result)
+		}
+	})
+
+	t.Run("cached value should be returned without loading", func(t *testing.T) {
+		loader := NewLoader(NewLRU(10))
+		var value string
+		loader.Fetch(ctx, "key", time.Minute, &value, func(ctx context.Context) interface{} { return "first" })
+		loader.Fetch(ctx, "key", time.Minute, &value, func(ctx context.Context) interface{} { return "second" })
+		assert.Equal(t, "first", value)
+
+		loader.Invalidate(ctx, "key")
+		loader.Fetch(ctx, "key", time.Minute, &value, func(ctx context.Context) interface{} { return "second" })
+		assert.Equal(t, "second", value)
+	})
+
+	t.Run("load invalidated midway should not be cached", func(t *testing.T) {
+		loader := NewLoader(NewLRU(10))
+		var value string
+		loader.Fetch(ctx, "key", time.Minute, &value, func(ctx context.Context) interface{} {
+			loader.Invalidate(ctx, "key")
+			return "stale"
+		})
+		assert.Equal(t, "stale", value)
+
+		loader.Fetch(ctx, "key", time.Minute, &value, func(ctx context.Context) interface{} { return "fresh" })
+		assert.Equal(t, "fresh", value)
+	})
+
+	t.Run("panicking load should reach the caller and cache nothing", func(t *testing.T) {
+		loader := NewLoader(NewLRU(10))
+		var value string
+		assert.PanicsWithValue(t, "not found", func() {
+			loader.Fetch(ctx, "key", time.Minute, &value, func(ctx context.Context) interface{} { panic("not found") })
+		})
+
+		loader.Fetch(ctx, "key", time.Minute, &value, func(ctx context.Context) interface{} { return "found" })
+		assert.Equal(t, "found", value)
+	})
+
+	t.Run("load should outlive the caller's cancelation but keep its values", func(t *testing.T) {
+		loader := NewLoader(NewLRU(10))
+		type key struct{}
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), key{}, "request"))
+		cancel()
+
+		var value string
+		loader.Fetch(ctx, "key", time.Minute, &value, func(ctx context.Context) interface{} {
+			assert.NoError(t, ctx.Err())
+			deadline, ok := ctx.Deadline()
+			assert.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(LoadTimeout), deadline, time.Second)
+			return ctx.Value(key{})
+		})
+		assert.Equal(t, "request", value)
+	})
+	t.Run("invalidated key should be deleted again after loads elsewhere had their time", func(t *testing.T) {
+		c := NewLRU(10)
+		loader := NewLoader(c)
+		loader.redeleteAfter = 10 * time.Millisecond
+
+		loader.Invalidate(ctx, "key")
+		// what a load on another instance that read before the change stores late
+		c.Set(ctx, "key", []byte(`"stale"`), time.Minute)
+
+		assert.Eventually(t, func() bool {
+			_, ok := c.Get(ctx, "key")
+			return !ok
+		}, time.Second, 5*time.Millisecond)
+	})
+}
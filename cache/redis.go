@@ -0,0 +1,184 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"go-api/app"
+	"io"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	redisPoolSize = 10
+	redisTimeout  = 500 * time.Millisecond
+)
+
+type redisConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// redisCache talks RESP to a Redis compatible server, so every instance of the API shares it.
+type redisCache struct {
+	addr string
+	pool chan *redisConn
+}
+
+func NewRedis(addr string) Cache {
+	return &redisCache{addr: addr, pool: make(chan *redisConn, redisPoolSize)}
+}
+
+func (c *redisCache) Get(ctx context.Context, key string) ([]byte, bool) {
+	reply, err := c.do("GET", key)
+	if err != nil {
+		app.Logger(ctx).Warn("cache get failed", "key", key, "error", err)
+		return nil, false
+	}
+
+	value, ok := reply.([]byte)
+	return value, ok
+}
+
+func (c *redisCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) {
+	args := []string{"SET", key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+
+	_, err := c.do(args...)
+	if err != nil {
+		app.Logger(ctx).Warn("cache set failed", "key", key, "error", err)
+	}
+}
+
+func (c *redisCache) Delete(ctx context.Context, keys ...string) {
+	if len(keys) == 0 {
+		return
+	}
+
+	_, err := c.do(append([]string{"DEL"}, keys...)...)
+	if err != nil {
+		app.Logger(ctx).Warn("cache delete failed", "keys", keys, "error", err)
+	}
+}
+
+func (c *redisCache) do(args ...string) (interface{}, error) {
+	conn, err := c.conn()
+	if err != nil {
+		return nil, err
+	}
+
+	reply, err := roundTrip(conn, args)
+	if err != nil {
+		// the connection may be halfway through a reply, it can't be reused
+		conn.Close()
+		return nil, err
+	}
+
+	select {
+	case c.pool <- conn:
+	default:
+		conn.Close()
+	}
+
+	if replyErr, ok := reply.(error); ok {
+		return nil, replyErr
+	}
+	return reply, nil
+}
+
+func (c *redisCache) conn() (*redisConn, error) {
+	select {
+	case conn := <-c.pool:
+		return conn, nil
+	default:
+	}
+
+	conn, err := net.DialTimeout("tcp", c.addr, redisTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return &redisConn{Conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+func roundTrip(conn *redisConn, args []string) (interface{}, error) {
+	err := conn.SetDeadline(time.Now().Add(redisTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = conn.Write(encodeCommand(args))
+	if err != nil {
+		return nil, err
+	}
+	return readReply(conn.reader)
+}
+
+func encodeCommand(args []string) []byte {
+	buf := []byte("*" + strconv.Itoa(len(args)) + "\r\n")
+	for _, arg := range args {
+		buf = append(buf, "$"+strconv.Itoa(len(arg))+"\r\n"...)
+		buf = append(buf, arg...)
+		buf = append(buf, "\r\n"...)
+	}
+	return buf
+}
+
+// readReply returns a bulk string as []byte, nil for a null bulk string and server errors as error values.
+func readReply(r *bufio.Reader) (interface{}, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, errors.New("malformed reply")
+	}
+	kind, body := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return body, nil
+	case '-':
+		return errors.New(body), nil
+	case ':':
+		return strconv.ParseInt(body, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		data := make([]byte, n+2)
+		_, err = io.ReadFull(r, data)
+		if err != nil {
+			return nil, err
+		}
+		return data[:n], nil
+	case '*':
+		n, err := strconv.Atoi(body)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+
+		items := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			item, err := readReply(r)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unknown reply type %q", kind)
+}
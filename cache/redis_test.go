@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serveRedis runs a local server answering the GET, SET and DEL commands the cache sends.
+func serveRedis(t *testing.T) string {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	var mu sync.Mutex
+	values := map[string]string{}
+	expiries := map[string]time.Time{}
+
+	handle := func(args []string) string {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch args[0] {
+		case "GET":
+			value, ok := values[args[1]]
+			if !ok || (!expiries[args[1]].IsZero() && time.Now().After(expiries[args[1]])) {
+				return "$-1\r\n"
+			}
+			return "$" + strconv.Itoa(len(value)) + "\r\n" + value + "\r\n"
+		case "SET":
+			values[args[1]] = args[2]
+			delete(expiries, args[1])
+			if len(args) == 5 && args[3] == "PX" {
+				ms, _ := strconv.Atoi(args[4])
+				expiries[args[1]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+			}
+			return "+OK\r\n"
+		case "DEL":
+			deleted := 0
+			for _, key := range args[1:] {
+				if _, ok := values[key]; ok {
+					delete(values, key)
+					deleted++
+				}
+			}
+			return ":" + strconv.Itoa(deleted) + "\r\n"
+		}
+		return "-ERR unknown command\r\n"
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					reply, err := readReply(r)
+					if err != nil {
+						return
+					}
+					var args []string
+					for _, arg := range reply.([]interface{}) {
+						args = append(args, string(arg.([]byte)))
+					}
+					conn.Write([]byte(handle(args)))
+				}
+			}()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestRedis(t *testing.T) {
+	ctx := context.Background()
+	c := NewRedis(serveRedis(t))
+
+	_, ok := c.Get(ctx, "missing")
+	assert.False(t, ok)
+
+	c.Set(ctx, "key", []byte("line\r\nbreak"), 0)
+	value, ok := c.Get(ctx, "key")
+	assert.True(t, ok)
+	assert.Equal(t, []byte("line\r\nbreak"), value)
+
+	c.Set(ctx, "short", []byte("1"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	_, ok = c.Get(ctx, "short")
+	assert.False(t, ok)
+
+	c.Delete(ctx, "key")
+	_, ok = c.Get(ctx, "key")
+	assert.False(t, ok)
+}
+
+func TestRedisUnavailable(t *testing.T) {
+	ctx := context.Background()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	c := NewRedis(addr)
+	c.Set(ctx, "key", []byte("1"), 0)
+	_, ok := c.Get(ctx, "key")
+	assert.False(t, ok)
+}
@@ -1,5 +1,7 @@
 package exception
 
+import "time"
+
 type (
 	Errors struct {
 		Errors []error `json:"errors"`
@@ -29,6 +31,19 @@ type (
 		Message string `json:"message"`
 	}
 
+	ConflictError struct {
+		Message string `json:"message"`
+	}
+
+	CredentialError struct {
+		Message string `json:"message"`
+	}
+
+	TooManyRequestsError struct {
+		Message    string        `json:"message"`
+		RetryAfter time.Duration `json:"-"`
+	}
+
 	FieldError struct {
 		Field   string `json:"field"`
 		Message string `json:"message"`
@@ -56,9 +71,21 @@ func (e NoAccessError) Error() string {
 }
 
 func (e DuplicateError) Error() string {
-    return e.Message
+	return e.Message
+}
+
+func (e CredentialError) Error() string {
+	return e.Message
+}
+
+func (e TooManyRequestsError) Error() string {
+	return e.Message
 }
 
 func (e FieldError) Error() string {
 	return e.Message
 }
+
+func (e ConflictError) Error() string {
+	return e.Message
+}
@@ -1,6 +1,7 @@
 package helper
 
 import (
+	"go-api/app"
 	"gorm.io/gorm"
 )
 
@@ -14,4 +15,5 @@ func TXCommitOrRollback(tx *gorm.DB) {
 	if err != nil {
 		panic(err)
 	}
+	app.Committed(tx)
 }
@@ -7,24 +7,34 @@ import (
 
 var jwtSecretKey = []byte("jwt-secret-key")
 
-func GenerateJWT(uid, username string) (string, error) {
-	payload := jwt.NewWithClaims(jwt.SigningMethodHS256, &jwt.StandardClaims{
-		ExpiresAt: jwt.TimeFunc().Add(time.Hour * 24).Unix(),
-		Id:        uid,
-		IssuedAt:  jwt.TimeFunc().Unix(),
-		Issuer:    "instapounds",
-		NotBefore: jwt.TimeFunc().Unix(),
-		Subject:   username,
+const JWTLifetime = time.Hour * 24
+
+type Claims struct {
+	SessionID string `json:"sid"`
+	jwt.StandardClaims
+}
+
+func GenerateJWT(uid, username, sessionID string) (string, error) {
+	payload := jwt.NewWithClaims(jwt.SigningMethodHS256, &Claims{
+		SessionID: sessionID,
+		StandardClaims: jwt.StandardClaims{
+			ExpiresAt: jwt.TimeFunc().Add(JWTLifetime).Unix(),
+			Id:        uid,
+			IssuedAt:  jwt.TimeFunc().Unix(),
+			Issuer:    "instapounds",
+			NotBefore: jwt.TimeFunc().Unix(),
+			Subject:   username,
+		},
 	})
 	token, err := payload.SignedString(jwtSecretKey)
 	return token, err
 }
 
-func ValidateJWT(tokenString string) (*jwt.StandardClaims, error) {
-	token, err := jwt.ParseWithClaims(tokenString, &jwt.StandardClaims{}, func(token *jwt.Token) (interface{}, error) {
+func ValidateJWT(tokenString string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		return jwtSecretKey, nil
 	})
-	claims, ok := token.Claims.(*jwt.StandardClaims)
+	claims, ok := token.Claims.(*Claims)
 	if !ok || !token.Valid {
 		return nil, err
 	}
@@ -0,0 +1,63 @@
+package helper
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"go-api/app"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// mediaURLSecretKey signs media links, it's set at startup from outside the code.
+var mediaURLSecretKey []byte
+
+func SetMediaURLSecret(key []byte) {
+	mediaURLSecretKey = key
+}
+
+const (
+	MediaURLLifetime = time.Hour
+	// mediaURLStep rounds the expiry so a viewer gets the same URL for a while and clients can cache it.
+	mediaURLStep = 30 * time.Minute
+)
+
+// SignMediaURL returns a link to the stored path that only works for viewerID until it expires.
+func SignMediaURL(path, viewerID string) string {
+	if path == "" {
+		return ""
+	}
+
+	expires := time.Now().Truncate(mediaURLStep).Add(MediaURLLifetime).Unix()
+	query := url.Values{}
+	query.Set("uid", viewerID)
+	query.Set("exp", strconv.FormatInt(expires, 10))
+	query.Set("sig", mediaSignature(path, viewerID, expires))
+	return app.GetStorage().URL(path) + "?" + query.Encode()
+}
+
+// VerifyMediaURL checks the signature query of a media link and returns when it expires.
+func VerifyMediaURL(path, viewerID, exp, signature string) (time.Time, error) {
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		return time.Time{}, errors.New("media link is malformed")
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(mediaSignature(path, viewerID, expires))) {
+		return time.Time{}, errors.New("media link signature is invalid")
+	}
+
+	expiresAt := time.Unix(expires, 0)
+	if !time.Now().Before(expiresAt) {
+		return time.Time{}, errors.New("media link has expired")
+	}
+	return expiresAt, nil
+}
+
+func mediaSignature(path, viewerID string, expires int64) string {
+	mac := hmac.New(sha256.New, mediaURLSecretKey)
+	mac.Write([]byte(path + "\n" + viewerID + "\n" + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
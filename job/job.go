@@ -0,0 +1,38 @@
+package job
+
+import (
+	"context"
+	uuid "github.com/satori/go.uuid"
+	"go-api/app"
+	"runtime/debug"
+	"time"
+)
+
+// Every runs task right away and then on each interval until ctx is done.
+// A panicking run is logged and the job keeps its schedule.
+func Every(ctx context.Context, name string, interval time.Duration, task func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		run(ctx, name, task)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// run logs every run under an ID of its own, like a request, so its lines can be told apart.
+func run(ctx context.Context, name string, task func(ctx context.Context)) {
+	ctx = app.WithRequestID(ctx, uuid.NewV4().String())
+	defer func() {
+		if err := recover(); err != nil {
+			app.Logger(ctx).Error("job failed", "job", name, "error", err, "stack", string(debug.Stack()))
+		}
+	}()
+
+	task(ctx)
+}
@@ -0,0 +1,32 @@
+package job
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEvery(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	runs := 0
+
+	done := make(chan struct{})
+	go func() {
+		Every(ctx, "test", time.Millisecond, func(ctx context.Context) {
+			runs++
+			if runs == 3 {
+				cancel()
+			}
+			panic("failing run should not stop the job")
+		})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("job did not stop after context was cancelled")
+	}
+	assert.Equal(t, 3, runs)
+}
@@ -0,0 +1,183 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	}
+	return "ERROR"
+}
+
+// Redacted replaces the value of every attribute whose key looks sensitive.
+const Redacted = "[REDACTED]"
+
+var sensitiveKeys = []string{"password", "secret", "token", "authorization", "cookie"}
+
+// Logger writes one JSON object per line, attributes are passed slog style as alternating keys and values.
+type Logger struct {
+	out   io.Writer
+	mu    *sync.Mutex
+	level Level
+	attrs []interface{}
+	now   func() time.Time
+}
+
+func New(out io.Writer, level Level) *Logger {
+	return &Logger{out: out, mu: &sync.Mutex{}, level: level, now: time.Now}
+}
+
+var defaultLogger = New(os.Stdout, LevelInfo)
+
+// Default is the logger shared by the whole app.
+func Default() *Logger {
+	return defaultLogger
+}
+
+func SetDefault(l *Logger) {
+	defaultLogger = l
+}
+
+// With returns a logger that adds args to every line.
+func (l *Logger) With(args ...interface{}) *Logger {
+	child := *l
+	child.attrs = append(append([]interface{}{}, l.attrs...), args...)
+	return &child
+}
+
+func (l *Logger) Debug(msg string, args ...interface{}) {
+	l.log(LevelDebug, msg, args)
+}
+
+func (l *Logger) Info(msg string, args ...interface{}) {
+	l.log(LevelInfo, msg, args)
+}
+
+func (l *Logger) Warn(msg string, args ...interface{}) {
+	l.log(LevelWarn, msg, args)
+}
+
+func (l *Logger) Error(msg string, args ...interface{}) {
+	l.log(LevelError, msg, args)
+}
+
+func Info(msg string, args ...interface{}) {
+	defaultLogger.log(LevelInfo, msg, args)
+}
+
+func Warn(msg string, args ...interface{}) {
+	defaultLogger.log(LevelWarn, msg, args)
+}
+
+func Error(msg string, args ...interface{}) {
+	defaultLogger.log(LevelError, msg, args)
+}
+
+func (l *Logger) log(level Level, msg string, args []interface{}) {
+	if level < l.level {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	writeAttr(buf, "time", l.now().UTC().Format(time.RFC3339Nano))
+	buf.WriteByte(',')
+	writeAttr(buf, "level", level.String())
+	buf.WriteByte(',')
+	writeAttr(buf, "msg", msg)
+
+	attrs := append(append([]interface{}{}, l.attrs...), args...)
+	for i := 0; i < len(attrs); i += 2 {
+		key, ok := attrs[i].(string)
+		if !ok || i+1 == len(attrs) {
+			// a value without a key is kept rather than dropped
+			key = "!BADKEY"
+			i--
+		}
+		buf.WriteByte(',')
+		writeAttr(buf, key, redact(key, attrs[i+1]))
+	}
+	buf.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.out.Write(buf.Bytes())
+}
+
+func writeAttr(buf *bytes.Buffer, key string, value interface{}) {
+	encodedKey, _ := json.Marshal(key)
+	buf.Write(encodedKey)
+	buf.WriteByte(':')
+
+	switch v := value.(type) {
+	case error:
+		value = v.Error()
+	case fmt.Stringer:
+		value = v.String()
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		encoded, _ = json.Marshal(fmt.Sprint(value))
+	}
+	buf.Write(encoded)
+}
+
+// redact hides the value of a sensitive key, and of sensitive keys nested in maps.
+func redact(key string, value interface{}) interface{} {
+	if IsSensitive(key) {
+		return Redacted
+	}
+
+	switch v := value.(type) {
+	case map[string]interface{}:
+		redacted := make(map[string]interface{}, len(v))
+		for k, nested := range v {
+			redacted[k] = redact(k, nested)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for k, nested := range v {
+			if IsSensitive(k) {
+				nested = Redacted
+			}
+			redacted[k] = nested
+		}
+		return redacted
+	}
+	return value
+}
+
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
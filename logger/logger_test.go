@@ -0,0 +1,64 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestLogger(level Level) (*Logger, *bytes.Buffer) {
+	buf := &bytes.Buffer{}
+	l := New(buf, level)
+	l.now = func() time.Time { return time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC) }
+	return l, buf
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("line should be JSON with attributes in order", func(t *testing.T) {
+		l, buf := newTestLogger(LevelInfo)
+
+		l.With("request_id", "abc").Info("request", "status", 200, "error", errors.New("boom"))
+
+		assert.Equal(t, `{"time":"2021-01-02T03:04:05Z","level":"INFO","msg":"request","request_id":"abc","status":200,"error":"boom"}`+"\n", buf.String())
+	})
+
+	t.Run("levels below the logger's should be skipped", func(t *testing.T) {
+		l, buf := newTestLogger(LevelWarn)
+
+		l.Info("dropped")
+		l.Warn("kept")
+
+		assert.Equal(t, 1, strings.Count(buf.String(), "\n"))
+		assert.Contains(t, buf.String(), `"msg":"kept"`)
+	})
+
+	t.Run("sensitive values should be redacted, nested ones too", func(t *testing.T) {
+		l, buf := newTestLogger(LevelInfo)
+
+		l.Info("login", "Password", "hunter2", "body", map[string]interface{}{
+			"username": "john",
+			"auth":     map[string]string{"access_token": "t0k3n"},
+		})
+
+		var line map[string]interface{}
+		assert.Nil(t, json.Unmarshal(buf.Bytes(), &line))
+		assert.Equal(t, Redacted, line["Password"])
+		body := line["body"].(map[string]interface{})
+		assert.Equal(t, "john", body["username"])
+		assert.Equal(t, Redacted, body["auth"].(map[string]interface{})["access_token"])
+		assert.NotContains(t, buf.String(), "hunter2")
+		assert.NotContains(t, buf.String(), "t0k3n")
+	})
+
+	t.Run("value without a key should be kept", func(t *testing.T) {
+		l, buf := newTestLogger(LevelInfo)
+
+		l.Info("odd", "status")
+
+		assert.Contains(t, buf.String(), `"!BADKEY":"status"`)
+	})
+}
@@ -1,19 +1,45 @@
 package main
 
 import (
+	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator"
 	"go-api/app"
+	"go-api/cache"
+	"go-api/helper"
+	"go-api/job"
+	"go-api/logger"
+	"go-api/metrics"
 	"go-api/middleware"
+	"go-api/model/admin"
+	"go-api/model/audit"
+	"go-api/model/blob"
 	"go-api/model/comment"
+	"go-api/model/explore"
 	"go-api/model/like"
+	"go-api/model/location"
+	"go-api/model/media"
+	"go-api/model/notification"
 	"go-api/model/post"
+	"go-api/model/relation"
 	"go-api/model/resource"
+	"go-api/model/search"
+	"go-api/model/session"
+	"go-api/model/story"
+	"go-api/model/tag"
+	"go-api/model/token"
+	"go-api/model/upload"
 	"go-api/model/user"
+	"net/http"
+	"strings"
 )
 
 func main() {
 	app.Init()
+	// app.InitReplicas(dsn, ...) sends read-only transactions to replicas, without it they stay on the primary
+	app.InitStorage("res")
+	audit.SetHashKey([]byte(app.MustEnv("AUDIT_HMAC_KEY")))
+	helper.SetMediaURLSecret([]byte(app.MustEnv("MEDIA_URL_SECRET")))
 	validate := validator.New()
 
 	// repositories
@@ -22,23 +48,100 @@ func main() {
 	likeRepository := like.NewRepository()
 	commentRepository := comment.NewRepository()
 	resourceRepository := resource.NewRepository()
+	sessionRepository := session.NewRepository()
+	tokenRepository := token.NewRepository()
+	auditRepository := audit.NewRepository()
+	relationRepository := relation.NewRepository()
+	exploreRepository := explore.NewRepository()
+	storyRepository := story.NewRepository()
+	uploadRepository := upload.NewRepository()
+	blobRepository := blob.NewRepository()
+	tagRepository := tag.NewRepository()
+	locationRepository := location.NewRepository()
+	notificationRepository := notification.NewRepository()
+
+	// cache.NewRedis("localhost:6379") in place of the LRU shares the cache between instances
+	cacheLoader := cache.NewLoader(cache.NewLRU(10000))
 
 	// services
-	userService := user.NewService(validate, userRepository)
-	postService := post.NewService(validate, postRepository, resourceRepository, likeRepository, commentRepository)
-	likeService := like.NewService(validate, likeRepository)
-	commentService := comment.NewService(validate, commentRepository)
+	searchService := search.NewService(validate, search.NewRepository(), search.NewMemoryIndexer())
+	userService := user.NewService(validate, userRepository, sessionRepository, auditRepository, relationRepository, searchService, user.NewMemoryAttemptStore(), cacheLoader)
+	uploadService := upload.NewService(validate, uploadRepository)
+	postService := post.NewService(validate, postRepository, resourceRepository, likeRepository, commentRepository, auditRepository, searchService, uploadService, blobRepository, tagRepository, locationRepository, userRepository, relationRepository, notificationRepository, cacheLoader)
+	likeService := like.NewService(validate, likeRepository, cacheLoader)
+	commentService := comment.NewService(validate, commentRepository, likeRepository, cacheLoader)
+	sessionService := session.NewService(validate, sessionRepository)
+	tokenService := token.NewService(validate, tokenRepository)
+	auditService := audit.NewService(validate, auditRepository)
+	exploreService := explore.NewService(validate, exploreRepository, relationRepository, resourceRepository)
+	storyService := story.NewService(validate, storyRepository, userRepository, relationRepository, blobRepository)
+	notificationService := notification.NewService(notificationRepository)
+	mediaService := media.NewService(resourceRepository, blobRepository, postRepository, storyRepository, userRepository, relationRepository)
+	adminService := admin.NewService(validate, userRepository, sessionRepository, tokenRepository, postRepository, commentRepository, likeRepository, tagRepository, auditRepository, relationRepository, searchService, cacheLoader)
 
 	// controllers
 	userController := user.NewController(userService)
 	postController := post.NewController(postService)
 	likeController := like.NewController(likeService)
 	commentController := comment.NewController(commentService)
+	sessionController := session.NewController(sessionService)
+	tokenController := token.NewController(tokenService)
+	adminController := admin.NewController(adminService)
+	searchController := search.NewController(searchService)
+	auditController := audit.NewController(auditService)
+	exploreController := explore.NewController(exploreService)
+	storyController := story.NewController(storyService)
+	mediaController := media.NewController(mediaService)
+	uploadController := upload.NewController(uploadService)
+	notificationController := notification.NewController(notificationService)
+
+	// the embedded search index lives in memory, so it's filled from the database on every start
+	// and then rebuilt on a schedule, which brings in what was changed through other instances
+	rebuildSearch := func(ctx context.Context) {
+		searchService.Rebuild(func(index search.Service) {
+			userService.Reindex(ctx, index)
+			postService.Reindex(ctx, index)
+		})
+	}
+	likeService.MigrateReactions(context.Background())
+	rebuildSearch(context.Background())
+	// ADMIN_USERNAME names a registered user made the first admin, the rest are promoted through the admin API
+	if username := app.Env("ADMIN_USERNAME", ""); username != "" {
+		userService.BootstrapAdmin(context.Background(), username)
+	}
 
-	router := gin.Default()
-	router.Use(middleware.JWTValidator())
+	// background jobs
+	go job.Every(context.Background(), "explore", explore.RecomputeInterval, exploreService.Recompute)
+	go job.Every(context.Background(), "story cleanup", story.CleanupInterval, storyService.Cleanup)
+	go job.Every(context.Background(), "post purge", post.PurgeInterval, postService.Purge)
+	go job.Every(context.Background(), "search rebuild", search.RebuildInterval, rebuildSearch)
+	go job.Every(context.Background(), "post scheduler", post.PublishInterval, postService.PublishDue)
+	go job.Every(context.Background(), "login attempts cleanup", user.AttemptCleanupInterval, userService.CleanupAttempts)
+	go job.Every(context.Background(), "upload cleanup", upload.CleanupInterval, uploadService.Cleanup)
+	go job.Every(context.Background(), "media gc", media.GCInterval, func(ctx context.Context) {
+		mediaService.GC(ctx, &media.GCRequest{})
+	})
+	// the first run right at startup also fills in the counters of posts from before they existed
+	go job.Every(context.Background(), "post counters", post.ReconcileInterval, func(ctx context.Context) {
+		postService.ReconcileCounters(ctx)
+	})
+
+	// recovery runs inside the request logger so the errors it handles get logged with the request
+	router := gin.New()
+	// client IPs key the login throttle, so forwarding headers are only believed from the proxies
+	// listed in TRUSTED_PROXIES, comma separated addresses or CIDRs, by default from none
+	router.TrustedProxies = nil
+	if proxies := app.Env("TRUSTED_PROXIES", ""); proxies != "" {
+		router.TrustedProxies = strings.Split(proxies, ",")
+	}
+	router.Use(middleware.RequestID())
+	router.Use(middleware.StickyReads())
+	router.Use(middleware.Metrics())
+	router.Use(middleware.RequestLogger(logger.Default()))
 	router.Use(gin.CustomRecovery(middleware.PanicHandler))
+	router.Use(middleware.JWTValidator(sessionService, tokenService))
 
+	media.InitRoutes(&router.RouterGroup, mediaController)
 	apiGroup := router.Group("/api")
 
 	// routes
@@ -46,6 +149,27 @@ func main() {
 	post.InitRoutes(apiGroup, postController)
 	like.InitRoutes(apiGroup, likeController)
 	comment.InitRoutes(apiGroup, commentController)
+	session.InitRoutes(apiGroup, sessionController)
+	token.InitRoutes(apiGroup, tokenController)
+	admin.InitRoutes(apiGroup, adminController, userService)
+	search.InitRoutes(apiGroup, searchController)
+	explore.InitRoutes(apiGroup, exploreController)
+	story.InitRoutes(apiGroup, storyController)
+	upload.InitRoutes(apiGroup, uploadController)
+	notification.InitRoutes(apiGroup, notificationController)
+	audit.InitRoutes(apiGroup, auditController, middleware.RequirePermission(userService, user.PermissionAuditRead))
+	media.InitAdminRoutes(apiGroup, mediaController, middleware.RequirePermission(userService, user.PermissionMediaGC))
+
+	// metrics are served unauthenticated on their own address, only the host can reach it unless
+	// METRICS_ADDR opens it to a network the scraper is on
+	go func() {
+		metricsMux := http.NewServeMux()
+		metricsMux.Handle("/metrics", metrics.Handler())
+		err := http.ListenAndServe(app.Env("METRICS_ADDR", "127.0.0.1:9090"), metricsMux)
+		if err != nil {
+			panic(err)
+		}
+	}()
 
 	err := router.Run(":3000")
 	if err != nil {
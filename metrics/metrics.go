@@ -0,0 +1,277 @@
+package metrics
+
+import (
+	"bufio"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are upper bounds in seconds, from 5ms to 10s.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+var (
+	mu      sync.Mutex
+	metrics []metric
+)
+
+func register(m metric) {
+	mu.Lock()
+	defer mu.Unlock()
+	metrics = append(metrics, m)
+}
+
+// Handler serves every registered metric in the Prometheus text format.
+func Handler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+		mu.Lock()
+		registered := append([]metric{}, metrics...)
+		mu.Unlock()
+
+		w := bufio.NewWriter(rw)
+		for _, m := range registered {
+			m.write(w)
+		}
+		_ = w.Flush()
+	})
+}
+
+type desc struct {
+	name   string
+	help   string
+	kind   string
+	labels []string
+}
+
+func (d *desc) writeHeader(w *bufio.Writer) {
+	w.WriteString("# HELP " + d.name + " " + strings.ReplaceAll(d.help, "\n", " ") + "\n")
+	w.WriteString("# TYPE " + d.name + " " + d.kind + "\n")
+}
+
+// series keeps the values of one metric by label values, in the order they first appeared.
+type series struct {
+	desc
+	mu     sync.Mutex
+	keys   []string
+	values map[string][]string
+}
+
+func (s *series) init(name, help, kind string, labels []string) {
+	s.desc = desc{name, help, kind, labels}
+	s.values = map[string][]string{}
+	if len(labels) == 0 {
+		// a metric without labels is exposed as 0 before anything happens
+		s.key(nil)
+	}
+}
+
+func (s *series) key(labelValues []string) string {
+	if len(labelValues) != len(s.labels) {
+		panic("metric " + s.name + " expects labels " + strings.Join(s.labels, ", "))
+	}
+	key := strings.Join(labelValues, "\xff")
+	if _, ok := s.values[key]; !ok {
+		s.keys = append(s.keys, key)
+		s.values[key] = append([]string{}, labelValues...)
+	}
+	return key
+}
+
+// Counter only goes up, like requests served or bytes received.
+type Counter struct {
+	series
+	counts map[string]float64
+}
+
+func NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{counts: map[string]float64{}}
+	c.init(name, help, "counter", labels)
+	register(c)
+	return c
+}
+
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic("counter " + c.name + " can't decrease")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.counts[c.key(labelValues)] += delta
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(w)
+	for _, key := range c.keys {
+		writeSample(w, c.name, c.labels, c.values[key], "", "", c.counts[key])
+	}
+}
+
+// Gauge is a value that goes up and down, like requests in flight.
+type Gauge struct {
+	series
+	current map[string]float64
+}
+
+func NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{current: map[string]float64{}}
+	g.init(name, help, "gauge", labels)
+	register(g)
+	return g
+}
+
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.current[g.key(labelValues)] = value
+}
+
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.current[g.key(labelValues)] += delta
+}
+
+func (g *Gauge) write(w *bufio.Writer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.writeHeader(w)
+	for _, key := range g.keys {
+		writeSample(w, g.name, g.labels, g.values[key], "", "", g.current[key])
+	}
+}
+
+// Histogram counts observations, like latencies, into cumulative buckets.
+type Histogram struct {
+	series
+	buckets []float64
+	counts  map[string][]uint64
+	sums    map[string]float64
+}
+
+func NewHistogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = append([]float64{}, buckets...)
+	sort.Float64s(buckets)
+
+	h := &Histogram{buckets: buckets, counts: map[string][]uint64{}, sums: map[string]float64{}}
+	h.init(name, help, "histogram", labels)
+	register(h)
+	return h
+}
+
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := h.key(labelValues)
+	counts, ok := h.counts[key]
+	if !ok {
+		// the last one is +Inf
+		counts = make([]uint64, len(h.buckets)+1)
+		h.counts[key] = counts
+	}
+
+	i := sort.SearchFloat64s(h.buckets, value)
+	for ; i < len(counts); i++ {
+		counts[i]++
+	}
+	h.sums[key] += value
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w)
+	for _, key := range h.keys {
+		counts, ok := h.counts[key]
+		if !ok {
+			counts = make([]uint64, len(h.buckets)+1)
+		}
+		for i, bound := range h.buckets {
+			writeSample(w, h.name+"_bucket", h.labels, h.values[key], "le", formatFloat(bound), float64(counts[i]))
+		}
+		total := float64(counts[len(h.buckets)])
+		writeSample(w, h.name+"_bucket", h.labels, h.values[key], "le", "+Inf", total)
+		writeSample(w, h.name+"_sum", h.labels, h.values[key], "", "", h.sums[key])
+		writeSample(w, h.name+"_count", h.labels, h.values[key], "", "", total)
+	}
+}
+
+// funcMetric reads its value when it's scraped, for values kept elsewhere like the stats of a pool.
+type funcMetric struct {
+	desc
+	value func() float64
+}
+
+func NewGaugeFunc(name, help string, value func() float64) {
+	register(&funcMetric{desc{name, help, "gauge", nil}, value})
+}
+
+// NewCounterFunc is for totals something else keeps count of, value must never decrease.
+func NewCounterFunc(name, help string, value func() float64) {
+	register(&funcMetric{desc{name, help, "counter", nil}, value})
+}
+
+func (f *funcMetric) write(w *bufio.Writer) {
+	f.writeHeader(w)
+	writeSample(w, f.name, nil, nil, "", "", f.value())
+}
+
+func writeSample(w *bufio.Writer, name string, labels, labelValues []string, extraLabel, extraValue string, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extraLabel != "" {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, label, labelValues[i])
+		}
+		if extraLabel != "" {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			writeLabel(w, extraLabel, extraValue)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(value))
+	w.WriteByte('\n')
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func writeLabel(w *bufio.Writer, label, value string) {
+	w.WriteString(label + `="` + labelEscaper.Replace(value) + `"`)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
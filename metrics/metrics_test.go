@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"testing"
+)
+
+func scrape() string {
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	return rec.Body.String()
+}
+
+func TestMetrics(t *testing.T) {
+	t.Run("counter should be exposed by label values", func(t *testing.T) {
+		c := NewCounter("test_requests_total", "Requests.", "route", "status")
+		c.Inc("/api/post/:postID", "200")
+		c.Add(2, "/api/post/:postID", "200")
+		c.Inc("/api/post/:postID", "404")
+
+		body := scrape()
+		assert.Contains(t, body, "# HELP test_requests_total Requests.\n# TYPE test_requests_total counter\n")
+		assert.Contains(t, body, `test_requests_total{route="/api/post/:postID",status="200"} 3`+"\n")
+		assert.Contains(t, body, `test_requests_total{route="/api/post/:postID",status="404"} 1`+"\n")
+	})
+
+	t.Run("metric without labels should start at 0", func(t *testing.T) {
+		NewCounter("test_untouched_total", "Untouched.")
+		NewHistogram("test_untouched_seconds", "Untouched.", []float64{1})
+
+		body := scrape()
+		assert.Contains(t, body, "test_untouched_total 0\n")
+		assert.Contains(t, body, "test_untouched_seconds_count 0\n")
+	})
+
+	t.Run("histogram buckets should be cumulative", func(t *testing.T) {
+		h := NewHistogram("test_duration_seconds", "Durations.", []float64{0.1, 1}, "op")
+		h.Observe(0.05, "query")
+		h.Observe(0.1, "query")
+		h.Observe(0.5, "query")
+		h.Observe(3, "query")
+
+		body := scrape()
+		assert.Contains(t, body, `test_duration_seconds_bucket{op="query",le="0.1"} 2`+"\n")
+		assert.Contains(t, body, `test_duration_seconds_bucket{op="query",le="1"} 3`+"\n")
+		assert.Contains(t, body, `test_duration_seconds_bucket{op="query",le="+Inf"} 4`+"\n")
+		assert.Contains(t, body, `test_duration_seconds_sum{op="query"} 3.65`+"\n")
+		assert.Contains(t, body, `test_duration_seconds_count{op="query"} 4`+"\n")
+	})
+
+	t.Run("gauge should go up and down", func(t *testing.T) {
+		g := NewGauge("test_in_flight", "In flight.")
+		g.Add(2)
+		g.Add(-1)
+
+		assert.Contains(t, scrape(), "test_in_flight 1\n")
+	})
+
+	t.Run("func metric should be read when scraped", func(t *testing.T) {
+		value := 1.0
+		NewGaugeFunc("test_pool_open", "Open.", func() float64 { return value })
+		value = 7
+
+		assert.Contains(t, scrape(), "test_pool_open 7\n")
+	})
+
+	t.Run("label values should be escaped", func(t *testing.T) {
+		c := NewCounter("test_escaped_total", "Escaped.", "path")
+		c.Inc("a\"b\\c\nd")
+
+		assert.Contains(t, scrape(), `test_escaped_total{path="a\"b\\c\nd"} 1`+"\n")
+	})
+
+	t.Run("wrong number of label values should panic", func(t *testing.T) {
+		c := NewCounter("test_labels_total", "Labels.", "a", "b")
+		assert.Panics(t, func() { c.Inc("only a") })
+	})
+}
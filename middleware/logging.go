@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	uuid "github.com/satori/go.uuid"
+	"go-api/app"
+	"go-api/logger"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+	// stackKey holds the stack of the panic PanicHandler turned into a 5xx.
+	stackKey = "panic_stack"
+)
+
+// incoming IDs are echoed into headers and logs, so only short and plain ones are kept
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID keeps the X-Request-ID the caller sent, or generates one, and returns it with the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewV4().String()
+		}
+
+		c.Set(app.RequestIDKey, id)
+		// statements run with the request's own context, their logs need the ID too
+		c.Request = c.Request.WithContext(app.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// RequestLogger logs every request once it's done. Routes are logged by their template so the IDs
+// in paths don't leak into logs, server errors carry the error and where it was raised.
+func RequestLogger(log *logger.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		args := []interface{}{
+			"request_id", app.RequestIDFrom(c),
+			"user_id", app.UserIDFrom(c),
+			"method", c.Request.Method,
+			"route", routeTemplate(c),
+			"status", status,
+			"latency_ms", float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip", c.ClientIP(),
+		}
+
+		if status < http.StatusInternalServerError {
+			log.Info("request", args...)
+			return
+		}
+
+		if err := c.Errors.Last(); err != nil {
+			args = append(args, "error", err.Err.Error(), "error_type", fmt.Sprintf("%T", err.Err))
+		}
+		if stack := c.GetString(stackKey); stack != "" {
+			args = append(args, "stack", stack)
+		}
+		log.Error("request failed", args...)
+	}
+}
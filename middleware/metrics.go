@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/metrics"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	httpRequests = metrics.NewCounter("http_requests_total",
+		"Requests served by route template and status.", "method", "route", "status")
+	httpDuration = metrics.NewHistogram("http_request_duration_seconds",
+		"Time taken to serve requests by route template and status.", metrics.DefaultBuckets, "method", "route", "status")
+	httpInFlight = metrics.NewGauge("http_requests_in_flight", "Requests being served.")
+)
+
+// Metrics records every request under its route template, paths would give a series per post or user.
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		httpInFlight.Add(1)
+		defer httpInFlight.Add(-1)
+
+		c.Next()
+
+		method := methodLabel(c.Request.Method)
+		route := routeTemplate(c)
+		status := strconv.Itoa(c.Writer.Status())
+		httpRequests.Inc(method, route, status)
+		httpDuration.Observe(time.Since(start).Seconds(), method, route, status)
+	}
+}
+
+var knownMethods = map[string]bool{
+	http.MethodGet: true, http.MethodHead: true, http.MethodPost: true, http.MethodPut: true, http.MethodPatch: true,
+	http.MethodDelete: true, http.MethodConnect: true, http.MethodOptions: true, http.MethodTrace: true,
+}
+
+// methodLabel keeps made up methods from adding a series each, they're counted as one.
+func methodLabel(method string) string {
+	if knownMethods[method] {
+		return method
+	}
+	return "other"
+}
+
+// routeTemplate is the pattern the request matched, requests that matched none share one.
+func routeTemplate(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return "unmatched"
+}
@@ -1,10 +1,14 @@
 package middleware
 
 import (
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"go-api/exception"
 	"go-api/model"
+	"math"
 	"net/http"
+	"runtime/debug"
+	"strconv"
 
 	"github.com/go-playground/validator"
 )
@@ -52,6 +56,27 @@ func unauthorizedError(res *model.WebResponse, err []error) {
 	res.Errors = parseError(err)
 }
 
+func forbidden(res *model.WebResponse, err []error) {
+	res.Code = http.StatusForbidden
+	res.Status = "Forbidden"
+	res.Errors = parseError(err)
+}
+
+func conflict(res *model.WebResponse, err []error) {
+	res.Code = http.StatusConflict
+	res.Status = "Conflict"
+	res.Errors = parseError(err)
+}
+
+func tooManyRequests(c *gin.Context, res *model.WebResponse, err exception.TooManyRequestsError) {
+	res.Code = http.StatusTooManyRequests
+	res.Status = "Too Many Requests"
+	res.Errors = parseError([]error{err})
+	if err.RetryAfter > 0 {
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+	}
+}
+
 func validationError(res *model.WebResponse, err validator.ValidationErrors) {
 	res.Code = http.StatusBadRequest
 	res.Status = "Bad Request"
@@ -69,12 +94,22 @@ func PanicHandler(c *gin.Context, err interface{}) {
 	switch err.(type) {
 	case exception.Errors:
 		badRequest(res, err.(exception.Errors).Errors)
+	case exception.FieldError:
+		badRequest(res, []error{err.(exception.FieldError)})
 	case validator.ValidationErrors:
 		validationError(res, err.(validator.ValidationErrors))
 	case exception.TokenError:
 		unauthorizedError(res, []error{err.(exception.TokenError)})
 	case exception.WrongPasswordError:
 		badRequest(res, []error{err.(exception.WrongPasswordError)})
+	case exception.CredentialError:
+		unauthorizedError(res, []error{err.(exception.CredentialError)})
+	case exception.TooManyRequestsError:
+		tooManyRequests(c, res, err.(exception.TooManyRequestsError))
+	case exception.NoAccessError:
+		forbidden(res, []error{err.(exception.NoAccessError)})
+	case exception.ConflictError:
+		conflict(res, []error{err.(exception.ConflictError)})
 	case exception.DuplicateError:
 		badRequest(res, []error{err.(exception.DuplicateError)})
 	case exception.NotFoundError:
@@ -83,7 +118,19 @@ func PanicHandler(c *gin.Context, err interface{}) {
 		internalServerError(res, []error{err.(exception.DatabaseError)})
 	case error:
 		internalServerError(res, []error{err.(error)})
+	default:
+		internalServerError(res, []error{fmt.Errorf("%v", err)})
+	}
+
+	// the details stay in the logs, RequestLogger picks them up
+	if res.Code >= http.StatusInternalServerError {
+		if e, ok := err.(error); ok {
+			_ = c.Error(e)
+		} else {
+			_ = c.Error(fmt.Errorf("%v", err))
+		}
+		c.Set(stackKey, string(debug.Stack()))
 	}
 
-	c.AbortWithStatusJSON(res.Code, res)
+	model.Abort(c, res)
 }
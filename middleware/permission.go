@@ -0,0 +1,17 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/exception"
+	"go-api/model/user"
+)
+
+// RequirePermission only lets the request through when the user's role grants the permission.
+func RequirePermission(userService user.Service, permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !userService.HasPermission(c.Request.Context(), c.GetHeader("User_id"), permission) {
+			panic(exception.NoAccessError{Message: "missing " + permission + " permission"})
+		}
+		c.Next()
+	}
+}
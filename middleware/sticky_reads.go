@@ -0,0 +1,77 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/app"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// LastWriteCookie carries when the client last wrote, in unix milliseconds.
+const LastWriteCookie = "last_write"
+
+// StickyReads reads the client's last write from its cookie for app.ReadTx, and sends the cookie
+// back when the request wrote. Keeping it with the client lets every instance route the reads.
+// A forged cookie can only send the client's own reads to the primary.
+func StickyReads() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var at time.Time
+		if cookie, err := c.Cookie(LastWriteCookie); err == nil {
+			if ms, err := strconv.ParseInt(cookie, 10, 64); err == nil {
+				at = time.UnixMilli(ms)
+			}
+		}
+
+		lastWrite := app.NewLastWrite(at)
+		c.Set(app.LastWriteKey, lastWrite)
+		writer := &lastWriteWriter{ResponseWriter: c.Writer, lastWrite: lastWrite, from: at}
+		c.Writer = writer
+		c.Next()
+		// responses without a body get their headers written by gin after the handlers, past the wrapper
+		writer.setCookie()
+	}
+}
+
+// lastWriteWriter adds the cookie right before the headers go out, writes are done by then.
+type lastWriteWriter struct {
+	gin.ResponseWriter
+	lastWrite *app.LastWrite
+	from      time.Time
+	done      bool
+}
+
+func (w *lastWriteWriter) setCookie() {
+	if w.done || w.Written() {
+		return
+	}
+	w.done = true
+
+	at := w.lastWrite.At()
+	if !at.After(w.from) {
+		return
+	}
+	http.SetCookie(w.ResponseWriter, &http.Cookie{
+		Name:     LastWriteCookie,
+		Value:    strconv.FormatInt(at.UnixMilli(), 10),
+		Path:     "/",
+		MaxAge:   int(app.StickyWindow / time.Second),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (w *lastWriteWriter) WriteHeaderNow() {
+	w.setCookie()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *lastWriteWriter) Write(data []byte) (int, error) {
+	w.setCookie()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *lastWriteWriter) WriteString(s string) (int, error) {
+	w.setCookie()
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go-api/exception"
+	"go-api/model"
+	"go-api/model/audit"
+	"net/http"
+	"strconv"
+)
+
+type Controller interface {
+	SearchUsers(ctx *gin.Context)
+	Suspend(ctx *gin.Context)
+	Unsuspend(ctx *gin.Context)
+	SetVerified(ctx *gin.Context)
+	SetRole(ctx *gin.Context)
+	DeletePost(ctx *gin.Context)
+	DeleteComment(ctx *gin.Context)
+}
+
+type controllerImpl struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return &controllerImpl{service: service}
+}
+
+// bindOptionalJSON allows admin endpoints to be called without a body.
+func bindOptionalJSON(ctx *gin.Context, req interface{}) {
+	if ctx.Request.ContentLength == 0 {
+		return
+	}
+
+	err := ctx.ShouldBindWith(req, binding.JSON)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (c *controllerImpl) SearchUsers(ctx *gin.Context) {
+	var req SearchRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		panic(err)
+	}
+
+	res := c.service.SearchUsers(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) Suspend(ctx *gin.Context) {
+	c.setSuspended(ctx, true)
+}
+
+func (c *controllerImpl) Unsuspend(ctx *gin.Context) {
+	c.setSuspended(ctx, false)
+}
+
+func (c *controllerImpl) setSuspended(ctx *gin.Context, suspended bool) {
+	var req SuspendRequest
+	bindOptionalJSON(ctx, &req)
+
+	req.Actor = audit.ActorFrom(ctx)
+	req.UserID = ctx.Param("userID")
+	req.Suspended = suspended
+	c.service.SetSuspended(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) SetVerified(ctx *gin.Context) {
+	var req VerifyRequest
+	bindOptionalJSON(ctx, &req)
+
+	req.Actor = audit.ActorFrom(ctx)
+	req.UserID = ctx.Param("userID")
+	c.service.SetVerified(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) SetRole(ctx *gin.Context) {
+	var req RoleRequest
+	bindOptionalJSON(ctx, &req)
+
+	req.Actor = audit.ActorFrom(ctx)
+	req.UserID = ctx.Param("userID")
+	c.service.SetRole(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) DeletePost(ctx *gin.Context) {
+	var req DeletePostRequest
+	bindOptionalJSON(ctx, &req)
+
+	req.Actor = audit.ActorFrom(ctx)
+	req.PostID = ctx.Param("postID")
+	c.service.DeletePost(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) DeleteComment(ctx *gin.Context) {
+	var req DeleteCommentRequest
+	bindOptionalJSON(ctx, &req)
+
+	commentID, err := strconv.ParseInt(ctx.Param("commentID"), 10, 64)
+	if err != nil {
+		panic(exception.NotFoundError{Message: "comment not found"})
+	}
+
+	req.Actor = audit.ActorFrom(ctx)
+	req.CommentID = commentID
+	c.service.DeleteComment(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
@@ -0,0 +1,133 @@
+package admin_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"go-api/app"
+	"go-api/cache"
+	"go-api/middleware"
+	"go-api/model/admin"
+	"go-api/model/audit"
+	"go-api/model/comment"
+	"go-api/model/like"
+	"go-api/model/post"
+	"go-api/model/relation"
+	"go-api/model/search"
+	"go-api/model/session"
+	"go-api/model/tag"
+	"go-api/model/token"
+	"go-api/model/user"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestControllerImpl_Moderation(t *testing.T) {
+	app.TestDBInit()
+	audit.SetHashKey([]byte("test audit key"))
+	searchService := search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer())
+	cacheLoader := cache.NewLoader(cache.NewLRU(100))
+	userService := user.NewService(validator.New(), user.NewRepository(), session.NewRepository(), audit.NewRepository(), relation.NewRepository(), searchService, user.NewMemoryAttemptStore(), cacheLoader)
+	adminService := admin.NewService(validator.New(), user.NewRepository(), session.NewRepository(), token.NewRepository(), post.NewRepository(), comment.NewRepository(), like.NewRepository(), tag.NewRepository(), audit.NewRepository(), relation.NewRepository(), searchService, cacheLoader)
+
+	router := gin.New()
+	router.Use(gin.CustomRecovery(middleware.PanicHandler))
+	admin.InitRoutes(&router.RouterGroup, admin.NewController(adminService), userService)
+
+	now := time.Now()
+	var adminID, moderatorID, memberID, otherModeratorID string
+	for id, role := range map[*string]string{&adminID: user.RoleAdmin, &moderatorID: user.RoleModerator, &memberID: user.RoleUser, &otherModeratorID: user.RoleModerator} {
+		*id = uuid.NewV4().String()
+		assert.Nil(t, app.DB.Create(&user.User{ID: *id, Email: *id + "@example.com", Username: *id, DisplayName: *id, Role: role, CreatedAt: now, UpdatedAt: now}).Error)
+	}
+
+	call := func(method, target, actorID string, body interface{}) int {
+		var payload bytes.Buffer
+		if body != nil {
+			assert.Nil(t, json.NewEncoder(&payload).Encode(body))
+		}
+		req := httptest.NewRequest(method, target, &payload)
+		req.Header.Set("User_id", actorID)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	auditEntries := func(action, targetID string) []*audit.Entry {
+		var entries []*audit.Entry
+		assert.Nil(t, app.DB.Where("action = ? AND target_id = ?", action, targetID).Find(&entries).Error)
+		return entries
+	}
+
+	t.Run("suspending should revoke sessions and tokens and be audited", func(t *testing.T) {
+		sess := session.New(memberID, "phone", "10.0.0.1")
+		assert.Nil(t, app.DB.Create(sess).Error)
+		tok := &token.Token{ID: uuid.NewV4().String(), UserID: memberID, Name: "ci", Prefix: "pfx", Hash: uuid.NewV4().String(), Scopes: "post:read", CreatedAt: now}
+		assert.Nil(t, app.DB.Create(tok).Error)
+
+		assert.Equal(t, http.StatusOK, call(http.MethodPut, "/admin/user/"+memberID+"/suspend", moderatorID, map[string]string{"reason": "spam"}))
+		assert.True(t, user.NewRepository().FindById(app.DB, memberID).IsSuspended)
+		assert.True(t, session.NewRepository().FindBySessionID(app.DB, sess.ID).IsRevoked)
+		assert.True(t, token.NewRepository().FindByTokenID(app.DB, tok.ID).IsRevoked)
+
+		entries := auditEntries(audit.ActionUserSuspend, memberID)
+		assert.Len(t, entries, 1)
+		assert.Equal(t, moderatorID, entries[0].ActorID)
+		assert.Contains(t, entries[0].Detail, "spam")
+
+		assert.Equal(t, http.StatusOK, call(http.MethodDelete, "/admin/user/"+memberID+"/suspend", moderatorID, nil))
+		assert.False(t, user.NewRepository().FindById(app.DB, memberID).IsSuspended)
+		assert.Len(t, auditEntries(audit.ActionUserUnsuspend, memberID), 1)
+	})
+
+	t.Run("moderators should not act on their own or a higher rank", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, call(http.MethodPut, "/admin/user/"+otherModeratorID+"/suspend", moderatorID, nil))
+		assert.Equal(t, http.StatusForbidden, call(http.MethodPut, "/admin/user/"+adminID+"/suspend", moderatorID, nil))
+		assert.Equal(t, http.StatusForbidden, call(http.MethodPut, "/admin/user/"+moderatorID+"/suspend", moderatorID, nil))
+		assert.False(t, user.NewRepository().FindById(app.DB, otherModeratorID).IsSuspended)
+	})
+
+	t.Run("role change should need the role permission and be audited", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, call(http.MethodPut, "/admin/user/"+memberID+"/role", moderatorID, map[string]string{"role": user.RoleModerator}))
+		assert.Equal(t, user.RoleUser, user.NewRepository().FindById(app.DB, memberID).Role)
+
+		assert.Equal(t, http.StatusOK, call(http.MethodPut, "/admin/user/"+memberID+"/role", adminID, map[string]string{"role": user.RoleModerator}))
+		assert.Equal(t, user.RoleModerator, user.NewRepository().FindById(app.DB, memberID).Role)
+
+		entries := auditEntries(audit.ActionUserRole, memberID)
+		assert.Len(t, entries, 1)
+		assert.Contains(t, entries[0].Before, user.RoleUser)
+		assert.Contains(t, entries[0].After, user.RoleModerator)
+	})
+
+	t.Run("moderated post and comment should be gone and audited", func(t *testing.T) {
+		postID := uuid.NewV4().String()
+		assert.Nil(t, app.DB.Create(&post.Post{ID: postID, UserID: memberID, Caption: "off topic", Status: post.StatusPublished, CommentsCount: 1, CreatedAt: now, UpdatedAt: now}).Error)
+		c := &comment.Comment{PostID: postID, UserID: otherModeratorID, Content: "rude", CreatedAt: now}
+		assert.Nil(t, app.DB.Create(c).Error)
+
+		assert.Equal(t, http.StatusOK, call(http.MethodDelete, "/admin/comment/"+strconv.FormatInt(c.ID, 10), moderatorID, map[string]string{"reason": "abuse"}))
+		assert.Zero(t, comment.NewRepository().FindByCommentID(app.DB, c.ID).ID)
+		assert.Zero(t, post.NewRepository().FindByPostID(app.DB, postID).CommentsCount)
+		assert.Len(t, auditEntries(audit.ActionCommentDelete, strconv.FormatInt(c.ID, 10)), 1)
+
+		assert.Equal(t, http.StatusOK, call(http.MethodDelete, "/admin/post/"+postID, moderatorID, map[string]string{"reason": "off topic"}))
+		assert.Empty(t, post.NewRepository().FindWithDeleted(app.DB, postID).ID)
+		assert.Len(t, auditEntries(audit.ActionPostDelete, postID), 1)
+	})
+
+	t.Run("members should not reach the admin API", func(t *testing.T) {
+		userID := uuid.NewV4().String()
+		assert.Nil(t, app.DB.Create(&user.User{ID: userID, Email: userID + "@example.com", Username: userID, DisplayName: userID, Role: user.RoleUser, CreatedAt: now, UpdatedAt: now}).Error)
+
+		assert.Equal(t, http.StatusForbidden, call(http.MethodGet, "/admin/user?q=x", userID, nil))
+		assert.Equal(t, http.StatusForbidden, call(http.MethodPut, "/admin/user/"+otherModeratorID+"/suspend", userID, nil))
+		assert.Empty(t, auditEntries(audit.ActionUserSuspend, otherModeratorID))
+	})
+}
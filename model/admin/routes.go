@@ -0,0 +1,22 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/middleware"
+	"go-api/model/user"
+)
+
+func InitRoutes(router *gin.RouterGroup, controller Controller, userService user.Service) {
+	require := func(permission string) gin.HandlerFunc {
+		return middleware.RequirePermission(userService, permission)
+	}
+
+	adminGroup := router.Group("/admin")
+	adminGroup.GET("/user", require(user.PermissionUserRead), controller.SearchUsers)
+	adminGroup.PUT("/user/:userID/suspend", require(user.PermissionUserSuspend), controller.Suspend)
+	adminGroup.DELETE("/user/:userID/suspend", require(user.PermissionUserSuspend), controller.Unsuspend)
+	adminGroup.PUT("/user/:userID/verify", require(user.PermissionUserVerify), controller.SetVerified)
+	adminGroup.PUT("/user/:userID/role", require(user.PermissionUserRole), controller.SetRole)
+	adminGroup.DELETE("/post/:postID", require(user.PermissionPostModerate), controller.DeletePost)
+	adminGroup.DELETE("/comment/:commentID", require(user.PermissionCommentModerate), controller.DeleteComment)
+}
@@ -0,0 +1,274 @@
+package admin
+
+import (
+	"context"
+	"github.com/go-playground/validator"
+	"go-api/app"
+	"go-api/cache"
+	"go-api/exception"
+	"go-api/helper"
+	"go-api/model/audit"
+	"go-api/model/comment"
+	"go-api/model/like"
+	"go-api/model/post"
+	"go-api/model/relation"
+	"go-api/model/search"
+	"go-api/model/session"
+	"go-api/model/tag"
+	"go-api/model/token"
+	"go-api/model/user"
+	"gorm.io/gorm"
+	"strconv"
+)
+
+const defaultSearchLimit = 20
+
+// roleRank stops moderators from acting on accounts ranked the same or above them.
+var roleRank = map[string]int{
+	user.RoleUser:      0,
+	user.RoleModerator: 1,
+	user.RoleAdmin:     2,
+}
+
+type Service interface {
+	SearchUsers(ctx context.Context, req *SearchRequest) []*UserResponse
+	SetSuspended(ctx context.Context, req *SuspendRequest)
+	SetVerified(ctx context.Context, req *VerifyRequest)
+	SetRole(ctx context.Context, req *RoleRequest)
+	DeletePost(ctx context.Context, req *DeletePostRequest)
+	DeleteComment(ctx context.Context, req *DeleteCommentRequest)
+}
+
+type serviceImpl struct {
+	validate           *validator.Validate
+	userRepository     user.Repository
+	sessionRepository  session.Repository
+	tokenRepository    token.Repository
+	postRepository     post.Repository
+	commentRepository  comment.Repository
+	likeRepository     like.Repository
+	tagRepository      tag.Repository
+	auditRepository    audit.Repository
+	relationRepository relation.Repository
+	searchService      search.Service
+	cache              *cache.Loader
+}
+
+func NewService(validate *validator.Validate, userRepository user.Repository, sessionRepository session.Repository, tokenRepository token.Repository, postRepository post.Repository, commentRepository comment.Repository, likeRepository like.Repository, tagRepository tag.Repository, auditRepository audit.Repository, relationRepository relation.Repository, searchService search.Service, cache *cache.Loader) Service {
+	return &serviceImpl{
+		validate:           validate,
+		userRepository:     userRepository,
+		sessionRepository:  sessionRepository,
+		tokenRepository:    tokenRepository,
+		postRepository:     postRepository,
+		commentRepository:  commentRepository,
+		likeRepository:     likeRepository,
+		tagRepository:      tagRepository,
+		auditRepository:    auditRepository,
+		relationRepository: relationRepository,
+		searchService:      searchService,
+		cache:              cache,
+	}
+}
+
+func (s *serviceImpl) SearchUsers(ctx context.Context, req *SearchRequest) []*UserResponse {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultSearchLimit
+	}
+
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	var response []*UserResponse
+	users := s.userRepository.Search(tx, req.Keyword, req.Offset, req.Limit)
+	for _, u := range users {
+		response = append(response, &UserResponse{
+			UserID:      u.ID,
+			Email:       u.Email,
+			Username:    u.Username,
+			DisplayName: u.DisplayName,
+			Role:        u.Role,
+			IsVerified:  u.IsVerified,
+			IsSuspended: u.IsSuspended,
+			CreatedAt:   u.CreatedAt,
+		})
+	}
+	return response
+}
+
+// SetSuspended also ends every session and access token of a suspended account.
+func (s *serviceImpl) SetSuspended(ctx context.Context, req *SuspendRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	target := s.findManageableUser(tx, req.ActorID, req.UserID)
+	s.userRepository.SetSuspended(tx, target.ID, req.Suspended)
+
+	action := audit.ActionUserUnsuspend
+	if req.Suspended {
+		action = audit.ActionUserSuspend
+		s.sessionRepository.RevokeByUserID(tx, target.ID)
+		s.tokenRepository.RevokeByUserID(tx, target.ID)
+	}
+
+	s.auditRepository.Append(tx, audit.NewEntry(req.Actor, action, audit.TargetUser, target.ID).
+		WithChanges(
+			map[string]interface{}{"is_suspended": target.IsSuspended},
+			map[string]interface{}{"is_suspended": req.Suspended},
+		).
+		WithDetail(map[string]interface{}{"reason": req.Reason}))
+
+	if req.Suspended {
+		app.AfterCommit(tx, func() { s.searchService.RemoveUser(target.ID) })
+	} else {
+		target.IsSuspended = false
+		s.index(tx, target)
+	}
+}
+
+func (s *serviceImpl) SetVerified(ctx context.Context, req *VerifyRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	var username string
+	defer func() {
+		s.cache.Invalidate(ctx, cache.ProfileKey(username))
+	}()
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	target := s.userRepository.FindById(tx, req.UserID)
+	if target.ID == "" {
+		panic(exception.NotFoundError{Message: "user not found"})
+	}
+
+	username = target.Username
+	s.userRepository.SetVerified(tx, target.ID, req.IsVerified)
+	s.auditRepository.Append(tx, audit.NewEntry(req.Actor, audit.ActionUserVerify, audit.TargetUser, target.ID).
+		WithChanges(
+			map[string]interface{}{"is_verified": target.IsVerified},
+			map[string]interface{}{"is_verified": req.IsVerified},
+		))
+
+	if !target.IsSuspended {
+		target.IsVerified = req.IsVerified
+		s.index(tx, target)
+	}
+}
+
+func (s *serviceImpl) SetRole(ctx context.Context, req *RoleRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	target := s.findManageableUser(tx, req.ActorID, req.UserID)
+	s.userRepository.SetRole(tx, target.ID, req.Role)
+	s.auditRepository.Append(tx, audit.NewEntry(req.Actor, audit.ActionUserRole, audit.TargetUser, target.ID).
+		WithChanges(
+			map[string]interface{}{"role": target.Role},
+			map[string]interface{}{"role": req.Role},
+		))
+}
+
+func (s *serviceImpl) DeletePost(ctx context.Context, req *DeletePostRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID), cache.PostCountsKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	// a post already in recently deleted can still be moderated away for good
+	fPost := s.postRepository.FindWithDeleted(tx, req.PostID)
+	if fPost.ID == "" {
+		panic(exception.NotFoundError{Message: "post not found"})
+	}
+
+	// a moderated post skips recently deleted so its owner can't restore it,
+	// its resources are left to the media GC
+	s.likeRepository.DeleteByPostID(tx, fPost.ID)
+	s.commentRepository.DeleteByPostID(tx, fPost.ID)
+	s.tagRepository.DeleteByPostID(tx, fPost.ID)
+	s.postRepository.DeleteRevisions(tx, fPost.ID)
+	s.postRepository.Purge(tx, fPost.ID)
+	s.auditRepository.Append(tx, audit.NewEntry(req.Actor, audit.ActionPostDelete, audit.TargetPost, fPost.ID).
+		WithChanges(
+			map[string]interface{}{"user_id": fPost.UserID, "caption": fPost.Caption},
+			map[string]interface{}{},
+		).
+		WithDetail(map[string]interface{}{"reason": req.Reason}))
+	app.AfterCommit(tx, func() { s.searchService.RemovePost(fPost.ID) })
+}
+
+func (s *serviceImpl) DeleteComment(ctx context.Context, req *DeleteCommentRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	var postID string
+	defer func() {
+		s.cache.Invalidate(ctx, cache.PostCountsKey(postID))
+	}()
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	fComment := s.commentRepository.FindByCommentID(tx, req.CommentID)
+	if fComment.ID == 0 {
+		panic(exception.NotFoundError{Message: "comment not found"})
+	}
+
+	postID = fComment.PostID
+
+	s.commentRepository.Delete(tx, fComment.ID)
+	s.commentRepository.AddToPostCount(tx, fComment.PostID, -1)
+	s.auditRepository.Append(tx, audit.NewEntry(req.Actor, audit.ActionCommentDelete, audit.TargetComment, strconv.FormatInt(fComment.ID, 10)).
+		WithChanges(
+			map[string]interface{}{"user_id": fComment.UserID, "post_id": fComment.PostID, "content": fComment.Content},
+			map[string]interface{}{},
+		).
+		WithDetail(map[string]interface{}{"reason": req.Reason}))
+}
+
+// findManageableUser returns the target user, panicking when the actor targets themselves
+// or an account ranked the same or above their own role.
+func (s *serviceImpl) findManageableUser(tx *gorm.DB, actorID, userID string) *user.User {
+	if actorID == userID {
+		panic(exception.NoAccessError{Message: "can't change your own account"})
+	}
+
+	target := s.userRepository.FindById(tx, userID)
+	if target.ID == "" {
+		panic(exception.NotFoundError{Message: "user not found"})
+	}
+
+	actor := s.userRepository.FindById(tx, actorID)
+	if actor.Role != user.RoleAdmin && roleRank[target.Role] >= roleRank[actor.Role] {
+		panic(exception.NoAccessError{Message: "can't change an account with the same or higher role"})
+	}
+	return target
+}
+
+// index puts the user back into search once tx is committed, with the follower count it's ranked by.
+func (s *serviceImpl) index(tx *gorm.DB, target *user.User) {
+	doc := target.ToDocument(s.relationRepository.CountFollowers(tx, []string{target.ID})[target.ID])
+	app.AfterCommit(tx, func() { s.searchService.IndexUser(doc) })
+}
@@ -0,0 +1,56 @@
+package admin
+
+import (
+	"go-api/model/audit"
+	"time"
+)
+
+type (
+	SearchRequest struct {
+		Keyword string `form:"q" json:"q"`
+		Offset  int    `validate:"min=0" form:"offset" json:"offset"`
+		Limit   int    `validate:"min=0,max=100" form:"limit" json:"limit"`
+	}
+
+	SuspendRequest struct {
+		audit.Actor
+		UserID    string `validate:"required" json:"user_id"`
+		Suspended bool   `json:"suspended"`
+		Reason    string `json:"reason"`
+	}
+
+	VerifyRequest struct {
+		audit.Actor
+		UserID     string `validate:"required" json:"user_id"`
+		IsVerified bool   `json:"is_verified"`
+	}
+
+	RoleRequest struct {
+		audit.Actor
+		UserID string `validate:"required" json:"user_id"`
+		Role   string `validate:"required,oneof=user moderator admin" json:"role"`
+	}
+
+	DeletePostRequest struct {
+		audit.Actor
+		PostID string `validate:"required" json:"post_id"`
+		Reason string `json:"reason"`
+	}
+
+	DeleteCommentRequest struct {
+		audit.Actor
+		CommentID int64  `validate:"required" json:"comment_id"`
+		Reason    string `json:"reason"`
+	}
+
+	UserResponse struct {
+		UserID      string    `json:"user_id"`
+		Email       string    `json:"email"`
+		Username    string    `json:"username"`
+		DisplayName string    `json:"display_name"`
+		Role        string    `json:"role"`
+		IsVerified  bool      `json:"is_verified"`
+		IsSuspended bool      `json:"is_suspended"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+)
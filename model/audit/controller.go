@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/model"
+	"net/http"
+)
+
+type Controller interface {
+	Find(ctx *gin.Context)
+	Verify(ctx *gin.Context)
+}
+
+type controllerImpl struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return &controllerImpl{service: service}
+}
+
+func (c *controllerImpl) Find(ctx *gin.Context) {
+	var req FindRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		panic(err)
+	}
+
+	res := c.service.Find(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) Verify(ctx *gin.Context) {
+	res := c.service.Verify(ctx)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Entry struct {
+	ID         int64     `gorm:"column:audit_id; primaryKey,autoIncrement"`
+	Sequence   int64     `gorm:"column:sequence; not null"`
+	ActorID    string    `gorm:"column:actor_id; not null"`
+	Action     string    `gorm:"column:action; not null"`
+	TargetType string    `gorm:"column:target_type; not null"`
+	TargetID   string    `gorm:"column:target_id; not null"`
+	Before     string    `gorm:"column:before_state;"`
+	After      string    `gorm:"column:after_state;"`
+	Detail     string    `gorm:"column:detail;"`
+	IPAddress  string    `gorm:"column:ip_address;"`
+	UserAgent  string    `gorm:"column:user_agent;"`
+	RequestID  string    `gorm:"column:request_id;"`
+	PrevHash   string    `gorm:"column:prev_hash; not null"`
+	Hash       string    `gorm:"column:hash; not null"`
+	CreatedAt  time.Time `gorm:"column:created_at; not null"`
+}
+
+func (Entry) TableName() string {
+	return "audit_logs"
+}
+
+// Head is the single row pointing at the newest entry, locking it serializes appends to the chain.
+type Head struct {
+	ID       int    `gorm:"column:head_id; primaryKey"`
+	Sequence int64  `gorm:"column:sequence; not null"`
+	Hash     string `gorm:"column:hash; not null"`
+}
+
+func (Head) TableName() string {
+	return "audit_heads"
+}
+
+const (
+	ActionUserProfileUpdate  = "user.profile_update"
+	ActionUserPasswordUpdate = "user.password_update"
+	ActionUserSuspend        = "user.suspend"
+	ActionUserUnsuspend      = "user.unsuspend"
+	ActionUserVerify         = "user.verify"
+	ActionUserRole           = "user.role"
+	ActionPostDelete         = "post.delete"
+	ActionPostRestore        = "post.restore"
+	ActionCommentDelete      = "comment.delete"
+)
+
+const (
+	TargetUser    = "user"
+	TargetPost    = "post"
+	TargetComment = "comment"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveFields never reach the log with their real value.
+var sensitiveFields = map[string]bool{
+	"password": true,
+	"token":    true,
+}
+
+func NewEntry(actor Actor, action, targetType, targetID string) *Entry {
+	return &Entry{
+		ActorID:    actor.ActorID,
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		RequestID:  actor.RequestID,
+		// stored with second precision so the hash can be recomputed from the database row
+		CreatedAt: time.Now().Truncate(time.Second),
+	}
+}
+
+// WithChanges keeps only the fields that differ between before and after.
+func (e *Entry) WithChanges(before, after map[string]interface{}) *Entry {
+	changedBefore := map[string]interface{}{}
+	changedAfter := map[string]interface{}{}
+	for key, value := range after {
+		old, ok := before[key]
+		if ok && equal(old, value) {
+			continue
+		}
+		changedBefore[key] = redact(key, old)
+		changedAfter[key] = redact(key, value)
+	}
+	for key, value := range before {
+		if _, ok := after[key]; !ok {
+			changedBefore[key] = redact(key, value)
+		}
+	}
+
+	e.Before = encode(changedBefore)
+	e.After = encode(changedAfter)
+	return e
+}
+
+func (e *Entry) WithDetail(detail map[string]interface{}) *Entry {
+	for key, value := range detail {
+		detail[key] = redact(key, value)
+	}
+	e.Detail = encode(detail)
+	return e
+}
+
+// hashKey signs the chain. It's kept out of the database, so whoever can rewrite the audit tables
+// still can't compute hashes that verify.
+var hashKey []byte
+
+// SetHashKey sets the key the chain is signed with, entries only verify under the key they were appended with.
+func SetHashKey(key []byte) {
+	hashKey = key
+}
+
+// ComputeHash chains the entry to PrevHash, any change to a stored field changes the result.
+func (e *Entry) ComputeHash() string {
+	fields := []string{
+		e.PrevHash,
+		strconv.FormatInt(e.Sequence, 10),
+		e.ActorID,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.Before,
+		e.After,
+		e.Detail,
+		e.IPAddress,
+		e.UserAgent,
+		e.RequestID,
+		e.CreatedAt.UTC().Format(time.RFC3339),
+	}
+	for i, f := range fields {
+		fields[i] = strconv.Quote(f)
+	}
+
+	mac := hmac.New(sha256.New, hashKey)
+	mac.Write([]byte(strings.Join(fields, "|")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (e *Entry) ToResponse() *Response {
+	return &Response{
+		AuditID:    e.ID,
+		Sequence:   e.Sequence,
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Before:     json.RawMessage(orEmpty(e.Before)),
+		After:      json.RawMessage(orEmpty(e.After)),
+		Detail:     json.RawMessage(orEmpty(e.Detail)),
+		IPAddress:  e.IPAddress,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+		Hash:       e.Hash,
+		CreatedAt:  e.CreatedAt,
+	}
+}
+
+func redact(key string, value interface{}) interface{} {
+	if value != nil && sensitiveFields[key] {
+		return redacted
+	}
+	return value
+}
+
+func equal(a, b interface{}) bool {
+	return encode(a) == encode(b)
+}
+
+func encode(v interface{}) string {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(encoded)
+}
+
+func orEmpty(s string) string {
+	if s == "" {
+		return "null"
+	}
+	return s
+}
@@ -0,0 +1,53 @@
+package audit
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestEntry_WithChanges(t *testing.T) {
+	entry := NewEntry(Actor{ActorID: "actor"}, ActionUserProfileUpdate, TargetUser, "target").
+		WithChanges(
+			map[string]interface{}{"username": "old", "biography": "same", "password": "secret"},
+			map[string]interface{}{"username": "new", "biography": "same", "password": "other"},
+		)
+
+	assert.JSONEq(t, `{"username":"old","password":"[REDACTED]"}`, entry.Before)
+	assert.JSONEq(t, `{"username":"new","password":"[REDACTED]"}`, entry.After)
+}
+
+func TestEntry_ComputeHash(t *testing.T) {
+	SetHashKey([]byte("test key"))
+	first := NewEntry(Actor{ActorID: "actor"}, ActionPostDelete, TargetPost, "post")
+	first.Sequence = 1
+	first.Hash = first.ComputeHash()
+
+	second := NewEntry(Actor{ActorID: "actor"}, ActionPostDelete, TargetPost, "other post")
+	second.Sequence = 2
+	second.PrevHash = first.Hash
+	second.Hash = second.ComputeHash()
+
+	t.Run("unchanged entry should produce the same hash", func(t *testing.T) {
+		assert.Equal(t, first.Hash, first.ComputeHash())
+	})
+
+	t.Run("edited entry should not match its hash", func(t *testing.T) {
+		first.TargetID = "edited"
+		assert.NotEqual(t, first.Hash, first.ComputeHash())
+	})
+
+	t.Run("changed previous hash should break the chain", func(t *testing.T) {
+		second.PrevHash = "edited"
+		assert.NotEqual(t, second.Hash, second.ComputeHash())
+	})
+
+	t.Run("hash computed without the key should not match", func(t *testing.T) {
+		entry := NewEntry(Actor{ActorID: "actor"}, ActionPostDelete, TargetPost, "post")
+		entry.Sequence = 3
+		entry.Hash = entry.ComputeHash()
+
+		SetHashKey([]byte("guessed key"))
+		defer SetHashKey([]byte("test key"))
+		assert.NotEqual(t, entry.Hash, entry.ComputeHash())
+	})
+}
@@ -0,0 +1,107 @@
+package audit
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const headID = 1
+
+type Repository interface {
+	Append(tx *gorm.DB, entry *Entry)
+	Find(tx *gorm.DB, req *FindRequest) []*Entry
+	FindAfterSequence(tx *gorm.DB, sequence int64, limit int) []*Entry
+	FindHead(tx *gorm.DB) *Head
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+// Append links the entry to the current head of the chain, it must run inside the transaction
+// of the change being audited so both are committed or rolled back together.
+func (*repositoryImpl) Append(tx *gorm.DB, entry *Entry) {
+	err := tx.Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&Head{ID: headID}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	var head Head
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("head_id = ?", headID).
+		First(&head).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	entry.Sequence = head.Sequence + 1
+	entry.PrevHash = head.Hash
+	entry.Hash = entry.ComputeHash()
+	err = tx.Create(&entry).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	err = tx.Model(&Head{}).
+		Where("head_id = ?", headID).
+		Updates(map[string]interface{}{"sequence": entry.Sequence, "hash": entry.Hash}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Find(tx *gorm.DB, req *FindRequest) []*Entry {
+	var entries []*Entry
+	query := tx.Order("sequence desc").Offset(req.Offset).Limit(req.Limit)
+	if req.ActorID != "" {
+		query = query.Where("actor_id = ?", req.ActorID)
+	}
+	if req.Action != "" {
+		query = query.Where("action = ?", req.Action)
+	}
+	if req.TargetType != "" {
+		query = query.Where("target_type = ?", req.TargetType)
+	}
+	if req.TargetID != "" {
+		query = query.Where("target_id = ?", req.TargetID)
+	}
+	if !req.From.IsZero() {
+		query = query.Where("created_at >= ?", req.From)
+	}
+	if !req.To.IsZero() {
+		query = query.Where("created_at < ?", req.To)
+	}
+
+	err := query.Find(&entries).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return entries
+}
+
+func (*repositoryImpl) FindAfterSequence(tx *gorm.DB, sequence int64, limit int) []*Entry {
+	var entries []*Entry
+	err := tx.Where("sequence > ?", sequence).
+		Order("sequence asc").
+		Limit(limit).
+		Find(&entries).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return entries
+}
+
+func (*repositoryImpl) FindHead(tx *gorm.DB) *Head {
+	var head Head
+	err := tx.Where("head_id = ?", headID).
+		Limit(1).
+		Find(&head).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return &head
+}
@@ -0,0 +1,10 @@
+package audit
+
+import "github.com/gin-gonic/gin"
+
+// InitRoutes takes the permission guard from the caller, this package can't depend on the user one.
+func InitRoutes(router *gin.RouterGroup, controller Controller, guard gin.HandlerFunc) {
+	auditGroup := router.Group("/admin/audit", guard)
+	auditGroup.GET("/", controller.Find)
+	auditGroup.GET("/verify", controller.Verify)
+}
@@ -0,0 +1,77 @@
+package audit
+
+import (
+	"context"
+	"github.com/go-playground/validator"
+	"go-api/app"
+	"go-api/helper"
+)
+
+const (
+	defaultFindLimit = 50
+	verifyBatchSize  = 500
+)
+
+type Service interface {
+	Find(ctx context.Context, req *FindRequest) []*Response
+	Verify(ctx context.Context) *VerifyResponse
+}
+
+type serviceImpl struct {
+	validate        *validator.Validate
+	auditRepository Repository
+}
+
+func NewService(validate *validator.Validate, auditRepository Repository) Service {
+	return &serviceImpl{validate: validate, auditRepository: auditRepository}
+}
+
+func (s *serviceImpl) Find(ctx context.Context, req *FindRequest) []*Response {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultFindLimit
+	}
+
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	var response []*Response
+	entries := s.auditRepository.Find(tx, req)
+	for _, e := range entries {
+		response = append(response, e.ToResponse())
+	}
+	return response
+}
+
+// Verify walks the whole chain, recomputing every hash and checking it against the head.
+func (s *serviceImpl) Verify(ctx context.Context) *VerifyResponse {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	var sequence int64
+	prevHash := ""
+	for {
+		entries := s.auditRepository.FindAfterSequence(tx, sequence, verifyBatchSize)
+		for _, e := range entries {
+			if e.Sequence != sequence+1 || e.PrevHash != prevHash || e.ComputeHash() != e.Hash {
+				return &VerifyResponse{Valid: false, Checked: sequence, BrokenAt: sequence + 1}
+			}
+			sequence = e.Sequence
+			prevHash = e.Hash
+		}
+
+		if len(entries) < verifyBatchSize {
+			break
+		}
+	}
+
+	head := s.auditRepository.FindHead(tx)
+	if head.Sequence != sequence || head.Hash != prevHash {
+		return &VerifyResponse{Valid: false, Checked: sequence, BrokenAt: sequence + 1}
+	}
+	return &VerifyResponse{Valid: true, Checked: sequence}
+}
@@ -0,0 +1,60 @@
+package audit
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+type (
+	// Actor identifies who performed an action and from where, it's filled by the controllers.
+	Actor struct {
+		ActorID   string `json:"-"`
+		IPAddress string `json:"-"`
+		UserAgent string `json:"-"`
+		RequestID string `json:"-"`
+	}
+
+	FindRequest struct {
+		ActorID    string    `form:"actor_id" json:"actor_id"`
+		Action     string    `form:"action" json:"action"`
+		TargetType string    `form:"target_type" json:"target_type"`
+		TargetID   string    `form:"target_id" json:"target_id"`
+		From       time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00" json:"from"`
+		To         time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00" json:"to"`
+		Offset     int       `validate:"min=0" form:"offset" json:"offset"`
+		Limit      int       `validate:"min=0,max=100" form:"limit" json:"limit"`
+	}
+
+	VerifyResponse struct {
+		Valid    bool  `json:"valid"`
+		Checked  int64 `json:"checked"`
+		BrokenAt int64 `json:"broken_at,omitempty"`
+	}
+
+	Response struct {
+		AuditID    int64           `json:"audit_id"`
+		Sequence   int64           `json:"sequence"`
+		ActorID    string          `json:"actor_id"`
+		Action     string          `json:"action"`
+		TargetType string          `json:"target_type"`
+		TargetID   string          `json:"target_id"`
+		Before     json.RawMessage `json:"before"`
+		After      json.RawMessage `json:"after"`
+		Detail     json.RawMessage `json:"detail"`
+		IPAddress  string          `json:"ip_address"`
+		UserAgent  string          `json:"user_agent"`
+		RequestID  string          `json:"request_id"`
+		Hash       string          `json:"hash"`
+		CreatedAt  time.Time       `json:"created_at"`
+	}
+)
+
+func ActorFrom(ctx *gin.Context) Actor {
+	return Actor{
+		ActorID:   ctx.GetHeader("User_id"),
+		IPAddress: ctx.ClientIP(),
+		UserAgent: ctx.Request.UserAgent(),
+		RequestID: ctx.GetHeader("X-Request-ID"),
+	}
+}
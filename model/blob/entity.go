@@ -0,0 +1,16 @@
+package blob
+
+import "time"
+
+// Blob is a stored file keyed by the sha256 of its content, shared by every resource with the same content.
+type Blob struct {
+	Hash      string    `gorm:"column:hash; primaryKey"`
+	Path      string    `gorm:"column:path; not null"`
+	RefCount  int64     `gorm:"column:ref_count; not null"`
+	CreatedAt time.Time `gorm:"column:created_at; not null"`
+	UpdatedAt time.Time `gorm:"column:updated_at; not null"`
+}
+
+func (Blob) TableName() string {
+	return "media_blobs"
+}
@@ -0,0 +1,56 @@
+package blob
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"go-api/app"
+	"gorm.io/gorm"
+	"io"
+	"path"
+	"strings"
+)
+
+// Prefix is where content addressed files live in storage.
+const Prefix = "blobs/"
+
+// Put stores the content under its hash and skips the write when the same content is already stored.
+// The blob is only kept once a reference to it is acquired, until then the garbage collector may take it
+// after its grace period. db isn't meant to be a transaction, the blob has to be registered right away.
+func Put(db *gorm.DB, src io.ReaderAt, size int64, ext string) *Blob {
+	hasher := sha256.New()
+	_, err := io.Copy(hasher, io.NewSectionReader(src, 0, size))
+	if err != nil {
+		panic(err)
+	}
+
+	hash := hex.EncodeToString(hasher.Sum(nil))
+	blob := &Blob{Hash: hash, Path: Path(hash, ext)}
+
+	// registered before the file is looked at: the collector takes a file only while it holds the row
+	// of a blob unused since before its grace period, so this either waits for it and writes the file
+	// again or keeps it from collecting the blob
+	NewRepository().Touch(db, blob.Hash, blob.Path)
+
+	object, err := app.GetStorage().Open(blob.Path)
+	if err == nil {
+		object.Close()
+		return blob
+	}
+
+	err = app.GetStorage().Save(blob.Path, io.NewSectionReader(src, 0, size))
+	if err != nil {
+		panic(err)
+	}
+	return blob
+}
+
+// HashOf returns the hash a blob stored at p is named after.
+func HashOf(p string) string {
+	name := path.Base(p)
+	return strings.TrimSuffix(name, path.Ext(name))
+}
+
+// Path spreads blobs over directories by the first hash byte so none of them gets too large.
+func Path(hash, ext string) string {
+	return Prefix + hash[:2] + "/" + hash + ext
+}
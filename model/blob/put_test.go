@@ -0,0 +1,75 @@
+package blob_test
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"go-api/app"
+	"go-api/model/blob"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"strings"
+	"testing"
+)
+
+// dryRunDB builds statements without a server and keeps the SQL of every one of them in statements.
+func dryRunDB(t *testing.T, statements *[]string) *gorm.DB {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "root:root@tcp(localhost:3306)/go_api_test?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	assert.Nil(t, err)
+
+	err = db.Callback().Create().After("gorm:create").Register("test:capture_sql", func(tx *gorm.DB) {
+		*statements = append(*statements, tx.Statement.SQL.String())
+	})
+	assert.Nil(t, err)
+	return db
+}
+
+func TestPut(t *testing.T) {
+	t.Run("same content should share one file", func(t *testing.T) {
+		app.InitStorage(t.TempDir())
+		db := dryRunDB(t, &[]string{})
+		content := []byte("same image bytes")
+
+		first := blob.Put(db, bytes.NewReader(content), int64(len(content)), ".jpg")
+		second := blob.Put(db, bytes.NewReader(content), int64(len(content)), ".jpg")
+		assert.Equal(t, first, second)
+		assert.Equal(t, "blobs/"+first.Hash[:2]+"/"+first.Hash+".jpg", first.Path)
+
+		objects, err := app.GetStorage().List(blob.Prefix)
+		assert.NoError(t, err)
+		assert.Len(t, objects, 1)
+		assert.Equal(t, first.Path, objects[0].Path)
+		assert.Equal(t, int64(len(content)), objects[0].Size)
+	})
+
+	t.Run("different content should not collide", func(t *testing.T) {
+		app.InitStorage(t.TempDir())
+		db := dryRunDB(t, &[]string{})
+		first := blob.Put(db, bytes.NewReader([]byte("a")), 1, ".jpg")
+		second := blob.Put(db, bytes.NewReader([]byte("b")), 1, ".jpg")
+		assert.NotEqual(t, first.Path, second.Path)
+	})
+
+	t.Run("reused content should be registered again", func(t *testing.T) {
+		app.InitStorage(t.TempDir())
+		var statements []string
+		db := dryRunDB(t, &statements)
+		content := []byte("same image bytes")
+
+		blob.Put(db, bytes.NewReader(content), int64(len(content)), ".jpg")
+		blob.Put(db, bytes.NewReader(content), int64(len(content)), ".jpg")
+
+		assert.Len(t, statements, 2)
+		for _, statement := range statements {
+			assert.True(t, strings.HasPrefix(statement, "INSERT INTO `media_blobs`"))
+			assert.Contains(t, statement, "ON DUPLICATE KEY UPDATE `updated_at`=")
+		}
+	})
+}
+
+func TestHashOf(t *testing.T) {
+	assert.Equal(t, "abcdef", blob.HashOf(blob.Path("abcdef", ".jpg")))
+	assert.Equal(t, "abcdef", blob.HashOf(blob.Path("abcdef", "")))
+}
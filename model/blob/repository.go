@@ -0,0 +1,110 @@
+package blob
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type Repository interface {
+	// Acquire adds a reference to the blob, registering it on the first one.
+	Acquire(tx *gorm.DB, hash, path string)
+	Release(tx *gorm.DB, hash string)
+	// Touch registers the blob without a reference, or marks a registered one as just used, so the
+	// collector leaves it alone until a reference is acquired.
+	Touch(tx *gorm.DB, hash, path string)
+	// Adopt registers a stored file as a blob unused since modTime, unless it's registered already.
+	Adopt(tx *gorm.DB, hash, path string, modTime time.Time)
+	// FindUnreferenced returns blobs without references that haven't changed since before.
+	FindUnreferenced(tx *gorm.DB, before time.Time, limit int) []*Blob
+	// DeleteUnreferenced deletes the blob only while it still has no reference and hasn't changed since
+	// before, and reports if it did. The row stays locked until tx ends.
+	DeleteUnreferenced(tx *gorm.DB, hash string, before time.Time) bool
+	FindExistingPaths(tx *gorm.DB, paths []string) []string
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (*repositoryImpl) Acquire(tx *gorm.DB, hash, path string) {
+	now := time.Now()
+	err := tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"ref_count":  gorm.Expr("ref_count + 1"),
+			"updated_at": now,
+		}),
+	}).Create(&Blob{Hash: hash, Path: path, RefCount: 1, CreatedAt: now, UpdatedAt: now}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Release(tx *gorm.DB, hash string) {
+	err := tx.Model(&Blob{}).
+		Where("hash = ? AND ref_count > 0", hash).
+		Updates(map[string]interface{}{
+			"ref_count":  gorm.Expr("ref_count - 1"),
+			"updated_at": time.Now(),
+		}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Touch(tx *gorm.DB, hash, path string) {
+	now := time.Now()
+	err := tx.Clauses(clause.OnConflict{
+		DoUpdates: clause.Assignments(map[string]interface{}{"updated_at": now}),
+	}).Create(&Blob{Hash: hash, Path: path, CreatedAt: now, UpdatedAt: now}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Adopt(tx *gorm.DB, hash, path string, modTime time.Time) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&Blob{Hash: hash, Path: path, CreatedAt: modTime, UpdatedAt: modTime}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindUnreferenced(tx *gorm.DB, before time.Time, limit int) []*Blob {
+	var blobs []*Blob
+	err := tx.Where("ref_count = 0 AND updated_at < ?", before).
+		Order("updated_at asc").
+		Limit(limit).
+		Find(&blobs).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return blobs
+}
+
+func (*repositoryImpl) DeleteUnreferenced(tx *gorm.DB, hash string, before time.Time) bool {
+	result := tx.Where("hash = ? AND ref_count = 0 AND updated_at < ?", hash, before).Delete(&Blob{})
+	if result.Error != nil {
+		panic(exception.DatabaseError{Message: result.Error.Error()})
+	}
+	return result.RowsAffected > 0
+}
+
+func (*repositoryImpl) FindExistingPaths(tx *gorm.DB, paths []string) []string {
+	var existing []string
+	if len(paths) == 0 {
+		return existing
+	}
+
+	err := tx.Model(&Blob{}).
+		Where("path IN ?", paths).
+		Pluck("path", &existing).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return existing
+}
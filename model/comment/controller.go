@@ -10,6 +10,7 @@ import (
 type Controller interface {
 	Create(ctx *gin.Context)
 	Delete(ctx *gin.Context)
+	FindByPostID(ctx *gin.Context)
 }
 
 type controllerImpl struct {
@@ -30,7 +31,7 @@ func (c *controllerImpl) Create(ctx *gin.Context) {
 	req.PostID = ctx.Param("postID")
 	req.UserID = ctx.GetHeader("User_id")
 	c.service.Create(ctx, req)
-	ctx.IndentedJSON(http.StatusCreated, &model.WebResponse{
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusCreated,
 		Status: "ok",
 	})
@@ -43,8 +44,17 @@ func (c *controllerImpl) Delete(ctx *gin.Context) {
 		PostID: postID,
 		UserID: userID,
 	})
-	ctx.IndentedJSON(http.StatusOK, &model.WebResponse{
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusOK,
 		Status: "ok",
 	})
 }
+
+func (c *controllerImpl) FindByPostID(ctx *gin.Context) {
+	response := c.service.FindByPostID(ctx, ctx.Param("postID"), ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   response,
+	})
+}
@@ -4,8 +4,12 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator"
 	"go-api/app"
+	"go-api/cache"
 	"go-api/helper"
 	"go-api/middleware"
+	"go-api/model/like"
+	"go-api/model/session"
+	"go-api/model/token"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -15,11 +19,11 @@ import (
 func TestControllerImpl_Create(t *testing.T) {
 	app.TestDBInit()
 	repository := NewRepository()
-	service := NewService(validator.New(), repository)
+	service := NewService(validator.New(), repository, like.NewRepository(), cache.NewLoader(cache.NewLRU(100)))
 	controller := NewController(service)
 
 	router := gin.Default()
-	router.Use(middleware.JWTValidator())
+	router.Use(middleware.JWTValidator(session.NewService(validator.New(), session.NewRepository()), token.NewService(validator.New(), token.NewRepository())))
 	router.Use(gin.CustomRecovery(middleware.PanicHandler))
 
 	router.POST("/comment/:postID", controller.Create)
@@ -44,11 +48,11 @@ func TestControllerImpl_Create(t *testing.T) {
 func TestControllerImpl_Delete(t *testing.T) {
 	app.TestDBInit()
 	repository := NewRepository()
-	service := NewService(validator.New(), repository)
+	service := NewService(validator.New(), repository, like.NewRepository(), cache.NewLoader(cache.NewLRU(100)))
 	controller := NewController(service)
 
 	router := gin.Default()
-	router.Use(middleware.JWTValidator())
+	router.Use(middleware.JWTValidator(session.NewService(validator.New(), session.NewRepository()), token.NewService(validator.New(), token.NewRepository())))
 	router.Use(gin.CustomRecovery(middleware.PanicHandler))
 
 	router.DELETE("/comment/:postID", controller.Delete)
@@ -64,4 +68,4 @@ func TestControllerImpl_Delete(t *testing.T) {
 	res := w.Result()
 	body, _ := ioutil.ReadAll(res.Body)
 	t.Log(string(body))
-}
\ No newline at end of file
+}
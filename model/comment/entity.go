@@ -3,9 +3,22 @@ package comment
 import "time"
 
 type Comment struct {
-	ID      int64  `gorm:"column:comment_id;primaryKey,autoIncrement"`
-	Content string `gorm:"column:content"`
-	PostID    string `gorm:"column:post_id"`
-	UserID    string `gorm:"column:user_id"`
+	ID        int64     `gorm:"column:comment_id;primaryKey,autoIncrement"`
+	Content   string    `gorm:"column:content"`
+	PostID    string    `gorm:"column:post_id"`
+	UserID    string    `gorm:"column:user_id"`
 	CreatedAt time.Time `gorm:"column:created_at"`
 }
+
+// Listed is a comment as FindByPostID lists it, with the username of its author.
+type Listed struct {
+	Comment
+	Username string `gorm:"column:username"`
+}
+
+// PostState is what Create needs to know about the commented post, read straight from the posts
+// table since the post package depends on this one.
+type PostState struct {
+	PostID           string `gorm:"column:post_id"`
+	CommentsDisabled bool   `gorm:"column:comments_disabled"`
+}
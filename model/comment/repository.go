@@ -2,15 +2,25 @@ package comment
 
 import (
 	"go-api/exception"
+	"go-api/model/like"
+	"go-api/model/relation"
 	"gorm.io/gorm"
 )
 
 type Repository interface {
 	Create(tx *gorm.DB, comment *Comment)
+	// Delete and DeleteByPostID remove the likes of the deleted comments as well.
 	Delete(tx *gorm.DB, commentID int64)
 	CountByPostID(tx *gorm.DB, postID string) int64
-	FindByPostID(tx *gorm.DB, postID string) []Comment
+	FindByCommentID(tx *gorm.DB, commentID int64) *Comment
+	// FindByPostID leaves out comments of suspended users and of users blocked either way by viewerID.
+	FindByPostID(tx *gorm.DB, postID, viewerID string) []Listed
 	FindByPostIDAndUserID(tx *gorm.DB, postID, userID string) *Comment
+	DeleteByPostID(tx *gorm.DB, postID string)
+	// AddToPostCount moves the comments_count counter of the post by delta, in the same transaction as the comment.
+	AddToPostCount(tx *gorm.DB, postID string, delta int)
+	// FindPostState returns an empty state unless viewerID may see the post.
+	FindPostState(tx *gorm.DB, postID, viewerID string) *PostState
 }
 
 type repositoryImpl struct {
@@ -28,7 +38,12 @@ func (*repositoryImpl) Create(tx *gorm.DB, comment *Comment) {
 }
 
 func (*repositoryImpl) Delete(tx *gorm.DB, commentID int64) {
-	err := tx.Where("comment_id = ?", commentID).Delete(&Comment{}).Error
+	err := tx.Where("comment_id = ?", commentID).Delete(&like.CommentLike{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	err = tx.Where("comment_id = ?", commentID).Delete(&Comment{}).Error
 	if err != nil {
 		panic(exception.DatabaseError{Message: err.Error()})
 	}
@@ -46,10 +61,14 @@ func (*repositoryImpl) CountByPostID(tx *gorm.DB, postID string) int64 {
 	return commentsCount
 }
 
-func (*repositoryImpl) FindByPostID(tx *gorm.DB, postID string) []Comment {
-	var comments []Comment
-	err := tx.Where("post_id = ?", postID).
-		Order("created_at asc").
+func (*repositoryImpl) FindByPostID(tx *gorm.DB, postID, viewerID string) []Listed {
+	var comments []Listed
+	err := tx.Table("comments c").
+		Select("c.*, u.username").
+		Joins("JOIN users u ON u.user_id = c.user_id").
+		Where("c.post_id = ? AND u.is_suspended = ?", postID, false).
+		Scopes(relation.NotBlocked("c.user_id", viewerID)).
+		Order("c.created_at asc").
 		Find(&comments).Error
 	if err != nil {
 		panic(exception.DatabaseError{Message: err.Error()})
@@ -66,3 +85,49 @@ func (*repositoryImpl) FindByPostIDAndUserID(tx *gorm.DB, postID, userID string)
 	}
 	return &comment
 }
+
+func (*repositoryImpl) FindByCommentID(tx *gorm.DB, commentID int64) *Comment {
+	var comment Comment
+	err := tx.Where("comment_id = ?", commentID).
+		Find(&comment).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return &comment
+}
+
+func (*repositoryImpl) DeleteByPostID(tx *gorm.DB, postID string) {
+	err := tx.Where("comment_id IN (?)", tx.Model(&Comment{}).Select("comment_id").Where("post_id = ?", postID)).
+		Delete(&like.CommentLike{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	err = tx.Where("post_id = ?", postID).Delete(&Comment{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindPostState(tx *gorm.DB, postID, viewerID string) *PostState {
+	var state PostState
+	err := tx.Table("posts AS p").
+		Select("p.post_id, p.comments_disabled").
+		Scopes(relation.VisiblePosts(viewerID)).
+		Where("p.post_id = ?", postID).
+		Limit(1).
+		Find(&state).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return &state
+}
+
+func (*repositoryImpl) AddToPostCount(tx *gorm.DB, postID string, delta int) {
+	err := tx.Table("posts").
+		Where("post_id = ?", postID).
+		UpdateColumn("comments_count", gorm.Expr("comments_count + ?", delta)).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
@@ -4,6 +4,7 @@ import "github.com/gin-gonic/gin"
 
 func InitRoutes(router *gin.RouterGroup, controller Controller) {
 	userGroup := router.Group("/comment")
+	userGroup.GET("/:postID", controller.FindByPostID)
 	userGroup.POST("/:postID", controller.Create)
 	userGroup.DELETE("/:postID", controller.Delete)
 }
@@ -4,23 +4,29 @@ import (
 	"context"
 	"github.com/go-playground/validator"
 	"go-api/app"
+	"go-api/cache"
 	"go-api/exception"
 	"go-api/helper"
+	"go-api/model/like"
 	"time"
 )
 
 type Service interface {
 	Create(ctx context.Context, req *CreateRequest)
 	Delete(ctx context.Context, req *DeleteRequest)
+	// FindByPostID lists the comments oldest first with their like counts.
+	FindByPostID(ctx context.Context, postID, viewerID string) []*Response
 }
 
 type serviceImpl struct {
 	validate    *validator.Validate
 	commentRepo Repository
+	likeRepo    like.Repository
+	cache       *cache.Loader
 }
 
-func NewService(validate *validator.Validate, commentRepo Repository) Service {
-	return &serviceImpl{validate: validate, commentRepo: commentRepo}
+func NewService(validate *validator.Validate, commentRepo Repository, likeRepo like.Repository, cache *cache.Loader) Service {
+	return &serviceImpl{validate: validate, commentRepo: commentRepo, likeRepo: likeRepo, cache: cache}
 }
 
 func (s *serviceImpl) Create(ctx context.Context, req *CreateRequest) {
@@ -29,15 +35,25 @@ func (s *serviceImpl) Create(ctx context.Context, req *CreateRequest) {
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
+	defer s.cache.Invalidate(ctx, cache.PostCountsKey(req.PostID))
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
+	post := s.commentRepo.FindPostState(tx, req.PostID, req.UserID)
+	if post.PostID == "" {
+		panic(exception.NotFoundError{Message: "post not found"})
+	}
+	if post.CommentsDisabled {
+		panic(exception.NoAccessError{Message: "comments are turned off for this post"})
+	}
+
 	s.commentRepo.Create(tx, &Comment{
 		Content:   req.Content,
 		PostID:    req.PostID,
 		UserID:    req.UserID,
 		CreatedAt: time.Now(),
 	})
+	s.commentRepo.AddToPostCount(tx, req.PostID, 1)
 }
 
 func (s *serviceImpl) Delete(ctx context.Context, req *DeleteRequest) {
@@ -46,7 +62,8 @@ func (s *serviceImpl) Delete(ctx context.Context, req *DeleteRequest) {
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
+	defer s.cache.Invalidate(ctx, cache.PostCountsKey(req.PostID))
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
 	comment := s.commentRepo.FindByPostIDAndUserID(tx, req.PostID, req.UserID)
@@ -55,4 +72,36 @@ func (s *serviceImpl) Delete(ctx context.Context, req *DeleteRequest) {
 	}
 
 	s.commentRepo.Delete(tx, comment.ID)
+	s.commentRepo.AddToPostCount(tx, comment.PostID, -1)
+}
+
+func (s *serviceImpl) FindByPostID(ctx context.Context, postID, viewerID string) []*Response {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	if s.commentRepo.FindPostState(tx, postID, viewerID).PostID == "" {
+		panic(exception.NotFoundError{Message: "post not found"})
+	}
+
+	comments := s.commentRepo.FindByPostID(tx, postID, viewerID)
+	commentIDs := make([]int64, len(comments))
+	for i, c := range comments {
+		commentIDs[i] = c.ID
+	}
+	counts, liked := s.likeRepo.CountByCommentIDs(tx, commentIDs, viewerID)
+
+	response := make([]*Response, len(comments))
+	for i, c := range comments {
+		response[i] = &Response{
+			CommentID:      c.ID,
+			PostID:         c.PostID,
+			UserID:         c.UserID,
+			Username:       c.Username,
+			Content:        c.Content,
+			LikesCount:     counts[c.ID],
+			ViewerHasLiked: liked[c.ID],
+			CreatedAt:      c.CreatedAt,
+		}
+	}
+	return response
 }
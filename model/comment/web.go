@@ -1,5 +1,7 @@
 package comment
 
+import "time"
+
 type (
 	CreateRequest struct {
 		PostID  string `validate:"required" json:"post_id"`
@@ -11,4 +13,15 @@ type (
 		PostID string `validate:"required" json:"post_id"`
 		UserID string `validate:"required" json:"user_id"`
 	}
+
+	Response struct {
+		CommentID      int64     `json:"comment_id"`
+		PostID         string    `json:"post_id"`
+		UserID         string    `json:"user_id"`
+		Username       string    `json:"username"`
+		Content        string    `json:"content"`
+		LikesCount     int64     `json:"likes_count"`
+		ViewerHasLiked bool      `json:"viewer_has_liked"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
 )
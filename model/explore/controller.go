@@ -0,0 +1,35 @@
+package explore
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/model"
+	"net/http"
+)
+
+type Controller interface {
+	Explore(ctx *gin.Context)
+}
+
+type controllerImpl struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return &controllerImpl{service: service}
+}
+
+func (c *controllerImpl) Explore(ctx *gin.Context) {
+	var req Request
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		panic(err)
+	}
+
+	req.ViewerID = ctx.GetHeader("User_id")
+	res := c.service.Explore(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
@@ -0,0 +1,33 @@
+package explore
+
+import (
+	"encoding/base64"
+	"fmt"
+	"go-api/exception"
+)
+
+// cursor points at the last position read inside a generation.
+type cursor struct {
+	Generation int64
+	Position   int
+}
+
+func (c cursor) Encode() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(fmt.Sprintf("%d:%d", c.Generation, c.Position)))
+}
+
+func decodeCursor(raw string) cursor {
+	var c cursor
+	if raw == "" {
+		return c
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(raw)
+	if err == nil {
+		_, err = fmt.Sscanf(string(b), "%d:%d", &c.Generation, &c.Position)
+	}
+	if err != nil || c.Generation <= 0 || c.Position < 0 {
+		panic(exception.Errors{Errors: []error{exception.FieldError{Field: "cursor", Message: "invalid cursor"}}})
+	}
+	return c
+}
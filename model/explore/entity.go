@@ -0,0 +1,87 @@
+package explore
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// Score is one ranked post inside a generation, a generation is the full result of one recompute run.
+// Pages are read from a single generation so a recompute in between doesn't shift them.
+type Score struct {
+	Generation    int64     `gorm:"column:generation; primaryKey"`
+	Position      int       `gorm:"column:position; primaryKey"`
+	PostID        string    `gorm:"column:post_id; not null"`
+	UserID        string    `gorm:"column:user_id; not null"`
+	Score         float64   `gorm:"column:score; not null"`
+	LikesCount    int64     `gorm:"column:likes_count; not null"`
+	CommentsCount int64     `gorm:"column:comments_count; not null"`
+	PostedAt      time.Time `gorm:"column:posted_at; not null"`
+	// ResourceCount and HideLikeCount are read from the post when a page is loaded, they aren't part of the snapshot.
+	ResourceCount int64 `gorm:"column:resource_count; ->"`
+	HideLikeCount bool  `gorm:"column:hide_like_count; ->"`
+}
+
+func (Score) TableName() string {
+	return "explore_scores"
+}
+
+// Engagement is the raw activity of a post used to compute its score.
+type Engagement struct {
+	PostID        string    `gorm:"column:post_id"`
+	UserID        string    `gorm:"column:user_id"`
+	CreatedAt     time.Time `gorm:"column:created_at"`
+	LikesCount    int64     `gorm:"column:likes_count"`
+	CommentsCount int64     `gorm:"column:comments_count"`
+}
+
+const (
+	likeWeight    = 1
+	commentWeight = 2
+	// gravity controls how fast older posts sink, higher values favour fresh posts.
+	gravity = 1.5
+)
+
+// ScoreOf weights engagement and decays it by the post age in hours.
+func ScoreOf(likes, comments int64, age time.Duration) float64 {
+	if age < 0 {
+		age = 0
+	}
+	engagement := float64(likes*likeWeight + comments*commentWeight)
+	return engagement / math.Pow(age.Hours()+2, gravity)
+}
+
+// rank scores every engagement and orders them best first, ties go to the newer post.
+func rank(generation int64, engagements []*Engagement, now time.Time, limit int) []*Score {
+	scores := make([]*Score, 0, len(engagements))
+	for _, e := range engagements {
+		scores = append(scores, &Score{
+			Generation:    generation,
+			PostID:        e.PostID,
+			UserID:        e.UserID,
+			Score:         ScoreOf(e.LikesCount, e.CommentsCount, now.Sub(e.CreatedAt)),
+			LikesCount:    e.LikesCount,
+			CommentsCount: e.CommentsCount,
+			PostedAt:      e.CreatedAt,
+		})
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		a, b := scores[i], scores[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.PostedAt.Equal(b.PostedAt) {
+			return a.PostedAt.After(b.PostedAt)
+		}
+		return a.PostID < b.PostID
+	})
+
+	if len(scores) > limit {
+		scores = scores[:limit]
+	}
+	for i, s := range scores {
+		s.Position = i + 1
+	}
+	return scores
+}
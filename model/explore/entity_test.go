@@ -0,0 +1,63 @@
+package explore
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestScoreOf(t *testing.T) {
+	t.Run("comments should weigh more than likes", func(t *testing.T) {
+		assert.Greater(t, ScoreOf(0, 1, time.Hour), ScoreOf(1, 0, time.Hour))
+	})
+
+	t.Run("older post should decay", func(t *testing.T) {
+		assert.Greater(t, ScoreOf(10, 0, time.Hour), ScoreOf(10, 0, 24*time.Hour))
+	})
+
+	t.Run("future post should be treated as new", func(t *testing.T) {
+		assert.Equal(t, ScoreOf(1, 1, 0), ScoreOf(1, 1, -time.Hour))
+	})
+}
+
+func TestRank(t *testing.T) {
+	now := time.Date(2021, 11, 20, 10, 0, 0, 0, time.UTC)
+	engagements := []*Engagement{
+		{PostID: "old", CreatedAt: now.Add(-72 * time.Hour), LikesCount: 20},
+		{PostID: "fresh", CreatedAt: now.Add(-time.Hour), LikesCount: 5, CommentsCount: 1},
+		{PostID: "quiet-old", CreatedAt: now.Add(-2 * time.Hour)},
+		{PostID: "quiet-new", CreatedAt: now.Add(-time.Hour)},
+	}
+
+	t.Run("should order by score then newest", func(t *testing.T) {
+		scores := rank(1, engagements, now, 10)
+		var ids []string
+		for i, s := range scores {
+			assert.Equal(t, i+1, s.Position)
+			assert.Equal(t, int64(1), s.Generation)
+			ids = append(ids, s.PostID)
+		}
+		assert.Equal(t, []string{"fresh", "old", "quiet-new", "quiet-old"}, ids)
+	})
+
+	t.Run("should keep only the limit", func(t *testing.T) {
+		assert.Len(t, rank(1, engagements, now, 2), 2)
+	})
+}
+
+func TestCursor(t *testing.T) {
+	t.Run("encoded cursor should decode back", func(t *testing.T) {
+		c := cursor{Generation: 1637402400000000000, Position: 40}
+		assert.Equal(t, c, decodeCursor(c.Encode()))
+	})
+
+	t.Run("empty cursor should start from the top", func(t *testing.T) {
+		assert.Equal(t, cursor{}, decodeCursor(""))
+	})
+
+	t.Run("malformed cursor should panic", func(t *testing.T) {
+		assert.Panics(t, func() {
+			decodeCursor("not a cursor")
+		})
+	})
+}
@@ -0,0 +1,102 @@
+package explore
+
+import (
+	"go-api/exception"
+	"go-api/model/post"
+	"gorm.io/gorm"
+	"time"
+)
+
+type Repository interface {
+	FindEngagementSince(tx *gorm.DB, since time.Time) []*Engagement
+	CreateScores(tx *gorm.DB, scores []*Score)
+	DeleteGenerationsBefore(tx *gorm.DB, generation int64)
+	FindLatestGeneration(tx *gorm.DB) int64
+	HasGeneration(tx *gorm.DB, generation int64) bool
+	// FindPage returns scores after position, skipping posts of private or suspended users and of excludedUserIDs.
+	FindPage(tx *gorm.DB, generation int64, position, limit int, excludedUserIDs []string) []*Score
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+const scoreBatchSize = 100
+
+func (*repositoryImpl) FindEngagementSince(tx *gorm.DB, since time.Time) []*Engagement {
+	var engagements []*Engagement
+	err := tx.Table("posts p").
+		Select("p.post_id, p.user_id, p.created_at, p.likes_count, p.comments_count").
+		Where("p.created_at >= ? AND p.deleted_at IS NULL AND p.status = ? AND p.is_archived = ?", since, post.StatusPublished, false).
+		Find(&engagements).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return engagements
+}
+
+func (*repositoryImpl) CreateScores(tx *gorm.DB, scores []*Score) {
+	if len(scores) == 0 {
+		return
+	}
+
+	err := tx.CreateInBatches(&scores, scoreBatchSize).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) DeleteGenerationsBefore(tx *gorm.DB, generation int64) {
+	err := tx.Where("generation < ?", generation).Delete(&Score{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindLatestGeneration(tx *gorm.DB) int64 {
+	var generation int64
+	err := tx.Model(&Score{}).
+		Select("COALESCE(MAX(generation), 0)").
+		Scan(&generation).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return generation
+}
+
+func (*repositoryImpl) HasGeneration(tx *gorm.DB, generation int64) bool {
+	var count int64
+	err := tx.Model(&Score{}).
+		Where("generation = ?", generation).
+		Limit(1).
+		Count(&count).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return count > 0
+}
+
+func (*repositoryImpl) FindPage(tx *gorm.DB, generation int64, position, limit int, excludedUserIDs []string) []*Score {
+	var scores []*Score
+	query := tx.Table("explore_scores s").
+		Select("s.*, p.resource_count, p.hide_like_count").
+		Joins("JOIN posts p ON p.post_id = s.post_id").
+		Joins("JOIN users u ON u.user_id = s.user_id").
+		Where("s.generation = ? AND s.position > ?", generation, position).
+		Where("p.deleted_at IS NULL AND p.is_archived = ?", false).
+		Where("u.is_private = ? AND u.is_suspended = ?", false, false)
+	if len(excludedUserIDs) > 0 {
+		query = query.Where("s.user_id NOT IN ?", excludedUserIDs)
+	}
+
+	err := query.Order("s.position asc").
+		Limit(limit).
+		Find(&scores).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return scores
+}
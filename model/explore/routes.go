@@ -0,0 +1,8 @@
+package explore
+
+import "github.com/gin-gonic/gin"
+
+func InitRoutes(router *gin.RouterGroup, controller Controller) {
+	exploreGroup := router.Group("/explore")
+	exploreGroup.GET("", controller.Explore)
+}
@@ -0,0 +1,109 @@
+package explore
+
+import (
+	"context"
+	"github.com/go-playground/validator"
+	"go-api/app"
+	"go-api/helper"
+	"go-api/model/post"
+	"go-api/model/relation"
+	"go-api/model/resource"
+	"time"
+)
+
+type Service interface {
+	Explore(ctx context.Context, req *Request) *Response
+	// Recompute scores recent posts into a new generation, it's run periodically by a background job.
+	Recompute(ctx context.Context)
+}
+
+const (
+	RecomputeInterval = 10 * time.Minute
+	// recentWindow is how old a post can be and still show up on explore.
+	recentWindow = 7 * 24 * time.Hour
+	// generationTTL keeps older generations around so clients paging through them don't get cut off.
+	generationTTL = time.Hour
+	maxRanked     = 1000
+	defaultLimit  = 20
+)
+
+type serviceImpl struct {
+	validate           *validator.Validate
+	exploreRepository  Repository
+	relationRepository relation.Repository
+	resourceRepository resource.Repository
+	now                func() time.Time
+}
+
+func NewService(validate *validator.Validate, exploreRepository Repository, relationRepository relation.Repository, resourceRepository resource.Repository) Service {
+	return &serviceImpl{validate: validate, exploreRepository: exploreRepository, relationRepository: relationRepository, resourceRepository: resourceRepository, now: time.Now}
+}
+
+func (s *serviceImpl) Explore(ctx context.Context, req *Request) *Response {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultLimit
+	}
+
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	// an expired generation restarts from the top of the latest one
+	cur := decodeCursor(req.Cursor)
+	if cur.Generation == 0 || !s.exploreRepository.HasGeneration(tx, cur.Generation) {
+		cur = cursor{Generation: s.exploreRepository.FindLatestGeneration(tx)}
+	}
+
+	response := &Response{Posts: []*post.Response{}}
+	if cur.Generation == 0 {
+		return response
+	}
+
+	excluded := []string{req.ViewerID}
+	excluded = append(excluded, s.relationRepository.FindFollowingIDs(tx, req.ViewerID)...)
+	excluded = append(excluded, s.relationRepository.FindBlockedIDs(tx, req.ViewerID)...)
+
+	scores := s.exploreRepository.FindPage(tx, cur.Generation, cur.Position, req.Limit, excluded)
+	postIDs := make([]string, len(scores))
+	for i, score := range scores {
+		postIDs[i] = score.PostID
+	}
+	thumbnails := s.resourceRepository.FindFirstByPostIDs(tx, postIDs)
+	for _, score := range scores {
+		var thumbnail *resource.Response
+		if first, ok := thumbnails[score.PostID]; ok {
+			thumbnail = first.ToThumbnail(req.ViewerID)
+		}
+		p := &post.Post{UserID: score.UserID, HideLikeCount: score.HideLikeCount}
+		likesCount, likeCountHidden := p.LikeCountFor(req.ViewerID, score.LikesCount)
+		response.Posts = append(response.Posts, &post.Response{
+			PostID:          score.PostID,
+			Thumbnail:       thumbnail,
+			ResourceCount:   score.ResourceCount,
+			LikesCount:      likesCount,
+			CommentsCount:   score.CommentsCount,
+			LikeCountHidden: likeCountHidden,
+		})
+	}
+
+	if len(scores) == req.Limit {
+		response.NextCursor = cursor{Generation: cur.Generation, Position: scores[len(scores)-1].Position}.Encode()
+	}
+	return response
+}
+
+func (s *serviceImpl) Recompute(ctx context.Context) {
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	now := s.now()
+	generation := now.UnixNano()
+
+	engagements := s.exploreRepository.FindEngagementSince(tx, now.Add(-recentWindow))
+	s.exploreRepository.CreateScores(tx, rank(generation, engagements, now, maxRanked))
+	s.exploreRepository.DeleteGenerationsBefore(tx, now.Add(-generationTTL).UnixNano())
+}
@@ -0,0 +1,16 @@
+package explore
+
+import "go-api/model/post"
+
+type (
+	Request struct {
+		Cursor   string `form:"cursor" json:"cursor"`
+		Limit    int    `validate:"min=0,max=50" form:"limit" json:"limit"`
+		ViewerID string `validate:"required" json:"-"`
+	}
+
+	Response struct {
+		Posts      []*post.Response `json:"posts"`
+		NextCursor string           `json:"next_cursor,omitempty"`
+	}
+)
@@ -2,13 +2,20 @@ package like
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go-api/exception"
 	"go-api/model"
 	"net/http"
+	"strconv"
 )
 
 type Controller interface {
 	Create(ctx *gin.Context)
 	Delete(ctx *gin.Context)
+	FindPostLikers(ctx *gin.Context)
+	LikeComment(ctx *gin.Context)
+	UnlikeComment(ctx *gin.Context)
+	FindCommentLikers(ctx *gin.Context)
 }
 
 type controllerImpl struct {
@@ -20,14 +27,19 @@ func NewController(service Service) *controllerImpl {
 }
 
 func (c *controllerImpl) Create(ctx *gin.Context) {
-	postID := ctx.Param("postID")
-	userID := ctx.GetHeader("User_id")
-	req := &Request{
-		PostID: postID,
-		UserID: userID,
+	req := &Request{}
+	// the body is optional, an empty one is a plain like
+	if ctx.Request.ContentLength > 0 {
+		err := ctx.ShouldBindWith(req, binding.JSON)
+		if err != nil {
+			panic(err)
+		}
 	}
+
+	req.PostID = ctx.Param("postID")
+	req.UserID = ctx.GetHeader("User_id")
 	c.service.Create(ctx, req)
-	ctx.IndentedJSON(http.StatusCreated, &model.WebResponse{
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusCreated,
 		Status: "ok",
 	})
@@ -41,8 +53,70 @@ func (c *controllerImpl) Delete(ctx *gin.Context) {
 		UserID: userID,
 	}
 	c.service.Delete(ctx, req)
-	ctx.IndentedJSON(http.StatusOK, &model.WebResponse{
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) FindPostLikers(ctx *gin.Context) {
+	req := &LikersRequest{}
+	err := ctx.ShouldBindQuery(req)
+	if err != nil {
+		panic(err)
+	}
+
+	req.ViewerID = ctx.GetHeader("User_id")
+	response := c.service.FindPostLikers(ctx, ctx.Param("postID"), req)
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusOK,
 		Status: "ok",
+		Data:   response,
 	})
 }
+
+func (c *controllerImpl) LikeComment(ctx *gin.Context) {
+	c.service.LikeComment(ctx, &CommentRequest{
+		CommentID: commentID(ctx),
+		UserID:    ctx.GetHeader("User_id"),
+	})
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusCreated,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) UnlikeComment(ctx *gin.Context) {
+	c.service.UnlikeComment(ctx, &CommentRequest{
+		CommentID: commentID(ctx),
+		UserID:    ctx.GetHeader("User_id"),
+	})
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) FindCommentLikers(ctx *gin.Context) {
+	req := &LikersRequest{}
+	err := ctx.ShouldBindQuery(req)
+	if err != nil {
+		panic(err)
+	}
+
+	req.ViewerID = ctx.GetHeader("User_id")
+	response := c.service.FindCommentLikers(ctx, commentID(ctx), req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   response,
+	})
+}
+
+func commentID(ctx *gin.Context) int64 {
+	id, err := strconv.ParseInt(ctx.Param("commentID"), 10, 64)
+	if err != nil {
+		panic(exception.NotFoundError{Message: "comment not found"})
+	}
+	return id
+}
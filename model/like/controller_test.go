@@ -1,24 +1,33 @@
 package like
 
 import (
+	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
 	"go-api/app"
+	"go-api/cache"
 	"go-api/middleware"
+	"go-api/model/relation"
+	"go-api/model/session"
+	"go-api/model/token"
+	"go-api/model/user"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 )
 
 func TestControllerImpl_Create(t *testing.T) {
 	app.TestDBInit()
 	repository := NewRepository()
-	service := NewService(validator.New(), repository)
+	service := NewService(validator.New(), repository, cache.NewLoader(cache.NewLRU(100)))
 	controller := NewController(service)
 
 	router := gin.Default()
-	router.Use(middleware.JWTValidator())
+	router.Use(middleware.JWTValidator(session.NewService(validator.New(), session.NewRepository()), token.NewService(validator.New(), token.NewRepository())))
 	router.Use(gin.CustomRecovery(middleware.PanicHandler))
 
 	router.POST("/like/:postID", controller.Create)
@@ -39,11 +48,11 @@ func TestControllerImpl_Create(t *testing.T) {
 func TestControllerImpl_Delete(t *testing.T) {
 	app.TestDBInit()
 	repository := NewRepository()
-	service := NewService(validator.New(), repository)
+	service := NewService(validator.New(), repository, cache.NewLoader(cache.NewLRU(100)))
 	controller := NewController(service)
 
 	router := gin.Default()
-	router.Use(middleware.JWTValidator())
+	router.Use(middleware.JWTValidator(session.NewService(validator.New(), session.NewRepository()), token.NewService(validator.New(), token.NewRepository())))
 	router.Use(gin.CustomRecovery(middleware.PanicHandler))
 
 	router.DELETE("/like/:postID", controller.Delete)
@@ -60,3 +69,82 @@ func TestControllerImpl_Delete(t *testing.T) {
 	body, _ := ioutil.ReadAll(res.Body)
 	t.Log(string(body))
 }
+
+func TestControllerImpl_FindPostLikers(t *testing.T) {
+	app.TestDBInit()
+	service := NewService(validator.New(), NewRepository(), cache.NewLoader(cache.NewLRU(100)))
+	controller := NewController(service)
+
+	router := gin.New()
+	router.Use(gin.CustomRecovery(middleware.PanicHandler))
+	router.GET("/like/:postID/users", controller.FindPostLikers)
+
+	now := time.Now()
+	var owner, viewer, followed, latest, older, blocked, suspended string
+	for _, id := range []*string{&owner, &viewer, &followed, &latest, &older, &blocked, &suspended} {
+		*id = uuid.NewV4().String()
+		assert.Nil(t, app.DB.Create(&user.User{ID: *id, Email: *id + "@example.com", Username: *id, DisplayName: *id, Role: user.RoleUser, IsSuspended: id == &suspended, CreatedAt: now, UpdatedAt: now}).Error)
+	}
+
+	postID := uuid.NewV4().String()
+	assert.Nil(t, app.DB.Table("posts").Create(map[string]interface{}{
+		"post_id":    postID,
+		"user_id":    owner,
+		"status":     "published",
+		"created_at": now,
+		"updated_at": now,
+	}).Error)
+	assert.Nil(t, app.DB.Create(&relation.Follow{FollowerID: viewer, FollowingID: followed, CreatedAt: now}).Error)
+	assert.Nil(t, app.DB.Create(&relation.Block{BlockerID: viewer, BlockedID: blocked, CreatedAt: now}).Error)
+	// the followed user liked first, they still come first
+	for i, liker := range []string{followed, older, latest, blocked, suspended} {
+		assert.Nil(t, app.DB.Create(&Like{PostID: postID, UserID: liker, Reaction: ReactionLike, CreatedAt: now.Add(time.Duration(i) * time.Minute)}).Error)
+	}
+
+	findLikers := func(query string) *LikersResponse {
+		req := httptest.NewRequest("GET", "/like/"+postID+"/users?"+query, nil)
+		req.Header.Set("User_id", viewer)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var res struct {
+			Data LikersResponse `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return &res.Data
+	}
+	userIDs := func(res *LikersResponse) []string {
+		ids := []string{}
+		for _, u := range res.Users {
+			ids = append(ids, u.UserID)
+		}
+		return ids
+	}
+
+	t.Run("followed users should come first and blocked or suspended ones should be left out", func(t *testing.T) {
+		res := findLikers("limit=2")
+		assert.Equal(t, []string{followed, latest}, userIDs(res))
+		assert.True(t, res.Users[0].FollowedByViewer)
+		assert.Equal(t, followed, res.Users[0].Username)
+		assert.NotEmpty(t, res.Users[0].ProfilePictureURL)
+		assert.Equal(t, 2, res.NextOffset)
+	})
+
+	t.Run("next offset should return the rest", func(t *testing.T) {
+		res := findLikers("limit=2&offset=2")
+		assert.Equal(t, []string{older}, userIDs(res))
+		assert.Equal(t, 0, res.NextOffset)
+	})
+
+	t.Run("post of a user who blocked the viewer should not be found", func(t *testing.T) {
+		assert.Nil(t, app.DB.Create(&relation.Block{BlockerID: owner, BlockedID: viewer, CreatedAt: now}).Error)
+		defer app.DB.Where("blocker_id = ? AND blocked_id = ?", owner, viewer).Delete(&relation.Block{})
+
+		req := httptest.NewRequest("GET", "/like/"+postID+"/users", nil)
+		req.Header.Set("User_id", viewer)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -3,8 +3,32 @@ package like
 import "time"
 
 type Like struct {
-	ID     int64  `gorm:"column:like_id;primaryKey,autoIncrement"`
-	PostID string `gorm:"column:post_id"`
-	UserID    string    `gorm:"column:user_id"`
+	ID int64 `gorm:"column:like_id;primaryKey,autoIncrement"`
+	// a user likes a post at most once, the pair is unique so concurrent likes can't both be inserted
+	PostID    string    `gorm:"column:post_id;uniqueIndex:likes_post_user"`
+	UserID    string    `gorm:"column:user_id;uniqueIndex:likes_post_user"`
+	Reaction  string    `gorm:"column:reaction;default:like"`
 	CreatedAt time.Time `gorm:"column:created_at"`
 }
+
+type CommentLike struct {
+	CommentID int64     `gorm:"column:comment_id;primaryKey"`
+	UserID    string    `gorm:"column:user_id;primaryKey"`
+	CreatedAt time.Time `gorm:"column:created_at"`
+}
+
+func (CommentLike) TableName() string {
+	return "comment_likes"
+}
+
+// Liker is someone who liked a post or comment, as seen by one viewer.
+type Liker struct {
+	UserID           string    `gorm:"column:user_id"`
+	Username         string    `gorm:"column:username"`
+	DisplayName      string    `gorm:"column:display_name"`
+	AvatarKey        string    `gorm:"column:avatar_key"`
+	IsVerified       bool      `gorm:"column:is_verified"`
+	Reaction         string    `gorm:"column:reaction"`
+	LikedAt          time.Time `gorm:"column:liked_at"`
+	FollowedByViewer bool      `gorm:"column:followed_by_viewer"`
+}
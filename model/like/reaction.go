@@ -0,0 +1,25 @@
+package like
+
+// Reactions are the only reactions a post takes, ReactionLike is what a plain like and every like
+// stored before reactions existed count as.
+const (
+	ReactionLike  = "like"
+	ReactionLove  = "love"
+	ReactionHaha  = "haha"
+	ReactionWow   = "wow"
+	ReactionSad   = "sad"
+	ReactionAngry = "angry"
+)
+
+var Reactions = []string{ReactionLike, ReactionLove, ReactionHaha, ReactionWow, ReactionSad, ReactionAngry}
+
+// CountReactions totals reaction counts and fills in zero for the reactions nobody used.
+func CountReactions(counts map[string]int64) (map[string]int64, int64) {
+	all := make(map[string]int64, len(Reactions))
+	var total int64
+	for _, reaction := range Reactions {
+		all[reaction] = counts[reaction]
+		total += counts[reaction]
+	}
+	return all, total
+}
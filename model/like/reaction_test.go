@@ -0,0 +1,15 @@
+package like
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCountReactions(t *testing.T) {
+	counts, total := CountReactions(map[string]int64{ReactionLike: 3, ReactionHaha: 2})
+	assert.Equal(t, int64(5), total)
+	assert.Len(t, counts, len(Reactions))
+	assert.Equal(t, int64(3), counts[ReactionLike])
+	assert.Equal(t, int64(2), counts[ReactionHaha])
+	assert.Equal(t, int64(0), counts[ReactionAngry])
+}
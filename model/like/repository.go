@@ -2,15 +2,40 @@ package like
 
 import (
 	"go-api/exception"
+	"go-api/model/relation"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type Repository interface {
-	Create(tx *gorm.DB, like *Like)
-	Delete(tx *gorm.DB, likeID int64)
+	// Create and Delete are false when the post was already liked, or the like already gone.
+	Create(tx *gorm.DB, like *Like) bool
+	Delete(tx *gorm.DB, likeID int64) bool
 	CountByPostID(tx *gorm.DB, postID, userID string) (int64, bool)
 	FindByPostID(tx *gorm.DB, postID string) []*Like
 	FindByPostIDAndUserID(tx *gorm.DB, postID, userID string) *Like
+	DeleteByPostID(tx *gorm.DB, postID string)
+	// AddToPostCount moves the likes_count counter of the post by delta, in the same transaction as the like.
+	AddToPostCount(tx *gorm.DB, postID string, delta int)
+	UpdateReaction(tx *gorm.DB, likeID int64, reaction string)
+	// CountReactionsByPostID counts the post's likes per reaction.
+	CountReactionsByPostID(tx *gorm.DB, postID string) map[string]int64
+	// MigrateReactions gives likes stored before reactions existed the default reaction.
+	MigrateReactions(tx *gorm.DB) int64
+	// FindPostLikers and FindCommentLikers list people the viewer follows first, then the latest likes.
+	// Users blocked either way by the viewer and suspended users are left out.
+	FindPostLikers(tx *gorm.DB, postID, viewerID string, offset, limit int) []*Liker
+	FindCommentLikers(tx *gorm.DB, commentID int64, viewerID string, offset, limit int) []*Liker
+	// CanSeePost is true when the post is up and viewerID may see it, read from the posts table since
+	// the post package depends on this one.
+	CanSeePost(tx *gorm.DB, postID, viewerID string) bool
+	// CommentExists is true for comments on posts viewerID may see, read from the comments and posts
+	// tables since the comment package depends on this one.
+	CommentExists(tx *gorm.DB, commentID int64, viewerID string) bool
+	CreateCommentLike(tx *gorm.DB, like *CommentLike) bool
+	DeleteCommentLike(tx *gorm.DB, commentID int64, userID string) bool
+	// CountByCommentIDs returns like counts of the comments and which of them userID liked.
+	CountByCommentIDs(tx *gorm.DB, commentIDs []int64, userID string) (map[int64]int64, map[int64]bool)
 }
 
 type repositoryImpl struct {
@@ -20,18 +45,20 @@ func NewRepository() Repository {
 	return &repositoryImpl{}
 }
 
-func (*repositoryImpl) Create(tx *gorm.DB, like *Like) {
-	err := tx.Create(&like).Error
-	if err != nil {
-		panic(exception.DatabaseError{Message: err.Error()})
+func (*repositoryImpl) Create(tx *gorm.DB, like *Like) bool {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&like)
+	if result.Error != nil {
+		panic(exception.DatabaseError{Message: result.Error.Error()})
 	}
+	return result.RowsAffected > 0
 }
 
-func (*repositoryImpl) Delete(tx *gorm.DB, likeID int64) {
-	err := tx.Where("like_id = ?", likeID).Delete(&Like{}).Error
-	if err != nil {
-		panic(exception.DatabaseError{Message: err.Error()})
+func (*repositoryImpl) Delete(tx *gorm.DB, likeID int64) bool {
+	result := tx.Where("like_id = ?", likeID).Delete(&Like{})
+	if result.Error != nil {
+		panic(exception.DatabaseError{Message: result.Error.Error()})
 	}
+	return result.RowsAffected > 0
 }
 
 func (*repositoryImpl) CountByPostID(tx *gorm.DB, postID, userID string) (int64, bool) {
@@ -65,3 +92,160 @@ func (*repositoryImpl) FindByPostIDAndUserID(tx *gorm.DB, postID, userID string)
 	}
 	return &like
 }
+
+func (*repositoryImpl) DeleteByPostID(tx *gorm.DB, postID string) {
+	err := tx.Where("post_id = ?", postID).Delete(&Like{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) UpdateReaction(tx *gorm.DB, likeID int64, reaction string) {
+	err := tx.Model(&Like{}).
+		Where("like_id = ?", likeID).
+		Update("reaction", reaction).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) CountReactionsByPostID(tx *gorm.DB, postID string) map[string]int64 {
+	var rows []struct {
+		Reaction string
+		Count    int64
+	}
+	err := tx.Model(&Like{}).
+		Select("reaction, count(*) as count").
+		Where("post_id = ?", postID).
+		Group("reaction").
+		Find(&rows).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Reaction] = row.Count
+	}
+	return counts
+}
+
+func (*repositoryImpl) MigrateReactions(tx *gorm.DB) int64 {
+	result := tx.Model(&Like{}).
+		Where("reaction IS NULL OR reaction = ''").
+		Update("reaction", ReactionLike)
+	if result.Error != nil {
+		panic(exception.DatabaseError{Message: result.Error.Error()})
+	}
+	return result.RowsAffected
+}
+
+func (r *repositoryImpl) FindPostLikers(tx *gorm.DB, postID, viewerID string, offset, limit int) []*Liker {
+	return r.findLikers(tx.Table("likes l").
+		Select("l.user_id, l.reaction, l.created_at AS liked_at, "+likerColumns, viewerID).
+		Where("l.post_id = ?", postID), viewerID, offset, limit)
+}
+
+func (r *repositoryImpl) FindCommentLikers(tx *gorm.DB, commentID int64, viewerID string, offset, limit int) []*Liker {
+	return r.findLikers(tx.Table("comment_likes l").
+		Select("l.user_id, l.created_at AS liked_at, "+likerColumns, viewerID).
+		Where("l.comment_id = ?", commentID), viewerID, offset, limit)
+}
+
+// likerColumns are read from the users joined by findLikers, so listing likers takes one query.
+const likerColumns = "u.username, u.display_name, u.avatar_key, u.is_verified, " +
+	"EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.following_id = l.user_id) AS followed_by_viewer"
+
+func (*repositoryImpl) findLikers(query *gorm.DB, viewerID string, offset, limit int) []*Liker {
+	var likers []*Liker
+	err := query.
+		Joins("JOIN users u ON u.user_id = l.user_id").
+		Where("u.is_suspended = ?", false).
+		Scopes(relation.NotBlocked("l.user_id", viewerID)).
+		Order("followed_by_viewer desc, liked_at desc, l.user_id asc").
+		Offset(offset).
+		Limit(limit).
+		Find(&likers).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return likers
+}
+
+func (*repositoryImpl) CanSeePost(tx *gorm.DB, postID, viewerID string) bool {
+	var count int64
+	err := tx.Table("posts AS p").
+		Scopes(relation.VisiblePosts(viewerID)).
+		Where("p.post_id = ?", postID).
+		Count(&count).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return count > 0
+}
+
+func (*repositoryImpl) CommentExists(tx *gorm.DB, commentID int64, viewerID string) bool {
+	var count int64
+	err := tx.Table("comments c").
+		Joins("JOIN posts p ON p.post_id = c.post_id").
+		Scopes(relation.VisiblePosts(viewerID), relation.NotBlocked("c.user_id", viewerID)).
+		Where("c.comment_id = ?", commentID).
+		Count(&count).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return count > 0
+}
+
+func (*repositoryImpl) CreateCommentLike(tx *gorm.DB, like *CommentLike) bool {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&like)
+	if result.Error != nil {
+		panic(exception.DatabaseError{Message: result.Error.Error()})
+	}
+	return result.RowsAffected > 0
+}
+
+func (*repositoryImpl) DeleteCommentLike(tx *gorm.DB, commentID int64, userID string) bool {
+	result := tx.Where("comment_id = ? AND user_id = ?", commentID, userID).Delete(&CommentLike{})
+	if result.Error != nil {
+		panic(exception.DatabaseError{Message: result.Error.Error()})
+	}
+	return result.RowsAffected > 0
+}
+
+func (*repositoryImpl) CountByCommentIDs(tx *gorm.DB, commentIDs []int64, userID string) (map[int64]int64, map[int64]bool) {
+	counts := map[int64]int64{}
+	liked := map[int64]bool{}
+	if len(commentIDs) == 0 {
+		return counts, liked
+	}
+
+	var rows []struct {
+		CommentID int64
+		Count     int64
+		Liked     bool
+	}
+	err := tx.Model(&CommentLike{}).
+		Select("comment_id, count(*) AS count, MAX(user_id = ?) AS liked", userID).
+		Where("comment_id IN ?", commentIDs).
+		Group("comment_id").
+		Find(&rows).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	for _, row := range rows {
+		counts[row.CommentID] = row.Count
+		liked[row.CommentID] = row.Liked
+	}
+	return counts, liked
+}
+
+func (*repositoryImpl) AddToPostCount(tx *gorm.DB, postID string, delta int) {
+	err := tx.Table("posts").
+		Where("post_id = ?", postID).
+		UpdateColumn("likes_count", gorm.Expr("likes_count + ?", delta)).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
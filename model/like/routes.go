@@ -5,5 +5,10 @@ import "github.com/gin-gonic/gin"
 func InitRoutes(router *gin.RouterGroup, controller Controller) {
 	userGroup := router.Group("/like")
 	userGroup.POST("/:postID", controller.Create)
+	userGroup.PUT("/:postID", controller.Create)
 	userGroup.DELETE("/:postID", controller.Delete)
+	userGroup.GET("/:postID/users", controller.FindPostLikers)
+	userGroup.POST("/comment/:commentID", controller.LikeComment)
+	userGroup.DELETE("/comment/:commentID", controller.UnlikeComment)
+	userGroup.GET("/comment/:commentID/users", controller.FindCommentLikers)
 }
@@ -4,23 +4,37 @@ import (
 	"context"
 	"github.com/go-playground/validator"
 	"go-api/app"
+	"go-api/cache"
 	"go-api/exception"
 	"go-api/helper"
+	"go-api/model/user"
 	"time"
 )
 
 type Service interface {
+	// Create reacts to the post, or changes the reaction the user already left on it.
 	Create(ctx context.Context, req *Request)
 	Delete(ctx context.Context, req *Request)
+	// MigrateReactions is run at startup so likes from before reactions count as ReactionLike.
+	MigrateReactions(ctx context.Context)
+	LikeComment(ctx context.Context, req *CommentRequest)
+	UnlikeComment(ctx context.Context, req *CommentRequest)
+	FindPostLikers(ctx context.Context, postID string, req *LikersRequest) *LikersResponse
+	FindCommentLikers(ctx context.Context, commentID int64, req *LikersRequest) *LikersResponse
 }
 
+const (
+	DefaultLikersLimit = 20
+)
+
 type serviceImpl struct {
 	validate *validator.Validate
 	likeRepo Repository
+	cache    *cache.Loader
 }
 
-func NewService(validate *validator.Validate, likeRepo Repository) Service {
-	return &serviceImpl{validate: validate, likeRepo: likeRepo}
+func NewService(validate *validator.Validate, likeRepo Repository, cache *cache.Loader) Service {
+	return &serviceImpl{validate: validate, likeRepo: likeRepo, cache: cache}
 }
 
 func (s *serviceImpl) Create(ctx context.Context, req *Request) {
@@ -29,19 +43,37 @@ func (s *serviceImpl) Create(ctx context.Context, req *Request) {
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
+	defer s.cache.Invalidate(ctx, cache.PostCountsKey(req.PostID))
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
-	like := s.likeRepo.FindByPostIDAndUserID(tx, req.PostID, req.UserID)
-	if like.ID != 0 {
-		panic(exception.DuplicateError{Message:"can't like post more than once"})
+	// likes of drafts, archived, deleted or unknown posts would only bump a count nobody sees
+	if !s.likeRepo.CanSeePost(tx, req.PostID, req.UserID) {
+		panic(exception.NotFoundError{Message: "post not found"})
+	}
+
+	reaction := req.Reaction
+	if reaction == "" {
+		reaction = ReactionLike
 	}
 
-	s.likeRepo.Create(tx, &Like{
+	// the insert is what decides between concurrent likes, only the one that inserted counts
+	created := s.likeRepo.Create(tx, &Like{
 		PostID:    req.PostID,
 		UserID:    req.UserID,
+		Reaction:  reaction,
 		CreatedAt: time.Now(),
 	})
+	if created {
+		s.likeRepo.AddToPostCount(tx, req.PostID, 1)
+		return
+	}
+
+	like := s.likeRepo.FindByPostIDAndUserID(tx, req.PostID, req.UserID)
+	if like.Reaction == reaction {
+		panic(exception.DuplicateError{Message: "can't like post more than once"})
+	}
+	s.likeRepo.UpdateReaction(tx, like.ID, reaction)
 }
 
 func (s *serviceImpl) Delete(ctx context.Context, req *Request) {
@@ -50,7 +82,8 @@ func (s *serviceImpl) Delete(ctx context.Context, req *Request) {
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
+	defer s.cache.Invalidate(ctx, cache.PostCountsKey(req.PostID))
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
 	like := s.likeRepo.FindByPostIDAndUserID(tx, req.PostID, req.UserID)
@@ -58,5 +91,118 @@ func (s *serviceImpl) Delete(ctx context.Context, req *Request) {
 		panic(exception.NotFoundError{Message: "like not found"})
 	}
 
-	s.likeRepo.Delete(tx, like.ID)
+	if s.likeRepo.Delete(tx, like.ID) {
+		s.likeRepo.AddToPostCount(tx, req.PostID, -1)
+	}
+}
+
+func (s *serviceImpl) MigrateReactions(ctx context.Context) {
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	s.likeRepo.MigrateReactions(tx)
+}
+
+func (s *serviceImpl) LikeComment(ctx context.Context, req *CommentRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	if !s.likeRepo.CommentExists(tx, req.CommentID, req.UserID) {
+		panic(exception.NotFoundError{Message: "comment not found"})
+	}
+
+	created := s.likeRepo.CreateCommentLike(tx, &CommentLike{
+		CommentID: req.CommentID,
+		UserID:    req.UserID,
+		CreatedAt: time.Now(),
+	})
+	if !created {
+		panic(exception.DuplicateError{Message: "can't like comment more than once"})
+	}
+}
+
+func (s *serviceImpl) UnlikeComment(ctx context.Context, req *CommentRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	if !s.likeRepo.DeleteCommentLike(tx, req.CommentID, req.UserID) {
+		panic(exception.NotFoundError{Message: "like not found"})
+	}
+}
+
+func (s *serviceImpl) FindPostLikers(ctx context.Context, postID string, req *LikersRequest) *LikersResponse {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	if !s.likeRepo.CanSeePost(tx, postID, req.ViewerID) {
+		panic(exception.NotFoundError{Message: "post not found"})
+	}
+
+	limit := likersLimit(req.Limit)
+	// one extra row tells whether there's a next page
+	likers := s.likeRepo.FindPostLikers(tx, postID, req.ViewerID, req.Offset, limit+1)
+	return toLikersResponse(likers, req.Offset, limit)
+}
+
+func (s *serviceImpl) FindCommentLikers(ctx context.Context, commentID int64, req *LikersRequest) *LikersResponse {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	if !s.likeRepo.CommentExists(tx, commentID, req.ViewerID) {
+		panic(exception.NotFoundError{Message: "comment not found"})
+	}
+
+	limit := likersLimit(req.Limit)
+	likers := s.likeRepo.FindCommentLikers(tx, commentID, req.ViewerID, req.Offset, limit+1)
+	return toLikersResponse(likers, req.Offset, limit)
+}
+
+func likersLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultLikersLimit
+	}
+	return limit
+}
+
+func toLikersResponse(likers []*Liker, offset, limit int) *LikersResponse {
+	response := &LikersResponse{Users: []*LikerResponse{}}
+	if len(likers) > limit {
+		likers = likers[:limit]
+		response.NextOffset = offset + limit
+	}
+
+	for _, liker := range likers {
+		u := &user.User{ID: liker.UserID, Username: liker.Username, AvatarKey: liker.AvatarKey}
+		response.Users = append(response.Users, &LikerResponse{
+			UserID:            liker.UserID,
+			Username:          liker.Username,
+			DisplayName:       liker.DisplayName,
+			ProfilePictureURL: u.AvatarURLs().Small,
+			IsVerified:        liker.IsVerified,
+			FollowedByViewer:  liker.FollowedByViewer,
+			Reaction:          liker.Reaction,
+			LikedAt:           liker.LikedAt,
+		})
+	}
+	return response
 }
@@ -0,0 +1,82 @@
+package like
+
+import (
+	"context"
+	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"go-api/app"
+	"go-api/cache"
+	"go-api/model/user"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestServiceImpl_Create(t *testing.T) {
+	app.TestDBInit()
+	service := NewService(validator.New(), NewRepository(), cache.NewLoader(cache.NewLRU(100)))
+
+	now := time.Now()
+	ownerID, likerID, postID := uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String()
+	for _, id := range []string{ownerID, likerID} {
+		assert.Nil(t, app.DB.Create(&user.User{ID: id, Email: id + "@example.com", Username: id, DisplayName: id, Role: user.RoleUser, CreatedAt: now, UpdatedAt: now}).Error)
+	}
+	assert.Nil(t, app.DB.Table("posts").Create(map[string]interface{}{
+		"post_id":    postID,
+		"user_id":    ownerID,
+		"status":     "published",
+		"created_at": now,
+		"updated_at": now,
+	}).Error)
+
+	likes := func() (rows int64, counter int64) {
+		assert.Nil(t, app.DB.Model(&Like{}).Where("post_id = ?", postID).Count(&rows).Error)
+		assert.Nil(t, app.DB.Table("posts").Select("likes_count").Where("post_id = ?", postID).Row().Scan(&counter))
+		return rows, counter
+	}
+
+	t.Run("concurrent likes of one user should be stored and counted once", func(t *testing.T) {
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				defer func() { recover() }()
+				service.Create(context.Background(), &Request{PostID: postID, UserID: likerID})
+			}()
+		}
+		wg.Wait()
+
+		rows, counter := likes()
+		assert.Equal(t, int64(1), rows)
+		assert.Equal(t, int64(1), counter)
+	})
+
+	t.Run("liking again with the same reaction should be a duplicate", func(t *testing.T) {
+		assert.Panics(t, func() {
+			service.Create(context.Background(), &Request{PostID: postID, UserID: likerID})
+		})
+	})
+
+	t.Run("another reaction should replace the like without counting it again", func(t *testing.T) {
+		service.Create(context.Background(), &Request{PostID: postID, UserID: likerID, Reaction: ReactionLove})
+
+		like := NewRepository().FindByPostIDAndUserID(app.DB, postID, likerID)
+		assert.Equal(t, ReactionLove, like.Reaction)
+		rows, counter := likes()
+		assert.Equal(t, int64(1), rows)
+		assert.Equal(t, int64(1), counter)
+	})
+
+	t.Run("unliking should take the like out of the count once", func(t *testing.T) {
+		service.Delete(context.Background(), &Request{PostID: postID, UserID: likerID})
+		assert.Panics(t, func() {
+			service.Delete(context.Background(), &Request{PostID: postID, UserID: likerID})
+		})
+
+		rows, counter := likes()
+		assert.Zero(t, rows)
+		assert.Zero(t, counter)
+	})
+}
@@ -1,8 +1,39 @@
 package like
 
+import "time"
+
 type (
 	Request struct {
 		PostID string `validate:"required" json:"post_id"`
 		UserID string `validate:"required" json:"user_id"`
+		// Reaction defaults to ReactionLike.
+		Reaction string `validate:"omitempty,oneof=like love haha wow sad angry" json:"reaction"`
+	}
+
+	CommentRequest struct {
+		CommentID int64  `validate:"required" json:"comment_id"`
+		UserID    string `validate:"required" json:"user_id"`
+	}
+
+	LikersRequest struct {
+		Offset   int    `validate:"min=0" form:"offset" json:"offset"`
+		Limit    int    `validate:"min=0,max=50" form:"limit" json:"limit"`
+		ViewerID string `json:"-"`
+	}
+
+	LikerResponse struct {
+		UserID            string    `json:"user_id"`
+		Username          string    `json:"username"`
+		DisplayName       string    `json:"display_name"`
+		ProfilePictureURL string    `json:"profile_picture_url"`
+		IsVerified        bool      `json:"is_verified"`
+		FollowedByViewer  bool      `json:"followed_by_viewer"`
+		Reaction          string    `json:"reaction,omitempty"`
+		LikedAt           time.Time `json:"liked_at"`
+	}
+
+	LikersResponse struct {
+		Users      []*LikerResponse `json:"users"`
+		NextOffset int              `json:"next_offset,omitempty"`
 	}
 )
@@ -0,0 +1,38 @@
+package location
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+type Location struct {
+	ID        string    `gorm:"column:location_id; primaryKey"`
+	Name      string    `gorm:"column:name; not null"`
+	Latitude  float64   `gorm:"column:latitude; not null"`
+	Longitude float64   `gorm:"column:longitude; not null"`
+	CreatedAt time.Time `gorm:"column:created_at; not null"`
+}
+
+// Normalize trims the name and rounds the coordinates to about 11 meters,
+// so the same place tagged from different phones ends up as one location.
+func Normalize(name string, latitude, longitude float64) (string, float64, float64) {
+	return strings.Join(strings.Fields(name), " "), round(latitude), round(longitude)
+}
+
+func round(coordinate float64) float64 {
+	return math.Round(coordinate*1e4) / 1e4
+}
+
+// ToResponse is nil for a post without location.
+func (l *Location) ToResponse() *Response {
+	if l == nil {
+		return nil
+	}
+	return &Response{
+		LocationID: l.ID,
+		Name:       l.Name,
+		Latitude:   l.Latitude,
+		Longitude:  l.Longitude,
+	}
+}
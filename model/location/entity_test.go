@@ -0,0 +1,18 @@
+package location
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	name, lat, lng := Normalize("  Monas   Jakarta ", -6.175392, 106.827153)
+	assert.Equal(t, "Monas Jakarta", name)
+	assert.Equal(t, -6.1754, lat)
+	assert.Equal(t, 106.8272, lng)
+
+	_, lat2, lng2 := Normalize("Monas Jakarta", -6.17541, 106.82718)
+	assert.Equal(t, lat, lat2)
+	assert.Equal(t, lng, lng2)
+}
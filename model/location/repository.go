@@ -0,0 +1,49 @@
+package location
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(tx *gorm.DB, location *Location)
+	FindByLocationID(tx *gorm.DB, locationID string) *Location
+	// FindByPlace looks a location up by its normalized name and coordinates.
+	FindByPlace(tx *gorm.DB, name string, latitude, longitude float64) *Location
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (*repositoryImpl) Create(tx *gorm.DB, location *Location) {
+	err := tx.Create(&location).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindByLocationID(tx *gorm.DB, locationID string) *Location {
+	var location Location
+	err := tx.Where("location_id = ?", locationID).
+		Limit(1).
+		Find(&location).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return &location
+}
+
+func (*repositoryImpl) FindByPlace(tx *gorm.DB, name string, latitude, longitude float64) *Location {
+	var location Location
+	err := tx.Where("name = ? AND latitude = ? AND longitude = ?", name, latitude, longitude).
+		Limit(1).
+		Find(&location).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return &location
+}
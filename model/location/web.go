@@ -0,0 +1,16 @@
+package location
+
+type (
+	Request struct {
+		Name      string  `validate:"required,max=100" json:"name" form:"location_name"`
+		Latitude  float64 `validate:"min=-90,max=90" json:"latitude" form:"latitude"`
+		Longitude float64 `validate:"min=-180,max=180" json:"longitude" form:"longitude"`
+	}
+
+	Response struct {
+		LocationID string  `json:"location_id"`
+		Name       string  `json:"name"`
+		Latitude   float64 `json:"latitude"`
+		Longitude  float64 `json:"longitude"`
+	}
+)
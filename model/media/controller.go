@@ -0,0 +1,99 @@
+package media
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/app"
+	"go-api/exception"
+	"go-api/helper"
+	"go-api/model"
+	"go-api/model/blob"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Controller interface {
+	Serve(ctx *gin.Context)
+	GC(ctx *gin.Context)
+}
+
+type controllerImpl struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return &controllerImpl{service: service}
+}
+
+// Serve streams a stored file, range requests are answered with partial content so videos can seek.
+// Avatars are public, everything else needs a link signed for the viewer who still has access to it.
+func (c *controllerImpl) Serve(ctx *gin.Context) {
+	name := strings.TrimPrefix(path.Clean(ctx.Param("path")), "/")
+
+	if isPublic(name) {
+		ctx.Header("Cache-Control", "public, max-age=86400")
+	} else {
+		viewerID := ctx.Query("uid")
+		expiresAt, err := helper.VerifyMediaURL(name, viewerID, ctx.Query("exp"), ctx.Query("sig"))
+		if err != nil {
+			panic(exception.NoAccessError{Message: err.Error()})
+		}
+
+		c.service.CheckAccess(ctx, name, viewerID)
+		maxAge := int(time.Until(expiresAt).Seconds())
+		ctx.Header("Cache-Control", "private, max-age="+strconv.Itoa(maxAge))
+	}
+
+	object, err := app.GetStorage().Open(name)
+	if os.IsNotExist(err) {
+		panic(exception.NotFoundError{Message: "media not found"})
+	}
+	if err != nil {
+		panic(err)
+	}
+	defer object.Close()
+
+	// ServeContent answers If-None-Match, If-Modified-Since and Range from these
+	ctx.Header("ETag", etag(name, object))
+	ctx.Header("Accept-Ranges", "bytes")
+	http.ServeContent(ctx.Writer, ctx.Request, path.Base(name), object.ModTime, object)
+}
+
+func (c *controllerImpl) GC(ctx *gin.Context) {
+	var req GCRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		panic(err)
+	}
+
+	res := c.service.GC(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+// publicPrefixes are served without a signed link.
+var publicPrefixes = []string{"avatars/"}
+
+func isPublic(name string) bool {
+	for _, prefix := range publicPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// etag is the content hash for blobs, other files are identified by size and modification time.
+func etag(name string, object *app.Object) string {
+	if strings.HasPrefix(name, blob.Prefix) {
+		hash := strings.TrimSuffix(path.Base(name), path.Ext(name))
+		return `"` + hash + `"`
+	}
+	return `W/"` + strconv.FormatInt(object.Size, 16) + "-" + strconv.FormatInt(object.ModTime.UnixNano(), 16) + `"`
+}
@@ -0,0 +1,122 @@
+package media_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go-api/app"
+	"go-api/exception"
+	"go-api/helper"
+	"go-api/middleware"
+	"go-api/model/media"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serviceStub lets only allowedViewer see non public media.
+type serviceStub struct {
+	allowedViewer string
+}
+
+func (s *serviceStub) CheckAccess(ctx context.Context, path, viewerID string) {
+	if viewerID != s.allowedViewer {
+		panic(exception.NoAccessError{Message: "this account is private"})
+	}
+}
+
+func (s *serviceStub) GC(ctx context.Context, req *media.GCRequest) *media.GCReport {
+	return &media.GCReport{}
+}
+
+func setupControllerTest(t *testing.T) *gin.Engine {
+	app.InitStorage(t.TempDir())
+	helper.SetMediaURLSecret([]byte("test secret"))
+	for _, name := range []string{"avatars/user/avatar.jpg", "posts/video.mp4"} {
+		err := app.GetStorage().Save(name, bytes.NewReader([]byte("0123456789")))
+		assert.NoError(t, err)
+	}
+
+	router := gin.New()
+	router.Use(gin.CustomRecovery(middleware.PanicHandler))
+	media.InitRoutes(&router.RouterGroup, media.NewController(&serviceStub{allowedViewer: "viewer"}))
+	return router
+}
+
+// localPath turns a signed storage URL into a request path.
+func localPath(url string) string {
+	return url[strings.Index(url, "/res/"):]
+}
+
+func serve(router *gin.Engine, target string, header map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestControllerImpl_Serve(t *testing.T) {
+	t.Run("signed link should return partial content for range request", func(t *testing.T) {
+		router := setupControllerTest(t)
+		rec := serve(router, localPath(helper.SignMediaURL("posts/video.mp4", "viewer")), map[string]string{"Range": "bytes=2-5"})
+
+		body, _ := ioutil.ReadAll(rec.Body)
+		assert.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Equal(t, "2345", string(body))
+		assert.Equal(t, "bytes 2-5/10", rec.Header().Get("Content-Range"))
+		assert.NotEmpty(t, rec.Header().Get("ETag"))
+		assert.NotEmpty(t, rec.Header().Get("Last-Modified"))
+	})
+
+	t.Run("matching etag should return not modified", func(t *testing.T) {
+		router := setupControllerTest(t)
+		target := localPath(helper.SignMediaURL("posts/video.mp4", "viewer"))
+		etag := serve(router, target, nil).Header().Get("ETag")
+
+		rec := serve(router, target, map[string]string{"If-None-Match": etag})
+		assert.Equal(t, http.StatusNotModified, rec.Code)
+	})
+
+	t.Run("unsigned link should be forbidden", func(t *testing.T) {
+		router := setupControllerTest(t)
+		rec := serve(router, "/res/posts/video.mp4", nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("link signed for another path should be forbidden", func(t *testing.T) {
+		router := setupControllerTest(t)
+		signed := localPath(helper.SignMediaURL("posts/other.mp4", "viewer"))
+		rec := serve(router, strings.Replace(signed, "other", "video", 1), nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("viewer without access should be forbidden", func(t *testing.T) {
+		router := setupControllerTest(t)
+		rec := serve(router, localPath(helper.SignMediaURL("posts/video.mp4", "stranger")), nil)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	t.Run("avatar should be served without signature", func(t *testing.T) {
+		router := setupControllerTest(t)
+		rec := serve(router, "/res/avatars/user/avatar.jpg", nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+
+	t.Run("missing avatar should return not found", func(t *testing.T) {
+		router := setupControllerTest(t)
+		rec := serve(router, "/res/avatars/user/missing.jpg", nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("path outside storage should not be served", func(t *testing.T) {
+		router := setupControllerTest(t)
+		rec := serve(router, "/res/../../etc/passwd", nil)
+		assert.NotEqual(t, http.StatusOK, rec.Code)
+	})
+}
@@ -0,0 +1,13 @@
+package media
+
+import "github.com/gin-gonic/gin"
+
+func InitRoutes(router *gin.RouterGroup, controller Controller) {
+	router.GET("/res/*path", controller.Serve)
+}
+
+// InitAdminRoutes takes the permission guard from the caller like the audit routes do.
+func InitAdminRoutes(router *gin.RouterGroup, controller Controller, guard gin.HandlerFunc) {
+	mediaGroup := router.Group("/admin/media", guard)
+	mediaGroup.POST("/gc", controller.GC)
+}
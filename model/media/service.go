@@ -0,0 +1,296 @@
+package media
+
+import (
+	"context"
+	"go-api/app"
+	"go-api/exception"
+	"go-api/helper"
+	"go-api/model/blob"
+	"go-api/model/post"
+	"go-api/model/relation"
+	"go-api/model/resource"
+	"go-api/model/story"
+	"go-api/model/user"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+type Service interface {
+	// CheckAccess panics unless the viewer may see the stored file, the owner of a private account
+	// has to be followed and files of deleted posts or expired stories aren't served at all.
+	CheckAccess(ctx context.Context, path, viewerID string)
+	// GC removes resources of deleted posts, blobs nobody references and stored files without a row.
+	GC(ctx context.Context, req *GCRequest) *GCReport
+}
+
+const (
+	GCInterval = 24 * time.Hour
+	// gcGrace leaves recent files and blobs alone, a post may be about to reference them.
+	gcGrace = time.Hour
+	gcBatch = 500
+)
+
+// gcPrefixes are the storage directories holding post media, other features clean their own files.
+var gcPrefixes = []string{blob.Prefix, "posts/"}
+
+type serviceImpl struct {
+	resourceRepository resource.Repository
+	blobRepository     blob.Repository
+	postRepository     post.Repository
+	storyRepository    story.Repository
+	userRepository     user.Repository
+	relationRepository relation.Repository
+	now                func() time.Time
+}
+
+func NewService(resourceRepository resource.Repository, blobRepository blob.Repository, postRepository post.Repository, storyRepository story.Repository, userRepository user.Repository, relationRepository relation.Repository) Service {
+	return &serviceImpl{
+		resourceRepository: resourceRepository,
+		blobRepository:     blobRepository,
+		postRepository:     postRepository,
+		storyRepository:    storyRepository,
+		userRepository:     userRepository,
+		relationRepository: relationRepository,
+		now:                time.Now,
+	}
+}
+
+func (s *serviceImpl) CheckAccess(ctx context.Context, path, viewerID string) {
+	// a replica behind a block or a deletion would still hand the media out
+	tx := app.ReadPrimaryTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	// a blob may be shared by posts and stories alike
+	var owners []string
+	for _, st := range s.storyRepository.FindByPath(tx, path) {
+		// expired stories stay visible to their owner only, archiving keeps a story past its expiry
+		// for its owner but doesn't hide it before, like the tray and the story listings
+		if !st.IsExpired(s.now()) || st.UserID == viewerID {
+			owners = append(owners, st.UserID)
+		}
+	}
+	if !strings.HasPrefix(path, story.Prefix) {
+		for _, r := range s.resourceRepository.FindByPath(tx, path) {
+			// media of drafts, archived posts and posts in recently deleted stays visible to their owner only
+			p := s.postRepository.FindWithDeleted(tx, r.PostID)
+			if p.ID != "" && (p.IsPublic() || p.UserID == viewerID) {
+				owners = append(owners, p.UserID)
+			}
+		}
+	}
+
+	if len(owners) == 0 {
+		panic(exception.NotFoundError{Message: "media not found"})
+	}
+
+	// a blob shared by several posts or stories is visible when any of them is
+	private := false
+	for _, ownerID := range owners {
+		visible, isPrivate := s.canView(tx, ownerID, viewerID)
+		if visible {
+			return
+		}
+		private = private || isPrivate
+	}
+
+	if private {
+		panic(exception.NoAccessError{Message: "this account is private"})
+	}
+	panic(exception.NotFoundError{Message: "media not found"})
+}
+
+func (s *serviceImpl) canView(tx *gorm.DB, ownerID, viewerID string) (visible bool, private bool) {
+	if ownerID == viewerID {
+		return true, false
+	}
+
+	owner := s.userRepository.FindById(tx, ownerID)
+	if owner.ID == "" || owner.IsSuspended || s.relationRepository.IsBlocked(tx, ownerID, viewerID) {
+		return false, false
+	}
+	if owner.IsPrivate && !s.relationRepository.IsFollowing(tx, viewerID, ownerID) {
+		return false, true
+	}
+	return true, false
+}
+
+func (s *serviceImpl) GC(ctx context.Context, req *GCRequest) *GCReport {
+	report := &GCReport{
+		DryRun:            req.DryRun,
+		OrphanResources:   []string{},
+		UnreferencedBlobs: []string{},
+		OrphanFiles:       []string{},
+		AdoptedBlobs:      []string{},
+	}
+	before := s.now().Add(-gcGrace)
+
+	s.collectResources(ctx, report)
+	s.collectBlobs(ctx, before, report)
+	s.collectFiles(ctx, before, report)
+	return report
+}
+
+// collectResources deletes rows of resources whose post is gone and releases their blobs.
+func (s *serviceImpl) collectResources(ctx context.Context, report *GCReport) {
+	for {
+		orphans := func() []*resource.Resource {
+			tx := app.WriteTx(ctx)
+			defer helper.TXCommitOrRollback(tx)
+
+			orphans := s.resourceRepository.FindOrphans(tx, gcBatch)
+			if report.DryRun {
+				return orphans
+			}
+
+			for _, r := range orphans {
+				s.resourceRepository.Delete(tx, r)
+				for hash := range r.Hashes() {
+					s.blobRepository.Release(tx, hash)
+				}
+			}
+			return orphans
+		}()
+
+		for _, r := range orphans {
+			report.OrphanResources = append(report.OrphanResources, r.ID)
+		}
+
+		// a dry run deletes nothing so the next batch would be the same one
+		if report.DryRun || len(orphans) < gcBatch {
+			return
+		}
+	}
+}
+
+func (s *serviceImpl) collectBlobs(ctx context.Context, before time.Time, report *GCReport) {
+	for {
+		blobs := func() []*blob.Blob {
+			tx := app.WriteTx(ctx)
+			defer helper.TXCommitOrRollback(tx)
+
+			unreferenced := s.blobRepository.FindUnreferenced(tx, before, gcBatch)
+			if report.DryRun {
+				for _, b := range unreferenced {
+					report.FreedBytes += s.remove(b.Path, true)
+				}
+				return unreferenced
+			}
+
+			// a reference acquired or a Put since the blob was read keeps it. The file goes while the
+			// deleted row is still locked, so a Put of the same content waits and then writes it again.
+			var deleted []*blob.Blob
+			for _, b := range unreferenced {
+				if s.blobRepository.DeleteUnreferenced(tx, b.Hash, before) {
+					report.FreedBytes += s.remove(b.Path, false)
+					deleted = append(deleted, b)
+				}
+			}
+			return deleted
+		}()
+
+		for _, b := range blobs {
+			report.UnreferencedBlobs = append(report.UnreferencedBlobs, b.Hash)
+		}
+
+		if report.DryRun || len(blobs) < gcBatch {
+			return
+		}
+	}
+}
+
+func (s *serviceImpl) collectFiles(ctx context.Context, before time.Time, report *GCReport) {
+	var candidates []app.ObjectInfo
+	for _, prefix := range gcPrefixes {
+		objects, err := app.GetStorage().List(prefix)
+		if err != nil {
+			panic(err)
+		}
+
+		for _, object := range objects {
+			if object.ModTime.Before(before) {
+				candidates = append(candidates, object)
+			}
+		}
+	}
+
+	for start := 0; start < len(candidates); start += gcBatch {
+		end := start + gcBatch
+		if end > len(candidates) {
+			end = len(candidates)
+		}
+		batch := candidates[start:end]
+
+		referenced := s.findReferenced(ctx, batch)
+		var unregistered []app.ObjectInfo
+		for _, object := range batch {
+			if referenced[object.Path] {
+				continue
+			}
+
+			// blob files are only ever deleted by collectBlobs, under the lock a Put waits on
+			if strings.HasPrefix(object.Path, blob.Prefix) {
+				unregistered = append(unregistered, object)
+				continue
+			}
+
+			report.OrphanFiles = append(report.OrphanFiles, object.Path)
+			report.FreedBytes += object.Size
+			if !report.DryRun {
+				_ = app.GetStorage().Delete(object.Path)
+			}
+		}
+		s.adoptBlobs(ctx, unregistered, report)
+	}
+}
+
+// adoptBlobs registers blob files nothing registered, so the next run collects them as unreferenced blobs.
+func (s *serviceImpl) adoptBlobs(ctx context.Context, objects []app.ObjectInfo, report *GCReport) {
+	for _, object := range objects {
+		report.AdoptedBlobs = append(report.AdoptedBlobs, object.Path)
+	}
+	if report.DryRun || len(objects) == 0 {
+		return
+	}
+
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	for _, object := range objects {
+		s.blobRepository.Adopt(tx, blob.HashOf(object.Path), object.Path, object.ModTime)
+	}
+}
+
+func (s *serviceImpl) findReferenced(ctx context.Context, objects []app.ObjectInfo) map[string]bool {
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	paths := make([]string, 0, len(objects))
+	for _, object := range objects {
+		paths = append(paths, object.Path)
+	}
+
+	referenced := map[string]bool{}
+	for _, path := range s.resourceRepository.FindReferencedPaths(tx, paths) {
+		referenced[path] = true
+	}
+	for _, path := range s.blobRepository.FindExistingPaths(tx, paths) {
+		referenced[path] = true
+	}
+	return referenced
+}
+
+// remove deletes the file unless it's a dry run and returns its size.
+func (s *serviceImpl) remove(path string, dryRun bool) int64 {
+	object, err := app.GetStorage().Open(path)
+	if err != nil {
+		return 0
+	}
+	size := object.Size
+	object.Close()
+
+	if !dryRun {
+		_ = app.GetStorage().Delete(path)
+	}
+	return size
+}
@@ -0,0 +1,47 @@
+package media_test
+
+import (
+	"context"
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"go-api/app"
+	"go-api/model/blob"
+	"go-api/model/media"
+	"go-api/model/post"
+	"go-api/model/relation"
+	"go-api/model/resource"
+	"go-api/model/story"
+	"go-api/model/user"
+	"testing"
+	"time"
+)
+
+func TestServiceImpl_CheckAccessStory(t *testing.T) {
+	app.TestDBInit()
+	service := media.NewService(resource.NewRepository(), blob.NewRepository(), post.NewRepository(), story.NewRepository(), user.NewRepository(), relation.NewRepository())
+	now := time.Now()
+
+	ownerID := uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&user.User{ID: ownerID, Email: ownerID + "@example.com", Username: ownerID, DisplayName: ownerID, Role: user.RoleUser, CreatedAt: now, UpdatedAt: now}).Error)
+
+	t.Run("archived story should stay visible until it expires", func(t *testing.T) {
+		path := story.Prefix + ownerID + "/" + uuid.NewV4().String() + ".jpg"
+		assert.Nil(t, app.DB.Create(&story.Story{ID: uuid.NewV4().String(), UserID: ownerID, Path: path, ShareURL: path, IsArchived: true, CreatedAt: now, ExpiresAt: now.Add(time.Hour)}).Error)
+
+		assert.NotPanics(t, func() {
+			service.CheckAccess(context.Background(), path, uuid.NewV4().String())
+		})
+	})
+
+	t.Run("expired archived story should be visible to its owner only", func(t *testing.T) {
+		path := story.Prefix + ownerID + "/" + uuid.NewV4().String() + ".jpg"
+		assert.Nil(t, app.DB.Create(&story.Story{ID: uuid.NewV4().String(), UserID: ownerID, Path: path, ShareURL: path, IsArchived: true, CreatedAt: now, ExpiresAt: now.Add(-time.Hour)}).Error)
+
+		assert.NotPanics(t, func() {
+			service.CheckAccess(context.Background(), path, ownerID)
+		})
+		assert.Panics(t, func() {
+			service.CheckAccess(context.Background(), path, uuid.NewV4().String())
+		})
+	})
+}
@@ -0,0 +1,18 @@
+package media
+
+type (
+	GCRequest struct {
+		DryRun bool `form:"dry_run" json:"dry_run"`
+	}
+
+	// GCReport lists what was collected, or what would be on a dry run. Adopted blobs are files nothing
+	// registered, the next run collects them with the other unreferenced blobs.
+	GCReport struct {
+		DryRun            bool     `json:"dry_run"`
+		OrphanResources   []string `json:"orphan_resources"`
+		UnreferencedBlobs []string `json:"unreferenced_blobs"`
+		OrphanFiles       []string `json:"orphan_files"`
+		AdoptedBlobs      []string `json:"adopted_blobs"`
+		FreedBytes        int64    `json:"freed_bytes"`
+	}
+)
@@ -0,0 +1,37 @@
+package notification
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/model"
+	"net/http"
+)
+
+type Controller interface {
+	FindByUserID(ctx *gin.Context)
+	MarkRead(ctx *gin.Context)
+}
+
+type controllerImpl struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return &controllerImpl{service: service}
+}
+
+func (c *controllerImpl) FindByUserID(ctx *gin.Context) {
+	res := c.service.FindByUserID(ctx, ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) MarkRead(ctx *gin.Context) {
+	c.service.MarkRead(ctx, ctx.Param("notificationID"), ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
@@ -0,0 +1,29 @@
+package notification
+
+import "time"
+
+const (
+	// TypePostPublishFailed tells the owner a scheduled post couldn't be published, TargetID is the post.
+	TypePostPublishFailed = "post.publish_failed"
+)
+
+type Notification struct {
+	ID        string    `gorm:"column:notification_id; primaryKey"`
+	UserID    string    `gorm:"column:user_id; not null; index"`
+	Type      string    `gorm:"column:type; not null"`
+	TargetID  string    `gorm:"column:target_id"`
+	Message   string    `gorm:"column:message"`
+	IsRead    bool      `gorm:"column:is_read"`
+	CreatedAt time.Time `gorm:"column:created_at; not null"`
+}
+
+func (n *Notification) ToResponse() *Response {
+	return &Response{
+		NotificationID: n.ID,
+		Type:           n.Type,
+		TargetID:       n.TargetID,
+		Message:        n.Message,
+		IsRead:         n.IsRead,
+		CreatedAt:      n.CreatedAt,
+	}
+}
@@ -0,0 +1,61 @@
+package notification
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	Create(tx *gorm.DB, notification *Notification)
+	// FindByUserID returns the newest notifications of the user.
+	FindByUserID(tx *gorm.DB, userID string, limit int) []*Notification
+	// MarkRead returns false when the user has no such notification.
+	MarkRead(tx *gorm.DB, notificationID, userID string) bool
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (*repositoryImpl) Create(tx *gorm.DB, notification *Notification) {
+	err := tx.Create(&notification).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindByUserID(tx *gorm.DB, userID string, limit int) []*Notification {
+	var notifications []*Notification
+	err := tx.Where("user_id = ?", userID).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&notifications).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return notifications
+}
+
+func (*repositoryImpl) MarkRead(tx *gorm.DB, notificationID, userID string) bool {
+	var count int64
+	err := tx.Model(&Notification{}).
+		Where("notification_id = ? AND user_id = ?", notificationID, userID).
+		Count(&count).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	if count == 0 {
+		return false
+	}
+
+	err = tx.Model(&Notification{}).
+		Where("notification_id = ?", notificationID).
+		Update("is_read", true).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return true
+}
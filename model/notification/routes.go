@@ -0,0 +1,9 @@
+package notification
+
+import "github.com/gin-gonic/gin"
+
+func InitRoutes(router *gin.RouterGroup, controller Controller) {
+	notificationGroup := router.Group("/notification")
+	notificationGroup.GET("/", controller.FindByUserID)
+	notificationGroup.PUT("/:notificationID/read", controller.MarkRead)
+}
@@ -0,0 +1,44 @@
+package notification
+
+import (
+	"context"
+	"go-api/app"
+	"go-api/exception"
+	"go-api/helper"
+)
+
+type Service interface {
+	FindByUserID(ctx context.Context, userID string) []*Response
+	MarkRead(ctx context.Context, notificationID, userID string)
+}
+
+const listLimit = 50
+
+type serviceImpl struct {
+	notificationRepository Repository
+}
+
+func NewService(notificationRepository Repository) Service {
+	return &serviceImpl{notificationRepository: notificationRepository}
+}
+
+func (s *serviceImpl) FindByUserID(ctx context.Context, userID string) []*Response {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	notifications := s.notificationRepository.FindByUserID(tx, userID, listLimit)
+	response := make([]*Response, 0, len(notifications))
+	for _, n := range notifications {
+		response = append(response, n.ToResponse())
+	}
+	return response
+}
+
+func (s *serviceImpl) MarkRead(ctx context.Context, notificationID, userID string) {
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	if !s.notificationRepository.MarkRead(tx, notificationID, userID) {
+		panic(exception.NotFoundError{Message: "notification not found"})
+	}
+}
@@ -0,0 +1,14 @@
+package notification
+
+import "time"
+
+type (
+	Response struct {
+		NotificationID string    `json:"notification_id"`
+		Type           string    `json:"type"`
+		TargetID       string    `json:"target_id,omitempty"`
+		Message        string    `json:"message"`
+		IsRead         bool      `json:"is_read"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
+)
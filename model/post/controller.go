@@ -4,8 +4,12 @@ import (
 	"context"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"go-api/app"
 	"go-api/model"
+	"go-api/model/audit"
+	"go-api/model/location"
 	"go-api/model/resource"
+	"mime/multipart"
 	"net/http"
 )
 
@@ -13,8 +17,29 @@ type Controller interface {
 	Create(ctx *gin.Context)
 	Update(ctx *gin.Context)
 	Delete(ctx *gin.Context)
+	Restore(ctx *gin.Context)
+	Schedule(ctx *gin.Context)
+	Archive(ctx *gin.Context)
+	Unarchive(ctx *gin.Context)
+	FindArchived(ctx *gin.Context)
+	Pin(ctx *gin.Context)
+	Unpin(ctx *gin.Context)
+	UpdateSettings(ctx *gin.Context)
+	Publish(ctx *gin.Context)
+	FindDrafts(ctx *gin.Context)
+	FindDeleted(ctx *gin.Context)
+	FindRevisions(ctx *gin.Context)
+	ReorderResources(ctx *gin.Context)
+	AppendResources(ctx *gin.Context)
+	RemoveResource(ctx *gin.Context)
+	UpdateResource(ctx *gin.Context)
+	RemoveTag(ctx *gin.Context)
+	SetLocation(ctx *gin.Context)
+	RemoveLocation(ctx *gin.Context)
 	FindByUserID(ctx *gin.Context)
 	FindByPostID(ctx *gin.Context)
+	FindTagged(ctx *gin.Context)
+	FindByLocationID(ctx *gin.Context)
 }
 
 type controllerImpl struct {
@@ -37,28 +62,21 @@ func (c *controllerImpl) Create(ctx *gin.Context) {
 		panic(err)
 	}
 
-	files := form.File["images[]"]
-	if len(files) == 0 {
-		panic("can't post with empty image")
+	// images[] is kept for older clients, media[] takes images and videos
+	files := append(form.File["images[]"], form.File["media[]"]...)
+	if len(files) == 0 && len(req.UploadIDs) == 0 {
+		panic("can't post with empty media")
 	}
 
 	for i, file := range files {
-		filePath := "../../res/posts/" + file.Filename
-		err = ctx.SaveUploadedFile(file, filePath)
-		if err != nil {
-			panic(err)
-		}
-
-		req.Resources = append(req.Resources, resource.Resource{
-			Path:        filePath,
-			ShareURL:    "localhost:3000/" + filePath,
-			IndexInPost: i,
-		})
+		r := saveUploadedMedia(ctx, file)
+		r.IndexInPost = i
+		req.Resources = append(req.Resources, *r)
 	}
 
 	req.UserID = ctx.GetHeader("User_id")
-	res := c.service.Create(context.Background(), req)
-	ctx.IndentedJSON(http.StatusCreated, &model.WebResponse{
+	res := c.service.Create(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusCreated,
 		Status: "ok",
 		Data:   res,
@@ -72,33 +90,287 @@ func (c *controllerImpl) Update(ctx *gin.Context) {
 		panic(err)
 	}
 
+	req.PostID = ctx.Param("postID")
 	req.UserID = ctx.GetHeader("User_id")
-	c.service.Update(context.Background(), req)
-	ctx.IndentedJSON(http.StatusCreated, &model.WebResponse{
+	c.service.Update(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusCreated,
 		Status: "ok",
 	})
 }
 
 func (c *controllerImpl) Delete(ctx *gin.Context) {
-	var req *DeleteRequest
+	req := &DeleteRequest{
+		Actor:  audit.ActorFrom(ctx),
+		PostID: ctx.Param("postID"),
+		UserID: ctx.GetHeader("User_id"),
+	}
+	c.service.Delete(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusCreated,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) Restore(ctx *gin.Context) {
+	req := &RestoreRequest{
+		Actor:  audit.ActorFrom(ctx),
+		PostID: ctx.Param("postID"),
+		UserID: ctx.GetHeader("User_id"),
+	}
+	c.service.Restore(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) Schedule(ctx *gin.Context) {
+	var req *ScheduleRequest
 	err := ctx.ShouldBindWith(&req, binding.JSON)
 	if err != nil {
 		panic(err)
 	}
 
+	req.PostID = ctx.Param("postID")
 	req.UserID = ctx.GetHeader("User_id")
-	c.service.Delete(context.Background(), req)
-	ctx.IndentedJSON(http.StatusCreated, &model.WebResponse{
+	c.service.Schedule(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) Archive(ctx *gin.Context) {
+	c.setArchived(ctx, true)
+}
+
+func (c *controllerImpl) Unarchive(ctx *gin.Context) {
+	c.setArchived(ctx, false)
+}
+
+func (c *controllerImpl) setArchived(ctx *gin.Context, archived bool) {
+	req := &ArchiveRequest{
+		PostID:   ctx.Param("postID"),
+		UserID:   ctx.GetHeader("User_id"),
+		Archived: archived,
+	}
+	c.service.Archive(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) FindArchived(ctx *gin.Context) {
+	res := c.service.FindArchived(ctx, ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) Pin(ctx *gin.Context) {
+	c.setPinned(ctx, true)
+}
+
+func (c *controllerImpl) Unpin(ctx *gin.Context) {
+	c.setPinned(ctx, false)
+}
+
+func (c *controllerImpl) setPinned(ctx *gin.Context, pinned bool) {
+	req := &PinRequest{
+		PostID: ctx.Param("postID"),
+		UserID: ctx.GetHeader("User_id"),
+		Pinned: pinned,
+	}
+	c.service.Pin(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) UpdateSettings(ctx *gin.Context) {
+	var req *SettingsRequest
+	err := ctx.ShouldBindWith(&req, binding.JSON)
+	if err != nil {
+		panic(err)
+	}
+
+	req.PostID = ctx.Param("postID")
+	req.UserID = ctx.GetHeader("User_id")
+	res := c.service.UpdateSettings(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) Publish(ctx *gin.Context) {
+	req := &PublishRequest{
+		PostID: ctx.Param("postID"),
+		UserID: ctx.GetHeader("User_id"),
+	}
+	c.service.Publish(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) FindDrafts(ctx *gin.Context) {
+	res := c.service.FindDrafts(ctx, ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) FindDeleted(ctx *gin.Context) {
+	res := c.service.FindDeleted(ctx, ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) FindRevisions(ctx *gin.Context) {
+	res := c.service.FindRevisions(ctx, ctx.Param("postID"), ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) ReorderResources(ctx *gin.Context) {
+	var req *ReorderRequest
+	err := ctx.ShouldBindWith(&req, binding.JSON)
+	if err != nil {
+		panic(err)
+	}
+
+	req.PostID = ctx.Param("postID")
+	req.UserID = ctx.GetHeader("User_id")
+	res := c.service.Reorder(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) AppendResources(ctx *gin.Context) {
+	var req *AppendResourcesRequest
+	err := ctx.ShouldBindWith(&req, binding.Form)
+	if err != nil {
+		panic(err)
+	}
+
+	form, err := ctx.MultipartForm()
+	if err != nil {
+		panic(err)
+	}
+
+	for _, file := range form.File["media[]"] {
+		req.Resources = append(req.Resources, *saveUploadedMedia(ctx, file))
+	}
+
+	req.PostID = ctx.Param("postID")
+	req.UserID = ctx.GetHeader("User_id")
+	res := c.service.AppendResources(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusCreated,
 		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) RemoveResource(ctx *gin.Context) {
+	req := &RemoveResourceRequest{
+		PostID:     ctx.Param("postID"),
+		ResourceID: ctx.Param("resourceID"),
+		UserID:     ctx.GetHeader("User_id"),
+	}
+	res := c.service.RemoveResource(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) UpdateResource(ctx *gin.Context) {
+	var req *UpdateResourceRequest
+	err := ctx.ShouldBindWith(&req, binding.JSON)
+	if err != nil {
+		panic(err)
+	}
+
+	req.PostID = ctx.Param("postID")
+	req.ResourceID = ctx.Param("resourceID")
+	req.UserID = ctx.GetHeader("User_id")
+	res := c.service.UpdateResource(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) RemoveTag(ctx *gin.Context) {
+	req := &RemoveTagRequest{
+		PostID: ctx.Param("postID"),
+		UserID: ctx.GetHeader("User_id"),
+	}
+	c.service.RemoveTag(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) SetLocation(ctx *gin.Context) {
+	var loc *location.Request
+	err := ctx.ShouldBindWith(&loc, binding.JSON)
+	if err != nil {
+		panic(err)
+	}
+
+	req := &SetLocationRequest{
+		PostID:   ctx.Param("postID"),
+		UserID:   ctx.GetHeader("User_id"),
+		Location: loc,
+	}
+	res := c.service.SetLocation(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) RemoveLocation(ctx *gin.Context) {
+	req := &SetLocationRequest{
+		PostID: ctx.Param("postID"),
+		UserID: ctx.GetHeader("User_id"),
+	}
+	c.service.SetLocation(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
 	})
 }
 
 func (c *controllerImpl) FindByUserID(ctx *gin.Context) {
 	userID := ctx.Query("user_id")
-	res := c.service.FindByUserID(context.Background(), userID)
-	ctx.IndentedJSON(http.StatusCreated, &model.WebResponse{
+	res := c.service.FindByUserID(ctx, userID, ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusCreated,
 		Status: "ok",
 		Data:   res,
@@ -108,10 +380,47 @@ func (c *controllerImpl) FindByUserID(ctx *gin.Context) {
 func (c *controllerImpl) FindByPostID(ctx *gin.Context) {
 	postID := ctx.Param("postID")
 	viewerID := ctx.GetHeader("User_id")
-	res := c.service.FindByPostID(context.Background(), postID, viewerID)
-	ctx.IndentedJSON(http.StatusCreated, &model.WebResponse{
+	res := c.service.FindByPostID(ctx, postID, viewerID)
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusCreated,
 		Status: "ok",
 		Data:   res,
 	})
 }
+
+func (c *controllerImpl) FindTagged(ctx *gin.Context) {
+	userID := ctx.Query("user_id")
+	res := c.service.FindTagged(ctx, userID, ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) FindByLocationID(ctx *gin.Context) {
+	var req FindByLocationRequest
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		panic(err)
+	}
+
+	req.LocationID = ctx.Param("locationID")
+	req.ViewerID = ctx.GetHeader("User_id")
+	res := c.service.FindByLocationID(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func saveUploadedMedia(ctx context.Context, file *multipart.FileHeader) *resource.Resource {
+	src, err := file.Open()
+	if err != nil {
+		panic(err)
+	}
+	defer src.Close()
+
+	return resource.Store(app.GetDB().WithContext(ctx), src, file.Size)
+}
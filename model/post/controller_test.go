@@ -2,28 +2,44 @@ package post_test
 
 import (
 	"bytes"
+	"encoding/json"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
 	"github.com/stretchr/testify/assert"
 	"go-api/app"
+	"go-api/cache"
 	"go-api/middleware"
+	"go-api/model/audit"
+	"go-api/model/blob"
 	"go-api/model/comment"
 	"go-api/model/like"
+	"go-api/model/location"
+	"go-api/model/notification"
 	"go-api/model/post"
+	"go-api/model/relation"
 	"go-api/model/resource"
+	"go-api/model/search"
+	"go-api/model/session"
+	"go-api/model/tag"
+	"go-api/model/token"
+	"go-api/model/upload"
+	"go-api/model/user"
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"testing"
+	"time"
 )
 
 func TestUpload(t *testing.T) {
 	app.TestDBInit()
+	app.InitStorage("../../res")
 	router := gin.Default()
 	router.MaxMultipartMemory = 8 << 20
-	router.Use(middleware.JWTValidator())
+	router.Use(middleware.JWTValidator(session.NewService(validator.New(), session.NewRepository()), token.NewService(validator.New(), token.NewRepository())))
 	router.Use(gin.CustomRecovery(middleware.PanicHandler))
 
 	postRepo := post.NewRepository()
@@ -31,7 +47,7 @@ func TestUpload(t *testing.T) {
 	likeRepo := like.NewRepository()
 	commentRepo := comment.NewRepository()
 
-	postService := post.NewService(validator.New(), postRepo, resourceRepo, likeRepo, commentRepo)
+	postService := post.NewService(validator.New(), postRepo, resourceRepo, likeRepo, commentRepo, audit.NewRepository(), search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer()), upload.NewService(validator.New(), upload.NewRepository()), blob.NewRepository(), tag.NewRepository(), location.NewRepository(), user.NewRepository(), relation.NewRepository(), notification.NewRepository(), cache.NewLoader(cache.NewLRU(100)))
 	postController := post.NewController(postService)
 
 	router.POST("/post", postController.Create)
@@ -78,7 +94,7 @@ func TestGetByUserID(t *testing.T) {
 	app.TestDBInit()
 	router := gin.Default()
 	router.MaxMultipartMemory = 8 << 20
-	router.Use(middleware.JWTValidator())
+	router.Use(middleware.JWTValidator(session.NewService(validator.New(), session.NewRepository()), token.NewService(validator.New(), token.NewRepository())))
 	router.Use(gin.CustomRecovery(middleware.PanicHandler))
 
 	postRepo := post.NewRepository()
@@ -86,7 +102,7 @@ func TestGetByUserID(t *testing.T) {
 	likeRepo := like.NewRepository()
 	commentRepo := comment.NewRepository()
 
-	postService := post.NewService(validator.New(), postRepo, resourceRepo, likeRepo, commentRepo)
+	postService := post.NewService(validator.New(), postRepo, resourceRepo, likeRepo, commentRepo, audit.NewRepository(), search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer()), upload.NewService(validator.New(), upload.NewRepository()), blob.NewRepository(), tag.NewRepository(), location.NewRepository(), user.NewRepository(), relation.NewRepository(), notification.NewRepository(), cache.NewLoader(cache.NewLRU(100)))
 	postController := post.NewController(postService)
 
 	router.GET("/post", postController.FindByUserID)
@@ -111,7 +127,7 @@ func TestGetByPostID(t *testing.T) {
 	app.TestDBInit()
 	router := gin.Default()
 	router.MaxMultipartMemory = 8 << 20
-	router.Use(middleware.JWTValidator())
+	router.Use(middleware.JWTValidator(session.NewService(validator.New(), session.NewRepository()), token.NewService(validator.New(), token.NewRepository())))
 	router.Use(gin.CustomRecovery(middleware.PanicHandler))
 
 	postRepo := post.NewRepository()
@@ -119,7 +135,7 @@ func TestGetByPostID(t *testing.T) {
 	likeRepo := like.NewRepository()
 	commentRepo := comment.NewRepository()
 
-	postService := post.NewService(validator.New(), postRepo, resourceRepo, likeRepo, commentRepo)
+	postService := post.NewService(validator.New(), postRepo, resourceRepo, likeRepo, commentRepo, audit.NewRepository(), search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer()), upload.NewService(validator.New(), upload.NewRepository()), blob.NewRepository(), tag.NewRepository(), location.NewRepository(), user.NewRepository(), relation.NewRepository(), notification.NewRepository(), cache.NewLoader(cache.NewLRU(100)))
 	postController := post.NewController(postService)
 
 	router.GET("/post/:postID", postController.FindByPostID)
@@ -139,3 +155,238 @@ func TestGetByPostID(t *testing.T) {
 	resBody, _ := ioutil.ReadAll(res.Body)
 	t.Log(string(resBody))
 }
+
+func TestReorderResources(t *testing.T) {
+	app.TestDBInit()
+	router := gin.New()
+	router.Use(gin.CustomRecovery(middleware.PanicHandler))
+
+	postService := post.NewService(validator.New(), post.NewRepository(), resource.NewRepository(), like.NewRepository(), comment.NewRepository(), audit.NewRepository(), search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer()), upload.NewService(validator.New(), upload.NewRepository()), blob.NewRepository(), tag.NewRepository(), location.NewRepository(), user.NewRepository(), relation.NewRepository(), notification.NewRepository(), cache.NewLoader(cache.NewLRU(100)))
+	postController := post.NewController(postService)
+
+	router.PUT("/post/:postID/resources", postController.ReorderResources)
+
+	now := time.Now()
+	ownerID := uuid.NewV4().String()
+	postID, foreignPostID := uuid.NewV4().String(), uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&post.Post{ID: postID, UserID: ownerID, Status: post.StatusPublished, CreatedAt: now, UpdatedAt: now, ResourceCount: 3}).Error)
+	assert.Nil(t, app.DB.Create(&post.Post{ID: foreignPostID, UserID: ownerID, Status: post.StatusPublished, CreatedAt: now, UpdatedAt: now, ResourceCount: 1}).Error)
+	ids := []string{uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String()}
+	for i, id := range ids {
+		assert.Nil(t, app.DB.Create(&resource.Resource{ID: id, PostID: postID, IndexInPost: i, Path: "posts/" + postID, MediaType: resource.MediaImage, CreatedAt: now}).Error)
+	}
+	foreignID := uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&resource.Resource{ID: foreignID, PostID: foreignPostID, IndexInPost: 0, Path: "posts/" + foreignPostID, MediaType: resource.MediaImage, CreatedAt: now}).Error)
+
+	reorder := func(resourceIDs ...string) *httptest.ResponseRecorder {
+		body, _ := json.Marshal(map[string]interface{}{"resource_ids": resourceIDs})
+		req := httptest.NewRequest(http.MethodPut, "/post/"+postID+"/resources", bytes.NewReader(body))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Set("User_id", ownerID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	storedOrder := func() []string {
+		var order []string
+		for _, r := range resource.NewRepository().FindByPostID(app.DB, postID) {
+			order = append(order, r.ID)
+		}
+		return order
+	}
+
+	t.Run("resources should come back and be stored in the new order", func(t *testing.T) {
+		w := reorder(ids[2], ids[0], ids[1])
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var res struct {
+			Data []resource.Response `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+		var returned []string
+		for _, r := range res.Data {
+			returned = append(returned, r.ID)
+		}
+		assert.Equal(t, []string{ids[2], ids[0], ids[1]}, returned)
+		assert.Equal(t, []string{ids[2], ids[0], ids[1]}, storedOrder())
+	})
+
+	t.Run("resource of another post should be rejected", func(t *testing.T) {
+		before := storedOrder()
+		w := reorder(ids[0], ids[1], foreignID)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, before, storedOrder())
+	})
+
+	t.Run("duplicate resource should be rejected", func(t *testing.T) {
+		before := storedOrder()
+		w := reorder(ids[0], ids[0], ids[1])
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, before, storedOrder())
+	})
+}
+
+func TestRevisions(t *testing.T) {
+	app.TestDBInit()
+	router := gin.New()
+	router.Use(gin.CustomRecovery(middleware.PanicHandler))
+
+	postService := post.NewService(validator.New(), post.NewRepository(), resource.NewRepository(), like.NewRepository(), comment.NewRepository(), audit.NewRepository(), search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer()), upload.NewService(validator.New(), upload.NewRepository()), blob.NewRepository(), tag.NewRepository(), location.NewRepository(), user.NewRepository(), relation.NewRepository(), notification.NewRepository(), cache.NewLoader(cache.NewLRU(100)))
+	postController := post.NewController(postService)
+
+	router.PUT("/post/:postID", postController.Update)
+	router.GET("/post/:postID/revisions", postController.FindRevisions)
+
+	now := time.Now()
+	ownerID := uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&user.User{ID: ownerID, Email: ownerID + "@example.com", Username: ownerID, DisplayName: ownerID, Role: user.RoleUser, CreatedAt: now, UpdatedAt: now}).Error)
+	postID := uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&post.Post{ID: postID, UserID: ownerID, Caption: "first caption", Status: post.StatusPublished, CreatedAt: now, UpdatedAt: now}).Error)
+	draftID := uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&post.Post{ID: draftID, UserID: ownerID, Caption: "draft caption", Status: post.StatusDraft, CreatedAt: now, UpdatedAt: now}).Error)
+	assert.Nil(t, app.DB.Create(&post.Revision{PostID: draftID, Caption: "earlier draft caption", ReplacedAt: now}).Error)
+
+	findRevisions := func(postID, viewerID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/post/"+postID+"/revisions", nil)
+		req.Header.Set("User_id", viewerID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("edited caption should be listed as a revision", func(t *testing.T) {
+		body, _ := json.Marshal(map[string]interface{}{"caption": "second caption"})
+		req := httptest.NewRequest(http.MethodPut, "/post/"+postID, bytes.NewReader(body))
+		req.Header.Add("Content-Type", "application/json")
+		req.Header.Set("User_id", ownerID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+
+		w = findRevisions(postID, ownerID)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var res struct {
+			Data []post.RevisionResponse `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Len(t, res.Data, 1)
+		assert.Equal(t, "first caption", res.Data[0].Caption)
+		assert.Equal(t, "second caption", post.NewRepository().FindByPostID(app.DB, postID).Caption)
+	})
+
+	t.Run("history of a draft should be shown to its owner only", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, findRevisions(draftID, ownerID).Code)
+		assert.Equal(t, http.StatusNotFound, findRevisions(draftID, uuid.NewV4().String()).Code)
+		assert.Equal(t, http.StatusOK, findRevisions(postID, uuid.NewV4().String()).Code)
+	})
+}
+
+func TestRestore(t *testing.T) {
+	app.TestDBInit()
+	router := gin.New()
+	router.Use(gin.CustomRecovery(middleware.PanicHandler))
+
+	postService := post.NewService(validator.New(), post.NewRepository(), resource.NewRepository(), like.NewRepository(), comment.NewRepository(), audit.NewRepository(), search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer()), upload.NewService(validator.New(), upload.NewRepository()), blob.NewRepository(), tag.NewRepository(), location.NewRepository(), user.NewRepository(), relation.NewRepository(), notification.NewRepository(), cache.NewLoader(cache.NewLRU(100)))
+	postController := post.NewController(postService)
+
+	router.DELETE("/post/:postID", postController.Delete)
+	router.POST("/post/:postID/restore", postController.Restore)
+	router.GET("/post/deleted", postController.FindDeleted)
+
+	now := time.Now()
+	ownerID := uuid.NewV4().String()
+	postID := uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&post.Post{ID: postID, UserID: ownerID, Caption: "deleted caption", Status: post.StatusPublished, CreatedAt: now, UpdatedAt: now}).Error)
+
+	t.Run("deleted post should be listed in recently deleted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodDelete, "/post/"+postID, nil)
+		req.Header.Set("User_id", ownerID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Empty(t, post.NewRepository().FindByPostID(app.DB, postID).ID)
+
+		req = httptest.NewRequest(http.MethodGet, "/post/deleted", nil)
+		req.Header.Set("User_id", ownerID)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var res struct {
+			Data []post.DeletedResponse `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+		assert.Len(t, res.Data, 1)
+		assert.Equal(t, postID, res.Data[0].PostID)
+	})
+
+	t.Run("another user should not restore the post", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/post/"+postID+"/restore", nil)
+		req.Header.Set("User_id", uuid.NewV4().String())
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("restored post should be back", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/post/"+postID+"/restore", nil)
+		req.Header.Set("User_id", ownerID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "deleted caption", post.NewRepository().FindByPostID(app.DB, postID).Caption)
+	})
+}
+
+func TestPrivateOwnerVisibility(t *testing.T) {
+	app.TestDBInit()
+	router := gin.New()
+	router.Use(gin.CustomRecovery(middleware.PanicHandler))
+
+	postService := post.NewService(validator.New(), post.NewRepository(), resource.NewRepository(), like.NewRepository(), comment.NewRepository(), audit.NewRepository(), search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer()), upload.NewService(validator.New(), upload.NewRepository()), blob.NewRepository(), tag.NewRepository(), location.NewRepository(), user.NewRepository(), relation.NewRepository(), notification.NewRepository(), cache.NewLoader(cache.NewLRU(100)))
+	postController := post.NewController(postService)
+
+	router.GET("/post/", postController.FindByUserID)
+	router.GET("/post/:postID", postController.FindByPostID)
+
+	now := time.Now()
+	ownerID, followerID, blockedID := uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String()
+	for _, id := range []string{ownerID, followerID, blockedID} {
+		assert.Nil(t, app.DB.Create(&user.User{ID: id, Email: id + "@example.com", Username: id, DisplayName: id, Role: user.RoleUser, IsPrivate: id == ownerID, CreatedAt: now, UpdatedAt: now}).Error)
+	}
+	assert.Nil(t, app.DB.Create(&relation.Follow{FollowerID: followerID, FollowingID: ownerID, CreatedAt: now}).Error)
+	assert.Nil(t, app.DB.Create(&relation.Follow{FollowerID: blockedID, FollowingID: ownerID, CreatedAt: now}).Error)
+	assert.Nil(t, app.DB.Create(&relation.Block{BlockerID: ownerID, BlockedID: blockedID, CreatedAt: now}).Error)
+	postID := uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&post.Post{ID: postID, UserID: ownerID, Caption: "private caption", Status: post.StatusPublished, CreatedAt: now, UpdatedAt: now}).Error)
+
+	get := func(path, viewerID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("User_id", viewerID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	listed := func(viewerID string) int {
+		var res struct {
+			Data []post.Response `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal(get("/post/?user_id="+ownerID, viewerID).Body.Bytes(), &res))
+		return len(res.Data)
+	}
+
+	t.Run("owner and followers should see the post", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, get("/post/"+postID, ownerID).Code)
+		assert.Equal(t, http.StatusOK, get("/post/"+postID, followerID).Code)
+		assert.Equal(t, 1, listed(ownerID))
+		assert.Equal(t, 1, listed(followerID))
+	})
+
+	t.Run("strangers and blocked users should not see the post", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, get("/post/"+postID, uuid.NewV4().String()).Code)
+		assert.Equal(t, http.StatusNotFound, get("/post/"+postID, blockedID).Code)
+		assert.Equal(t, 0, listed(uuid.NewV4().String()))
+		assert.Equal(t, 0, listed(blockedID))
+	})
+}
@@ -1,13 +1,103 @@
 package post
 
 import (
+	"go-api/model/search"
+	"gorm.io/gorm"
 	"time"
 )
 
 type Post struct {
-	ID     string `gorm:"column:post_id;primaryKey"`
-	UserID string `gorm:"column:user_id;"`
-	Caption   string    `gorm:"column:caption;"`
-	CreatedAt time.Time `gorm:"column:created_at;"`
-	UpdatedAt time.Time `gorm:"column:updated_at"`
-}
\ No newline at end of file
+	ID      string `gorm:"column:post_id;primaryKey"`
+	UserID  string `gorm:"column:user_id;"`
+	Caption string `gorm:"column:caption;"`
+	// LocationID is empty when the post has no location.
+	LocationID string    `gorm:"column:location_id;"`
+	CreatedAt  time.Time `gorm:"column:created_at;"`
+	UpdatedAt  time.Time `gorm:"column:updated_at"`
+	// DeletedAt is set while the post sits in recently deleted, see RestoreWindow.
+	DeletedAt gorm.DeletedAt `gorm:"column:deleted_at;index"`
+	// Status is one of the Status constants, only published posts are seen by anyone but the owner.
+	Status    string     `gorm:"column:status;default:published"`
+	PublishAt *time.Time `gorm:"column:publish_at;index"`
+	// PublishError says why the scheduler couldn't publish the post.
+	PublishError string `gorm:"column:publish_error;"`
+	// IsArchived hides the post from everyone but its owner without deleting it.
+	IsArchived       bool       `gorm:"column:is_archived;"`
+	CommentsDisabled bool       `gorm:"column:comments_disabled;"`
+	HideLikeCount    bool       `gorm:"column:hide_like_count;"`
+	PinnedAt         *time.Time `gorm:"column:pinned_at;"`
+	// The counters are moved along with the rows they count, ReconcileCounters repairs any drift.
+	LikesCount    int64 `gorm:"column:likes_count;"`
+	CommentsCount int64 `gorm:"column:comments_count;"`
+	ResourceCount int64 `gorm:"column:resource_count;"`
+}
+
+// Counters are the stored counters of a post next to the ones recounted from its rows.
+type Counters struct {
+	PostID          string `gorm:"column:post_id"`
+	LikesCount      int64  `gorm:"column:likes_count"`
+	CommentsCount   int64  `gorm:"column:comments_count"`
+	ResourceCount   int64  `gorm:"column:resource_count"`
+	ActualLikes     int64  `gorm:"column:actual_likes"`
+	ActualComments  int64  `gorm:"column:actual_comments"`
+	ActualResources int64  `gorm:"column:actual_resources"`
+}
+
+func (c *Counters) HasDrift() bool {
+	return c.LikesCount != c.ActualLikes || c.CommentsCount != c.ActualComments || c.ResourceCount != c.ActualResources
+}
+
+// MaxPinned is how many posts a user can pin to the top of their profile.
+const MaxPinned = 3
+
+const (
+	StatusPublished = "published"
+	StatusDraft     = "draft"
+	StatusScheduled = "scheduled"
+	// StatusFailed is a scheduled post the scheduler gave up on, the owner can fix and reschedule it.
+	StatusFailed = "failed"
+)
+
+// Revision is a caption a post had before it was edited.
+type Revision struct {
+	ID         int64     `gorm:"column:revision_id;primaryKey;autoIncrement"`
+	PostID     string    `gorm:"column:post_id;index"`
+	Caption    string    `gorm:"column:caption;"`
+	ReplacedAt time.Time `gorm:"column:replaced_at;"`
+}
+
+func (Revision) TableName() string {
+	return "post_revisions"
+}
+
+func (p *Post) IsPublished() bool {
+	return p.Status == StatusPublished
+}
+
+// IsPublic reports whether people other than the owner can see the post.
+func (p *Post) IsPublic() bool {
+	return p.IsPublished() && !p.IsArchived && !p.DeletedAt.Valid
+}
+
+// LikeCountFor is the like count the viewer gets to see, hidden counts are shown only to the owner.
+func (p *Post) LikeCountFor(viewerID string, likesCount int64) (int64, bool) {
+	if p.HideLikeCount && p.UserID != viewerID {
+		return 0, true
+	}
+	return likesCount, false
+}
+
+// PurgeAt is when a deleted post stops being restorable.
+func (p *Post) PurgeAt() time.Time {
+	return p.DeletedAt.Time.Add(RestoreWindow)
+}
+
+func (p *Post) ToDocument(thumbnailPath string) *search.PostDocument {
+	return &search.PostDocument{
+		PostID:        p.ID,
+		UserID:        p.UserID,
+		Caption:       p.Caption,
+		ThumbnailPath: thumbnailPath,
+		CreatedAt:     p.CreatedAt,
+	}
+}
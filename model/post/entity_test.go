@@ -0,0 +1,44 @@
+package post_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-api/model/post"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+func TestPurgeAt(t *testing.T) {
+	deletedAt := time.Date(2021, 11, 1, 10, 0, 0, 0, time.UTC)
+	p := &post.Post{DeletedAt: gorm.DeletedAt{Time: deletedAt, Valid: true}}
+	assert.Equal(t, time.Date(2021, 12, 1, 10, 0, 0, 0, time.UTC), p.PurgeAt())
+}
+
+func TestLikeCountFor(t *testing.T) {
+	p := &post.Post{UserID: "owner", HideLikeCount: true}
+
+	count, hidden := p.LikeCountFor("owner", 12)
+	assert.Equal(t, int64(12), count)
+	assert.False(t, hidden)
+
+	count, hidden = p.LikeCountFor("someone", 12)
+	assert.Equal(t, int64(0), count)
+	assert.True(t, hidden)
+
+	p.HideLikeCount = false
+	count, hidden = p.LikeCountFor("someone", 12)
+	assert.Equal(t, int64(12), count)
+	assert.False(t, hidden)
+}
+
+func TestCountersHasDrift(t *testing.T) {
+	c := &post.Counters{LikesCount: 3, ActualLikes: 3, CommentsCount: 1, ActualComments: 1, ResourceCount: 2, ActualResources: 2}
+	assert.False(t, c.HasDrift())
+
+	c.ActualComments = 2
+	assert.True(t, c.HasDrift())
+
+	c.ActualComments = 1
+	c.ResourceCount = 0
+	assert.True(t, c.HasDrift())
+}
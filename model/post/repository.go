@@ -2,15 +2,65 @@ package post
 
 import (
 	"go-api/exception"
+	"go-api/model/relation"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
 )
 
 type Repository interface {
 	Create(tx *gorm.DB, post *Post)
 	Update(tx *gorm.DB, post *Post)
+	// Delete moves the post to recently deleted, Purge removes the row for good.
 	Delete(tx *gorm.DB, postID string)
+	Purge(tx *gorm.DB, postID string)
+	Restore(tx *gorm.DB, postID string)
 	FindByPostID(tx *gorm.DB, postID string) *Post
-	FindByUserID(tx *gorm.DB, userID string) []*Post
+	// CanSee is true when the post is up and viewerID may see it, see relation.VisiblePosts.
+	CanSee(tx *gorm.DB, postID, viewerID string) bool
+	// FindForUpdate locks the post so concurrent edits of its carousel are applied one at a time.
+	FindForUpdate(tx *gorm.DB, postID string) *Post
+	Touch(tx *gorm.DB, postID string, updatedAt time.Time)
+	// FindByUserID returns the posts of the user that viewerID is allowed to see, pinned ones first.
+	FindByUserID(tx *gorm.DB, userID, viewerID string) []*Post
+	FindArchived(tx *gorm.DB, userID string) []*Post
+	SetArchived(tx *gorm.DB, postID string, archived bool)
+	// SetPinned pins the post at pinnedAt, nil unpins it.
+	SetPinned(tx *gorm.DB, postID string, pinnedAt *time.Time)
+	CountPinned(tx *gorm.DB, userID string) int64
+	UpdateSettings(tx *gorm.DB, postID string, commentsDisabled, hideLikeCount bool)
+	// FindDrafts returns the user's posts that aren't published yet, last edited first.
+	FindDrafts(tx *gorm.DB, userID string) []*Post
+	// FindDue returns scheduled posts whose publish time has come.
+	FindDue(tx *gorm.DB, now time.Time, limit int) []*Post
+	// Schedule moves an unpublished post to status, publishAt is nil for drafts.
+	Schedule(tx *gorm.DB, postID, status string, publishAt *time.Time)
+	// Publish makes the post visible, it counts as created at publishedAt.
+	Publish(tx *gorm.DB, postID string, publishedAt time.Time)
+	MarkPublishFailed(tx *gorm.DB, postID, reason string)
+	SetLocation(tx *gorm.DB, postID, locationID string)
+	// FindByLocationID returns posts at the location created before before, newest first, that viewerID is allowed to see.
+	FindByLocationID(tx *gorm.DB, locationID, viewerID string, before time.Time, limit int) []*Post
+	// FindTagged returns posts userID is tagged in that viewerID is allowed to see, newest first.
+	FindTagged(tx *gorm.DB, userID, viewerID string) []*Post
+	// FindWithDeleted finds the post whether or not it was deleted.
+	FindWithDeleted(tx *gorm.DB, postID string) *Post
+	// FindDeletedByUserID returns the user's posts deleted since since, most recently deleted first.
+	FindDeletedByUserID(tx *gorm.DB, userID string, since time.Time) []*Post
+	// FindPurgeable returns posts deleted before before.
+	FindPurgeable(tx *gorm.DB, before time.Time, limit int) []*Post
+	CreateRevision(tx *gorm.DB, revision *Revision)
+	// FindRevisions returns the replaced captions of a post, newest first.
+	FindRevisions(tx *gorm.DB, postID string) []*Revision
+	CountRevisions(tx *gorm.DB, postID string) int64
+	DeleteRevisions(tx *gorm.DB, postID string)
+	// FindAll returns the published, unarchived posts created at or after since, oldest first.
+	FindAll(tx *gorm.DB, since time.Time, offset, limit int) []*Post
+	SetResourceCount(tx *gorm.DB, postID string, count int)
+	// FindCounters recounts the rows of posts after afterID in post ID order, deleted posts included.
+	FindCounters(tx *gorm.DB, afterID string, limit int) []*Counters
+	// Recount sets the counters of the post from its rows.
+	Recount(tx *gorm.DB, postID string)
 }
 
 type repositoryImpl struct {
@@ -40,7 +90,29 @@ func (*repositoryImpl) Update(tx *gorm.DB, post *Post) {
 }
 
 func (*repositoryImpl) Delete(tx *gorm.DB, postID string) {
-	err := tx.Where("post_id = ?", postID).Delete(&Post{}).Error
+	// a deleted post must not keep one of its owner's pin slots, nor come back pinned when restored
+	err := tx.Model(&Post{}).Where("post_id = ?", postID).Update("pinned_at", nil).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	err = tx.Where("post_id = ?", postID).Delete(&Post{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Purge(tx *gorm.DB, postID string) {
+	err := tx.Unscoped().Where("post_id = ?", postID).Delete(&Post{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Restore(tx *gorm.DB, postID string) {
+	err := tx.Unscoped().Model(&Post{}).
+		Where("post_id = ?", postID).
+		Update("deleted_at", nil).Error
 	if err != nil {
 		panic(exception.DatabaseError{Message: err.Error()})
 	}
@@ -57,11 +129,329 @@ func (*repositoryImpl) FindByPostID(tx *gorm.DB, postID string) *Post {
 	return post
 }
 
-func (*repositoryImpl) FindByUserID(tx *gorm.DB, userID string) []*Post {
+func (*repositoryImpl) CanSee(tx *gorm.DB, postID, viewerID string) bool {
+	var ids []string
+	err := tx.Table("posts AS p").
+		Scopes(relation.VisiblePosts(viewerID)).
+		Where("p.post_id = ?", postID).
+		Limit(1).
+		Pluck("p.post_id", &ids).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return len(ids) > 0
+}
+
+func (*repositoryImpl) FindForUpdate(tx *gorm.DB, postID string) *Post {
+	var post Post
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("post_id = ?", postID).
+		Limit(1).
+		Find(&post).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return &post
+}
+
+func (*repositoryImpl) Touch(tx *gorm.DB, postID string, updatedAt time.Time) {
+	err := tx.Model(&Post{}).
+		Where("post_id = ?", postID).
+		Update("updated_at", updatedAt).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindByUserID(tx *gorm.DB, userID, viewerID string) []*Post {
+	var posts []*Post
+	err := tx.Table("posts AS p").
+		Select("p.*").
+		Scopes(relation.VisiblePosts(viewerID)).
+		Where("p.user_id = ?", userID).
+		Order("p.pinned_at IS NULL, p.pinned_at desc, p.created_at desc").
+		Find(&posts).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return posts
+}
+
+func (*repositoryImpl) FindArchived(tx *gorm.DB, userID string) []*Post {
+	var posts []*Post
+	err := tx.Where("user_id = ? AND is_archived = ?", userID, true).
+		Order("created_at desc").
+		Find(&posts).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return posts
+}
+
+func (*repositoryImpl) SetArchived(tx *gorm.DB, postID string, archived bool) {
+	updates := map[string]interface{}{"is_archived": archived}
+	if archived {
+		// an archived post can't stay pinned on a profile it isn't shown on
+		updates["pinned_at"] = nil
+	}
+
+	err := tx.Model(&Post{}).
+		Where("post_id = ?", postID).
+		Updates(updates).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) SetPinned(tx *gorm.DB, postID string, pinnedAt *time.Time) {
+	err := tx.Model(&Post{}).
+		Where("post_id = ?", postID).
+		Update("pinned_at", pinnedAt).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) CountPinned(tx *gorm.DB, userID string) int64 {
+	var count int64
+	err := tx.Model(&Post{}).
+		Where("user_id = ? AND pinned_at IS NOT NULL", userID).
+		Count(&count).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return count
+}
+
+func (*repositoryImpl) UpdateSettings(tx *gorm.DB, postID string, commentsDisabled, hideLikeCount bool) {
+	err := tx.Model(&Post{}).
+		Where("post_id = ?", postID).
+		Updates(map[string]interface{}{"comments_disabled": commentsDisabled, "hide_like_count": hideLikeCount}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindDrafts(tx *gorm.DB, userID string) []*Post {
+	var posts []*Post
+	err := tx.Where("user_id = ? AND status <> ?", userID, StatusPublished).
+		Order("updated_at desc").
+		Find(&posts).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return posts
+}
+
+func (*repositoryImpl) FindDue(tx *gorm.DB, now time.Time, limit int) []*Post {
 	var posts []*Post
-	err := tx.Where("user_id = ?", userID).Find(&posts).Error
+	err := tx.Where("status = ? AND publish_at <= ?", StatusScheduled, now).
+		Order("publish_at asc").
+		Limit(limit).
+		Find(&posts).Error
 	if err != nil {
 		panic(exception.DatabaseError{Message: err.Error()})
 	}
 	return posts
 }
+
+func (*repositoryImpl) Schedule(tx *gorm.DB, postID, status string, publishAt *time.Time) {
+	err := tx.Model(&Post{}).
+		Where("post_id = ?", postID).
+		Updates(map[string]interface{}{"status": status, "publish_at": publishAt, "publish_error": "", "updated_at": time.Now()}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Publish(tx *gorm.DB, postID string, publishedAt time.Time) {
+	err := tx.Model(&Post{}).
+		Where("post_id = ?", postID).
+		Updates(map[string]interface{}{
+			"status":        StatusPublished,
+			"publish_at":    nil,
+			"publish_error": "",
+			"created_at":    publishedAt,
+			"updated_at":    publishedAt,
+		}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) MarkPublishFailed(tx *gorm.DB, postID, reason string) {
+	err := tx.Model(&Post{}).
+		Where("post_id = ?", postID).
+		Updates(map[string]interface{}{"status": StatusFailed, "publish_error": reason}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) SetLocation(tx *gorm.DB, postID, locationID string) {
+	err := tx.Model(&Post{}).
+		Where("post_id = ?", postID).
+		Update("location_id", locationID).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindByLocationID(tx *gorm.DB, locationID, viewerID string, before time.Time, limit int) []*Post {
+	var posts []*Post
+	err := tx.Table("posts AS p").
+		Select("p.*").
+		Scopes(relation.VisiblePosts(viewerID)).
+		Where("p.location_id = ? AND p.created_at < ?", locationID, before).
+		Order("p.created_at desc").
+		Limit(limit).
+		Find(&posts).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return posts
+}
+
+func (*repositoryImpl) FindTagged(tx *gorm.DB, userID, viewerID string) []*Post {
+	var posts []*Post
+	err := tx.Table("posts AS p").
+		Select("p.*").
+		Scopes(relation.VisiblePosts(viewerID)).
+		Where("p.post_id IN (SELECT t.post_id FROM resource_tags t WHERE t.user_id = ?)", userID).
+		Order("p.created_at desc").
+		Find(&posts).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return posts
+}
+
+func (*repositoryImpl) FindWithDeleted(tx *gorm.DB, postID string) *Post {
+	var post Post
+	err := tx.Unscoped().
+		Where("post_id = ?", postID).
+		Limit(1).
+		Find(&post).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return &post
+}
+
+func (*repositoryImpl) FindDeletedByUserID(tx *gorm.DB, userID string, since time.Time) []*Post {
+	var posts []*Post
+	err := tx.Unscoped().
+		Where("user_id = ? AND deleted_at >= ?", userID, since).
+		Order("deleted_at desc").
+		Find(&posts).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return posts
+}
+
+func (*repositoryImpl) FindPurgeable(tx *gorm.DB, before time.Time, limit int) []*Post {
+	var posts []*Post
+	err := tx.Unscoped().
+		Where("deleted_at < ?", before).
+		Order("deleted_at asc").
+		Limit(limit).
+		Find(&posts).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return posts
+}
+
+func (*repositoryImpl) CreateRevision(tx *gorm.DB, revision *Revision) {
+	err := tx.Create(&revision).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindRevisions(tx *gorm.DB, postID string) []*Revision {
+	var revisions []*Revision
+	err := tx.Where("post_id = ?", postID).
+		Order("replaced_at desc, revision_id desc").
+		Find(&revisions).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return revisions
+}
+
+func (*repositoryImpl) CountRevisions(tx *gorm.DB, postID string) int64 {
+	var count int64
+	err := tx.Model(&Revision{}).Where("post_id = ?", postID).Count(&count).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return count
+}
+
+func (*repositoryImpl) DeleteRevisions(tx *gorm.DB, postID string) {
+	err := tx.Where("post_id = ?", postID).Delete(&Revision{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindAll(tx *gorm.DB, since time.Time, offset, limit int) []*Post {
+	var posts []*Post
+	err := tx.Where("status = ? AND is_archived = ? AND created_at >= ?", StatusPublished, false, since).
+		Order("created_at asc").
+		Offset(offset).
+		Limit(limit).
+		Find(&posts).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return posts
+}
+
+func (*repositoryImpl) SetResourceCount(tx *gorm.DB, postID string, count int) {
+	err := tx.Model(&Post{}).
+		Where("post_id = ?", postID).
+		UpdateColumn("resource_count", count).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+const (
+	countLikes     = "(SELECT COUNT(*) FROM likes l WHERE l.post_id = posts.post_id)"
+	countComments  = "(SELECT COUNT(*) FROM comments c WHERE c.post_id = posts.post_id)"
+	countResources = "(SELECT COUNT(*) FROM resources r WHERE r.post_id = posts.post_id)"
+)
+
+func (*repositoryImpl) FindCounters(tx *gorm.DB, afterID string, limit int) []*Counters {
+	var counters []*Counters
+	err := tx.Table("posts").
+		Select("post_id, likes_count, comments_count, resource_count, "+
+			countLikes+" AS actual_likes, "+
+			countComments+" AS actual_comments, "+
+			countResources+" AS actual_resources").
+		Where("post_id > ?", afterID).
+		Order("post_id asc").
+		Limit(limit).
+		Find(&counters).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return counters
+}
+
+func (*repositoryImpl) Recount(tx *gorm.DB, postID string) {
+	// counted inside the update so likes and comments made since FindCounters aren't lost
+	err := tx.Table("posts").
+		Where("post_id = ?", postID).
+		UpdateColumns(map[string]interface{}{
+			"likes_count":    gorm.Expr(countLikes),
+			"comments_count": gorm.Expr(countComments),
+			"resource_count": gorm.Expr(countResources),
+		}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
@@ -0,0 +1,46 @@
+package post_test
+
+import (
+	"github.com/stretchr/testify/assert"
+	"go-api/model/post"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"testing"
+	"time"
+)
+
+// dryRunDB builds statements without a server, the SQL of the last query is kept in sql.
+func dryRunDB(t *testing.T, sql *string) *gorm.DB {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "root:root@tcp(localhost:3306)/go_api_test?parseTime=true",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	assert.Nil(t, err)
+
+	err = db.Callback().Query().After("gorm:query").Register("test:capture_sql", func(tx *gorm.DB) {
+		*sql = tx.Statement.SQL.String()
+	})
+	assert.Nil(t, err)
+	return db
+}
+
+func TestAliasedQueriesFilterDeletedByAlias(t *testing.T) {
+	var sql string
+	db := dryRunDB(t, &sql)
+	repository := post.NewRepository()
+
+	queries := map[string]func(){
+		"FindByLocationID": func() { repository.FindByLocationID(db, "location", "viewer", time.Now(), 10) },
+		"FindTagged":       func() { repository.FindTagged(db, "user", "viewer") },
+		"FindByUserID":     func() { repository.FindByUserID(db, "user", "viewer") },
+	}
+
+	for name, query := range queries {
+		t.Run(name, func(t *testing.T) {
+			query()
+			assert.Contains(t, sql, "FROM posts AS p")
+			assert.Contains(t, sql, "`p`.`deleted_at` IS NULL")
+			assert.NotContains(t, sql, "`posts`.")
+		})
+	}
+}
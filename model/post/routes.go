@@ -5,8 +5,31 @@ import "github.com/gin-gonic/gin"
 func InitRoutes(router *gin.RouterGroup, controller Controller) {
 	userGroup := router.Group("/post")
 	userGroup.GET("/", controller.FindByUserID)
+	userGroup.GET("/tagged", controller.FindTagged)
+	userGroup.GET("/deleted", controller.FindDeleted)
+	userGroup.GET("/drafts", controller.FindDrafts)
+	userGroup.GET("/archive", controller.FindArchived)
 	userGroup.GET("/:postID", controller.FindByPostID)
 	userGroup.POST("/", controller.Create)
 	userGroup.PUT("/:postID", controller.Update)
 	userGroup.DELETE("/:postID", controller.Delete)
+	userGroup.POST("/:postID/restore", controller.Restore)
+	userGroup.PUT("/:postID/schedule", controller.Schedule)
+	userGroup.POST("/:postID/publish", controller.Publish)
+	userGroup.PUT("/:postID/archive", controller.Archive)
+	userGroup.DELETE("/:postID/archive", controller.Unarchive)
+	userGroup.PUT("/:postID/pin", controller.Pin)
+	userGroup.DELETE("/:postID/pin", controller.Unpin)
+	userGroup.PUT("/:postID/settings", controller.UpdateSettings)
+	userGroup.GET("/:postID/revisions", controller.FindRevisions)
+	userGroup.PUT("/:postID/resources", controller.ReorderResources)
+	userGroup.POST("/:postID/resources", controller.AppendResources)
+	userGroup.DELETE("/:postID/resources/:resourceID", controller.RemoveResource)
+	userGroup.PUT("/:postID/resources/:resourceID", controller.UpdateResource)
+	userGroup.DELETE("/:postID/tags", controller.RemoveTag)
+	userGroup.PUT("/:postID/location", controller.SetLocation)
+	userGroup.DELETE("/:postID/location", controller.RemoveLocation)
+
+	locationGroup := router.Group("/location")
+	locationGroup.GET("/:locationID", controller.FindByLocationID)
 }
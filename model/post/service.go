@@ -5,11 +5,23 @@ import (
 	"github.com/go-playground/validator"
 	uuid "github.com/satori/go.uuid"
 	"go-api/app"
+	"go-api/cache"
 	"go-api/exception"
 	"go-api/helper"
+	"go-api/metrics"
+	"go-api/model/audit"
+	"go-api/model/blob"
 	"go-api/model/comment"
 	"go-api/model/like"
+	"go-api/model/location"
+	"go-api/model/notification"
+	"go-api/model/relation"
 	"go-api/model/resource"
+	"go-api/model/search"
+	"go-api/model/tag"
+	"go-api/model/upload"
+	"go-api/model/user"
+	"gorm.io/gorm"
 	"time"
 )
 
@@ -17,37 +29,141 @@ type Service interface {
 	Create(ctx context.Context, req *CreateRequest) *DetailResponse
 	Update(ctx context.Context, req *UpdateRequest)
 	Delete(ctx context.Context, req *DeleteRequest)
+	Restore(ctx context.Context, req *RestoreRequest)
+	Schedule(ctx context.Context, req *ScheduleRequest)
+	Archive(ctx context.Context, req *ArchiveRequest)
+	FindArchived(ctx context.Context, userID string) []*Response
+	Pin(ctx context.Context, req *PinRequest)
+	UpdateSettings(ctx context.Context, req *SettingsRequest) *SettingsResponse
+	Publish(ctx context.Context, req *PublishRequest)
+	FindDrafts(ctx context.Context, userID string) []*DraftResponse
+	// PublishDue publishes scheduled posts whose time has come, each exactly once however many instances run it,
+	// then indexes the posts published since its last run on any instance.
+	PublishDue(ctx context.Context)
+	FindDeleted(ctx context.Context, userID string) []*DeletedResponse
+	// Purge removes posts deleted longer than RestoreWindow ago with their media, likes and comments.
+	Purge(ctx context.Context)
+	FindRevisions(ctx context.Context, postID, viewerID string) []*RevisionResponse
+	Reorder(ctx context.Context, req *ReorderRequest) []resource.Response
+	RemoveResource(ctx context.Context, req *RemoveResourceRequest) []resource.Response
+	AppendResources(ctx context.Context, req *AppendResourcesRequest) []resource.Response
+	UpdateResource(ctx context.Context, req *UpdateResourceRequest) resource.Response
+	RemoveTag(ctx context.Context, req *RemoveTagRequest)
+	SetLocation(ctx context.Context, req *SetLocationRequest) *location.Response
 	FindByPostID(ctx context.Context, postID, viewerID string) *DetailResponse
-	FindByUserID(ctx context.Context, userID string) []*Response
+	FindByUserID(ctx context.Context, userID, viewerID string) []*Response
+	FindTagged(ctx context.Context, userID, viewerID string) []*Response
+	FindByLocationID(ctx context.Context, req *FindByLocationRequest) *LocationResponse
+	Reindex(ctx context.Context, index search.Service)
+	// ReconcileCounters recounts the counters of every post, logs the ones that drifted and fixes them.
+	// It returns how many posts had drifted.
+	ReconcileCounters(ctx context.Context) int
 }
 
 type serviceImpl struct {
-	validate *validator.Validate
-	postRepository     Repository
-	resourceRepository resource.Repository
-	likeRepository     like.Repository
-	commentRepository  comment.Repository
+	validate               *validator.Validate
+	postRepository         Repository
+	resourceRepository     resource.Repository
+	likeRepository         like.Repository
+	commentRepository      comment.Repository
+	auditRepository        audit.Repository
+	searchService          search.Service
+	uploadService          upload.Service
+	blobRepository         blob.Repository
+	tagRepository          tag.Repository
+	locationRepository     location.Repository
+	userRepository         user.Repository
+	relationRepository     relation.Repository
+	notificationRepository notification.Repository
+	cache                  *cache.Loader
+	// indexedSince is when this instance last caught its search index up with published posts,
+	// it's only touched by the scheduler, the periodic rebuild of the index covers what came before.
+	indexedSince time.Time
 }
 
-func NewService(validate *validator.Validate, postRepository Repository, resourceRepository resource.Repository, likeRepository like.Repository, commentRepository comment.Repository) Service {
-	return &serviceImpl{validate: validate, postRepository: postRepository, resourceRepository: resourceRepository, likeRepository: likeRepository, commentRepository: commentRepository}
+func NewService(validate *validator.Validate, postRepository Repository, resourceRepository resource.Repository, likeRepository like.Repository, commentRepository comment.Repository, auditRepository audit.Repository, searchService search.Service, uploadService upload.Service, blobRepository blob.Repository, tagRepository tag.Repository, locationRepository location.Repository, userRepository user.Repository, relationRepository relation.Repository, notificationRepository notification.Repository, cache *cache.Loader) Service {
+	return &serviceImpl{validate: validate, postRepository: postRepository, resourceRepository: resourceRepository, likeRepository: likeRepository, commentRepository: commentRepository, auditRepository: auditRepository, searchService: searchService, uploadService: uploadService, blobRepository: blobRepository, tagRepository: tagRepository, locationRepository: locationRepository, userRepository: userRepository, relationRepository: relationRepository, notificationRepository: notificationRepository, cache: cache, indexedSince: time.Now()}
 }
 
+const (
+	reindexBatchSize     = 500
+	purgeBatchSize       = 100
+	publishBatchSize     = 100
+	reconcileBatchSize   = 500
+	MaxCarouselSize      = 10
+	defaultLocationLimit = 20
+
+	// RestoreWindow is how long a deleted post can be restored before it's purged.
+	RestoreWindow = 30 * 24 * time.Hour
+	PurgeInterval = time.Hour
+	// PublishInterval is how often the scheduler looks for due posts, it bounds how late they go out.
+	PublishInterval = time.Minute
+	// ReconcileInterval is how often the counters are checked against the rows they count.
+	ReconcileInterval = 6 * time.Hour
+	// CacheTTL bounds how stale a cached post can get when an invalidation is missed.
+	CacheTTL = 5 * time.Minute
+)
+
+var postsCreated = metrics.NewCounter("posts_created_total", "Posts created by the status they were created with.", "status")
+
 func (s *serviceImpl) Create(ctx context.Context, req *CreateRequest) *DetailResponse {
 	err := s.validate.Struct(req)
 	if err != nil {
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
-	defer helper.TXCommitOrRollback(tx)
+	if len(req.Resources)+len(req.UploadIDs) > MaxCarouselSize {
+		panic(exception.FieldError{Field: "media", Message: "a post can hold at most 10 media"})
+	}
 
+	status := StatusPublished
+	if req.PublishAt != nil {
+		if req.Draft {
+			panic(exception.FieldError{Field: "publish_at", Message: "a draft can't have a publish time"})
+		}
+		if !req.PublishAt.After(time.Now()) {
+			panic(exception.FieldError{Field: "publish_at", Message: "publish time must be in the future"})
+		}
+		status = StatusScheduled
+	} else if req.Draft {
+		status = StatusDraft
+	}
+
+	var claimed []*upload.Claimed
+	res := func() *DetailResponse {
+		tx := app.WriteTx(ctx)
+		defer helper.TXCommitOrRollback(tx)
+
+		claimed = s.claimUploads(tx, req.UploadIDs, req.UserID)
+		for _, c := range claimed {
+			c.Resource.IndexInPost = len(req.Resources)
+			req.Resources = append(req.Resources, *c.Resource)
+		}
+		applyAltTexts(req.Resources, req.AltTexts)
+
+		return s.create(tx, req, status)
+	}()
+
+	// the uploads' files go only once the post that took them over is committed
+	s.uploadService.Release(claimed)
+	postsCreated.Inc(status)
+	return res
+}
+
+func (s *serviceImpl) create(tx *gorm.DB, req *CreateRequest, status string) *DetailResponse {
 	post := &Post{
-		ID:        uuid.NewV4().String(),
-		Caption:   req.Caption,
-		UserID:    req.UserID,
-		CreatedAt: time.Now(),
-		UpdatedAt: time.Now(),
+		ID:            uuid.NewV4().String(),
+		Caption:       req.Caption,
+		UserID:        req.UserID,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+		Status:        status,
+		PublishAt:     req.PublishAt,
+		ResourceCount: int64(len(req.Resources)),
+	}
+	loc := s.findOrCreateLocation(tx, req.Location)
+	if loc != nil {
+		post.LocationID = loc.ID
 	}
 	s.postRepository.Create(tx, post)
 
@@ -57,28 +173,49 @@ func (s *serviceImpl) Create(ctx context.Context, req *CreateRequest) *DetailRes
 		r.CreatedAt = time.Now()
 		r.PostID = post.ID
 		s.resourceRepository.Create(tx, &r)
+		for hash, path := range r.Hashes() {
+			s.blobRepository.Acquire(tx, hash, path)
+		}
 
-		resourcesResp = append(resourcesResp, resource.Response{
-			ShareURL: r.ShareURL,
-		})
+		resourcesResp = append(resourcesResp, r.ToResponse(req.UserID))
 	}
 
+	if post.IsPublished() {
+		var thumbnailPath string
+		if len(req.Resources) > 0 {
+			thumbnailPath = req.Resources[0].ThumbnailPath()
+		}
+		app.AfterCommit(tx, func() { s.searchService.IndexPost(post.ToDocument(thumbnailPath)) })
+	}
 	return &DetailResponse{
 		PostID:    post.ID,
 		Caption:   post.Caption,
 		Resources: resourcesResp,
+		Location:  loc.ToResponse(),
+		Status:    post.Status,
+		PublishAt: post.PublishAt,
 		CreatedAt: post.CreatedAt,
 		UpdatedAt: post.UpdatedAt,
 	}
 }
 
+func (s *serviceImpl) claimUploads(tx *gorm.DB, uploadIDs []string, userID string) []*upload.Claimed {
+	claimed := make([]*upload.Claimed, 0, len(uploadIDs))
+	for _, uploadID := range uploadIDs {
+		claimed = append(claimed, s.uploadService.Claim(tx, uploadID, userID))
+	}
+	return claimed
+}
+
 func (s *serviceImpl) Update(ctx context.Context, req *UpdateRequest) {
-	err := s.validate.Struct(&req)
+	err := s.validate.Struct(req)
 	if err != nil {
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
+	// deferred before the transaction so the cached post is dropped once the change is committed
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
 	fPost := s.postRepository.FindByPostID(tx, req.PostID)
@@ -90,21 +227,37 @@ func (s *serviceImpl) Update(ctx context.Context, req *UpdateRequest) {
 		panic(exception.NoAccessError{Message: "can't update other person post"})
 	}
 
+	// drafts aren't public yet so their edits aren't history
+	if fPost.IsPublished() && fPost.Caption != req.Caption {
+		s.postRepository.CreateRevision(tx, &Revision{
+			PostID:     fPost.ID,
+			Caption:    fPost.Caption,
+			ReplacedAt: time.Now(),
+		})
+	}
+
 	s.postRepository.Update(tx, &Post{
 		ID:        fPost.ID,
 		Caption:   req.Caption,
 		UserID:    fPost.UserID,
 		UpdatedAt: time.Now(),
 	})
+
+	fPost.Caption = req.Caption
+	if fPost.IsPublished() {
+		thumbnail, _ := s.resourceRepository.FindFirstByPostID(tx, fPost.ID)
+		app.AfterCommit(tx, func() { s.searchService.IndexPost(fPost.ToDocument(thumbnail.ThumbnailPath())) })
+	}
 }
 
 func (s *serviceImpl) Delete(ctx context.Context, req *DeleteRequest) {
-	err := s.validate.Struct(&req)
+	err := s.validate.Struct(req)
 	if err != nil {
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
 	fPost := s.postRepository.FindByPostID(tx, req.PostID)
@@ -117,59 +270,917 @@ func (s *serviceImpl) Delete(ctx context.Context, req *DeleteRequest) {
 	}
 
 	s.postRepository.Delete(tx, fPost.ID)
+	s.auditRepository.Append(tx, audit.NewEntry(req.Actor, audit.ActionPostDelete, audit.TargetPost, fPost.ID).
+		WithChanges(
+			map[string]interface{}{"user_id": fPost.UserID, "caption": fPost.Caption},
+			map[string]interface{}{},
+		))
+	app.AfterCommit(tx, func() { s.searchService.RemovePost(fPost.ID) })
 }
 
-func (s *serviceImpl) FindByPostID(ctx context.Context, postID, viewerID string) *DetailResponse {
-	tx := app.GetDB().WithContext(ctx).Begin()
+func (s *serviceImpl) Restore(ctx context.Context, req *RestoreRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	fPost := s.postRepository.FindWithDeleted(tx, req.PostID)
+	if fPost.ID == "" || !fPost.DeletedAt.Valid || !time.Now().Before(fPost.PurgeAt()) {
+		panic(exception.NotFoundError{Message: "post not found in recently deleted"})
+	}
+
+	if fPost.UserID != req.UserID {
+		panic(exception.NoAccessError{Message: "can't restore other person post"})
+	}
+
+	s.postRepository.Restore(tx, fPost.ID)
+	s.auditRepository.Append(tx, audit.NewEntry(req.Actor, audit.ActionPostRestore, audit.TargetPost, fPost.ID))
+
+	if fPost.IsPublished() {
+		thumbnail, _ := s.resourceRepository.FindFirstByPostID(tx, fPost.ID)
+		app.AfterCommit(tx, func() { s.searchService.IndexPost(fPost.ToDocument(thumbnail.ThumbnailPath())) })
+	}
+}
+
+func (s *serviceImpl) Schedule(ctx context.Context, req *ScheduleRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	status := StatusDraft
+	if req.PublishAt != nil {
+		if !req.PublishAt.After(time.Now()) {
+			panic(exception.FieldError{Field: "publish_at", Message: "publish time must be in the future"})
+		}
+		status = StatusScheduled
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	post := s.findOwnPost(tx, req.PostID, req.UserID)
+	if post.IsPublished() {
+		panic(exception.ConflictError{Message: "post is already published"})
+	}
+	s.postRepository.Schedule(tx, post.ID, status, req.PublishAt)
+}
+
+func (s *serviceImpl) Archive(ctx context.Context, req *ArchiveRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	post := s.findOwnPost(tx, req.PostID, req.UserID)
+	if !post.IsPublished() {
+		panic(exception.ConflictError{Message: "only published posts can be archived"})
+	}
+	if post.IsArchived == req.Archived {
+		return
+	}
+
+	s.postRepository.SetArchived(tx, post.ID, req.Archived)
+	if req.Archived {
+		app.AfterCommit(tx, func() { s.searchService.RemovePost(post.ID) })
+		return
+	}
+
+	thumbnail, _ := s.resourceRepository.FindFirstByPostID(tx, post.ID)
+	app.AfterCommit(tx, func() { s.searchService.IndexPost(post.ToDocument(thumbnail.ThumbnailPath())) })
+}
+
+func (s *serviceImpl) FindArchived(ctx context.Context, userID string) []*Response {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	posts := s.postRepository.FindArchived(tx, userID)
+	return s.toResponses(tx, posts, userID)
+}
+
+func (s *serviceImpl) Pin(ctx context.Context, req *PinRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	// concurrent pins of the same user would all see a free slot otherwise
+	s.userRepository.Lock(tx, req.UserID)
+	post := s.findOwnPost(tx, req.PostID, req.UserID)
+	if !req.Pinned {
+		s.postRepository.SetPinned(tx, post.ID, nil)
+		return
+	}
+
+	if !post.IsPublic() {
+		panic(exception.ConflictError{Message: "only posts shown on the profile can be pinned"})
+	}
+	if post.PinnedAt != nil {
+		return
+	}
+	if s.postRepository.CountPinned(tx, post.UserID) >= MaxPinned {
+		panic(exception.ConflictError{Message: "you can pin up to 3 posts, unpin one first"})
+	}
+
+	now := time.Now()
+	s.postRepository.SetPinned(tx, post.ID, &now)
+}
+
+func (s *serviceImpl) UpdateSettings(ctx context.Context, req *SettingsRequest) *SettingsResponse {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	post := s.findOwnPost(tx, req.PostID, req.UserID)
+	if req.CommentsDisabled != nil {
+		post.CommentsDisabled = *req.CommentsDisabled
+	}
+	if req.HideLikeCount != nil {
+		post.HideLikeCount = *req.HideLikeCount
+	}
+
+	s.postRepository.UpdateSettings(tx, post.ID, post.CommentsDisabled, post.HideLikeCount)
+	return &SettingsResponse{
+		CommentsDisabled: post.CommentsDisabled,
+		HideLikeCount:    post.HideLikeCount,
+	}
+}
+
+func (s *serviceImpl) Publish(ctx context.Context, req *PublishRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	post := func() *Post {
+		defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+		tx := app.WriteTx(ctx)
+		defer helper.TXCommitOrRollback(tx)
+
+		post := s.findOwnPost(tx, req.PostID, req.UserID)
+		if post.IsPublished() {
+			panic(exception.ConflictError{Message: "post is already published"})
+		}
+		if problem := s.publishProblem(tx, post); problem != "" {
+			panic(exception.ConflictError{Message: problem})
+		}
+
+		s.postRepository.Publish(tx, post.ID, time.Now())
+		return post
+	}()
+
+	s.indexPublished(ctx, post.ID)
+}
+
+func (s *serviceImpl) FindDrafts(ctx context.Context, userID string) []*DraftResponse {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	posts := s.postRepository.FindDrafts(tx, userID)
+	responses := s.toResponses(tx, posts, userID)
+
+	drafts := make([]*DraftResponse, 0, len(posts))
+	for i, p := range posts {
+		drafts = append(drafts, &DraftResponse{
+			Response:     *responses[i],
+			Status:       p.Status,
+			PublishAt:    p.PublishAt,
+			PublishError: p.PublishError,
+			UpdatedAt:    p.UpdatedAt,
+		})
+	}
+	return drafts
+}
+
+func (s *serviceImpl) PublishDue(ctx context.Context) {
+	for {
+		due := func() []*Post {
+			tx := app.WriteTx(ctx)
+			defer helper.TXCommitOrRollback(tx)
+
+			return s.postRepository.FindDue(tx, time.Now(), publishBatchSize)
+		}()
+
+		for _, p := range due {
+			s.publishScheduled(ctx, p.ID)
+		}
+
+		if len(due) < publishBatchSize {
+			break
+		}
+	}
+
+	// the index is in memory, so every instance picks up what was published since its last run,
+	// whichever instance published it. The window overlaps the last one by an interval so a
+	// publish that committed late is still seen, indexing a post again is harmless.
+	now := time.Now()
+	s.index(ctx, s.searchService, s.indexedSince.Add(-PublishInterval))
+	s.indexedSince = now
+}
+
+// publishScheduled locks the post and publishes it only if it's still due, so when instances race
+// for the same post the loser finds it already published.
+func (s *serviceImpl) publishScheduled(ctx context.Context, postID string) {
+	defer s.cache.Invalidate(ctx, cache.PostKey(postID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	now := time.Now()
+	post := s.postRepository.FindForUpdate(tx, postID)
+	if post.ID == "" || post.Status != StatusScheduled || post.PublishAt == nil || post.PublishAt.After(now) {
+		return
+	}
+
+	if problem := s.publishProblem(tx, post); problem != "" {
+		s.postRepository.MarkPublishFailed(tx, post.ID, problem)
+		s.notificationRepository.Create(tx, &notification.Notification{
+			ID:        uuid.NewV4().String(),
+			UserID:    post.UserID,
+			Type:      notification.TypePostPublishFailed,
+			TargetID:  post.ID,
+			Message:   "your scheduled post couldn't be published: " + problem,
+			CreatedAt: now,
+		})
+		return
+	}
+
+	s.postRepository.Publish(tx, post.ID, now)
+}
+
+// publishProblem says why the post can't go out, or returns "" when it can.
+func (s *serviceImpl) publishProblem(tx *gorm.DB, post *Post) string {
+	owner := s.userRepository.FindById(tx, post.UserID)
+	if owner.ID == "" || owner.IsSuspended {
+		return "the account is suspended"
+	}
+
+	resources := s.resourceRepository.FindByPostID(tx, post.ID)
+	if len(resources) == 0 {
+		return "the post has no media"
+	}
+	for _, r := range resources {
+		obj, err := app.GetStorage().Open(r.Path)
+		if err != nil {
+			return "some media of the post is missing"
+		}
+		obj.Close()
+	}
+	return ""
+}
+
+func (s *serviceImpl) indexPublished(ctx context.Context, postID string) {
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
 	post := s.postRepository.FindByPostID(tx, postID)
-	if post.ID == "" {
+	thumbnail, _ := s.resourceRepository.FindFirstByPostID(tx, postID)
+	s.searchService.IndexPost(post.ToDocument(thumbnail.ThumbnailPath()))
+}
+
+func (s *serviceImpl) FindDeleted(ctx context.Context, userID string) []*DeletedResponse {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	posts := s.postRepository.FindDeletedByUserID(tx, userID, time.Now().Add(-RestoreWindow))
+	responses := s.toResponses(tx, posts, userID)
+
+	deleted := make([]*DeletedResponse, 0, len(posts))
+	for i, p := range posts {
+		deleted = append(deleted, &DeletedResponse{
+			Response:  *responses[i],
+			DeletedAt: p.DeletedAt.Time,
+			PurgeAt:   p.PurgeAt(),
+		})
+	}
+	return deleted
+}
+
+func (s *serviceImpl) Purge(ctx context.Context) {
+	for {
+		purged := func() int {
+			tx := app.WriteTx(ctx)
+			defer helper.TXCommitOrRollback(tx)
+
+			posts := s.postRepository.FindPurgeable(tx, time.Now().Add(-RestoreWindow), purgeBatchSize)
+			for _, p := range posts {
+				// blobs are only released here, the media GC deletes the files nobody references anymore
+				for _, r := range s.resourceRepository.FindByPostID(tx, p.ID) {
+					s.resourceRepository.Delete(tx, r)
+					for hash := range r.Hashes() {
+						s.blobRepository.Release(tx, hash)
+					}
+				}
+				s.likeRepository.DeleteByPostID(tx, p.ID)
+				s.commentRepository.DeleteByPostID(tx, p.ID)
+				s.tagRepository.DeleteByPostID(tx, p.ID)
+				s.postRepository.DeleteRevisions(tx, p.ID)
+				s.postRepository.Purge(tx, p.ID)
+			}
+			return len(posts)
+		}()
+
+		if purged < purgeBatchSize {
+			return
+		}
+	}
+}
+
+// FindRevisions shows the caption history to whoever can see the post, drafts and hidden posts only to their owner.
+func (s *serviceImpl) FindRevisions(ctx context.Context, postID, viewerID string) []*RevisionResponse {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	post := s.postRepository.FindByPostID(tx, postID)
+	if post.ID == "" || (post.UserID != viewerID && !s.postRepository.CanSee(tx, postID, viewerID)) {
 		panic(exception.NotFoundError{Message: "post not found"})
 	}
 
-	var resResponse []resource.Response
-	res := s.resourceRepository.FindByPostID(tx, postID)
-	for _, r := range res {
-		resResponse = append(resResponse, resource.Response{
-			ShareURL: r.ShareURL,
+	revisions := s.postRepository.FindRevisions(tx, postID)
+	response := make([]*RevisionResponse, 0, len(revisions))
+	for _, r := range revisions {
+		response = append(response, &RevisionResponse{
+			Caption:    r.Caption,
+			ReplacedAt: r.ReplacedAt,
 		})
 	}
+	return response
+}
+
+func (s *serviceImpl) Reorder(ctx context.Context, req *ReorderRequest) []resource.Response {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	post := s.findOwnPost(tx, req.PostID, req.UserID)
+	resources := s.resourceRepository.FindByPostID(tx, post.ID)
+
+	byID := map[string]*resource.Resource{}
+	for _, r := range resources {
+		byID[r.ID] = r
+	}
+	if len(req.ResourceIDs) != len(resources) {
+		panic(exception.FieldError{Field: "resource_ids", Message: "every media of the post has to be listed once"})
+	}
+
+	ordered := make([]*resource.Resource, 0, len(resources))
+	for i, id := range req.ResourceIDs {
+		r, ok := byID[id]
+		if !ok {
+			panic(exception.FieldError{Field: "resource_ids", Message: "every media of the post has to be listed once"})
+		}
+		delete(byID, id)
 
-	likesCount, hasViewerLiked := s.likeRepository.CountByPostID(tx, postID, viewerID)
-	commentsCount := s.commentRepository.CountByPostID(tx, postID)
+		if r.IndexInPost != i {
+			s.resourceRepository.UpdateIndex(tx, r.ID, i)
+			r.IndexInPost = i
+		}
+		ordered = append(ordered, r)
+	}
+
+	s.touch(tx, post, ordered)
+	return toResourceResponses(ordered, req.UserID)
+}
+
+func (s *serviceImpl) RemoveResource(ctx context.Context, req *RemoveResourceRequest) []resource.Response {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	post := s.findOwnPost(tx, req.PostID, req.UserID)
+	resources := s.resourceRepository.FindByPostID(tx, post.ID)
+
+	var removed *resource.Resource
+	remaining := make([]*resource.Resource, 0, len(resources))
+	for _, r := range resources {
+		if r.ID == req.ResourceID {
+			removed = r
+			continue
+		}
+		remaining = append(remaining, r)
+	}
+
+	if removed == nil {
+		panic(exception.NotFoundError{Message: "resource not found"})
+	}
+	if len(remaining) == 0 {
+		panic(exception.FieldError{Field: "resource_id", Message: "a post needs at least one media"})
+	}
+
+	s.resourceRepository.Delete(tx, removed)
+	s.postRepository.SetResourceCount(tx, post.ID, len(remaining))
+	s.tagRepository.DeleteByResourceID(tx, removed.ID)
+	for hash := range removed.Hashes() {
+		s.blobRepository.Release(tx, hash)
+	}
+
+	// close the gap so indexes stay 0..n-1
+	for i, r := range remaining {
+		if r.IndexInPost != i {
+			s.resourceRepository.UpdateIndex(tx, r.ID, i)
+			r.IndexInPost = i
+		}
+	}
+
+	s.touch(tx, post, remaining)
+	return toResourceResponses(remaining, req.UserID)
+}
+
+func (s *serviceImpl) AppendResources(ctx context.Context, req *AppendResourcesRequest) []resource.Response {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	if len(req.Resources)+len(req.UploadIDs) == 0 {
+		panic(exception.FieldError{Field: "media", Message: "media is required"})
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+
+	var claimed []*upload.Claimed
+	res := func() []resource.Response {
+		tx := app.WriteTx(ctx)
+		defer helper.TXCommitOrRollback(tx)
+
+		post := s.findOwnPost(tx, req.PostID, req.UserID)
+		resources := s.resourceRepository.FindByPostID(tx, post.ID)
+		if len(resources)+len(req.Resources)+len(req.UploadIDs) > MaxCarouselSize {
+			panic(exception.FieldError{Field: "media", Message: "a post can hold at most 10 media"})
+		}
+
+		// uploads are claimed only once the post is known to have room for them
+		claimed = s.claimUploads(tx, req.UploadIDs, req.UserID)
+		for _, c := range claimed {
+			req.Resources = append(req.Resources, *c.Resource)
+		}
+		applyAltTexts(req.Resources, req.AltTexts)
+
+		for i := range req.Resources {
+			r := req.Resources[i]
+			r.ID = uuid.NewV4().String()
+			r.CreatedAt = time.Now()
+			r.PostID = post.ID
+			r.IndexInPost = len(resources)
+			s.resourceRepository.Create(tx, &r)
+			for hash, path := range r.Hashes() {
+				s.blobRepository.Acquire(tx, hash, path)
+			}
+			resources = append(resources, &r)
+		}
+		s.postRepository.SetResourceCount(tx, post.ID, len(resources))
+
+		s.touch(tx, post, resources)
+		return toResourceResponses(resources, req.UserID)
+	}()
+
+	s.uploadService.Release(claimed)
+	return res
+}
+
+func (s *serviceImpl) UpdateResource(ctx context.Context, req *UpdateResourceRequest) resource.Response {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	post := s.findOwnPost(tx, req.PostID, req.UserID)
+	r := s.resourceRepository.FindByResourceID(tx, req.ResourceID)
+	if r.ID == "" || r.PostID != post.ID {
+		panic(exception.NotFoundError{Message: "resource not found"})
+	}
+
+	now := time.Now()
+	tags := make([]*tag.Tag, 0, len(req.Tags))
+	seen := map[string]bool{}
+	for _, t := range req.Tags {
+		if seen[t.UserID] {
+			panic(exception.FieldError{Field: "tags", Message: "a user can only be tagged once per media"})
+		}
+		seen[t.UserID] = true
+
+		tagged := s.userRepository.FindById(tx, t.UserID)
+		if tagged.ID == "" || tagged.IsSuspended {
+			panic(exception.FieldError{Field: "tags", Message: "tagged user not found"})
+		}
+		if s.relationRepository.IsBlocked(tx, post.UserID, t.UserID) {
+			panic(exception.FieldError{Field: "tags", Message: "can't tag this user"})
+		}
+
+		tags = append(tags, &tag.Tag{
+			ResourceID: r.ID,
+			UserID:     tagged.ID,
+			PostID:     post.ID,
+			X:          t.X,
+			Y:          t.Y,
+			CreatedAt:  now,
+			Username:   tagged.Username,
+		})
+	}
+
+	s.tagRepository.ReplaceForResource(tx, r.ID, tags)
+	s.resourceRepository.UpdateAltText(tx, r.ID, req.AltText)
+	s.postRepository.Touch(tx, post.ID, now)
+
+	r.AltText = req.AltText
+	response := r.ToResponse(req.UserID)
+	for _, t := range tags {
+		response.Tags = append(response.Tags, t.ToResponse())
+	}
+	return response
+}
+
+func (s *serviceImpl) RemoveTag(ctx context.Context, req *RemoveTagRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	if s.tagRepository.DeleteByPostAndUser(tx, req.PostID, req.UserID) == 0 {
+		panic(exception.NotFoundError{Message: "you aren't tagged in this post"})
+	}
+}
+
+func (s *serviceImpl) SetLocation(ctx context.Context, req *SetLocationRequest) *location.Response {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	defer s.cache.Invalidate(ctx, cache.PostKey(req.PostID))
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	post := s.findOwnPost(tx, req.PostID, req.UserID)
+	loc := s.findOrCreateLocation(tx, req.Location)
+
+	var locationID string
+	if loc != nil {
+		locationID = loc.ID
+	}
+	s.postRepository.SetLocation(tx, post.ID, locationID)
+	s.postRepository.Touch(tx, post.ID, time.Now())
+	return loc.ToResponse()
+}
+
+func (s *serviceImpl) FindByPostID(ctx context.Context, postID, viewerID string) *DetailResponse {
+	var cached cachedPost
+	s.cache.Fetch(ctx, cache.PostKey(postID), CacheTTL, &cached, func(ctx context.Context) interface{} {
+		tx := app.ReadPrimaryTx(ctx)
+		defer helper.TXCommitOrRollback(tx)
+
+		return s.loadPost(tx, postID)
+	})
+
+	// the cached post is shared by every viewer, so whether this one may see it is checked on each read
+	post := cached.Post
+	if post.UserID != viewerID && !s.canSee(ctx, postID, viewerID) {
+		panic(exception.NotFoundError{Message: "post not found"})
+	}
+
+	var counts cachedCounts
+	s.cache.Fetch(ctx, cache.PostCountsKey(postID), CacheTTL, &counts, func(ctx context.Context) interface{} {
+		tx := app.ReadPrimaryTx(ctx)
+		defer helper.TXCommitOrRollback(tx)
+
+		return &cachedCounts{
+			ReactionCounts: s.likeRepository.CountReactionsByPostID(tx, postID),
+			// the counter is kept with every comment, counting the rows would scan them all
+			CommentsCount: s.postRepository.FindByPostID(tx, postID).CommentsCount,
+		}
+	})
+
+	viewerReaction := func() string {
+		tx := app.ReadTx(ctx)
+		defer helper.TXCommitOrRollback(tx)
+
+		return s.likeRepository.FindByPostIDAndUserID(tx, postID, viewerID).Reaction
+	}()
+
+	tagsByResource := map[string][]tag.Response{}
+	for _, t := range cached.Tags {
+		tagsByResource[t.ResourceID] = append(tagsByResource[t.ResourceID], t.ToResponse())
+	}
+
+	var resResponse []resource.Response
+	for _, r := range cached.Resources {
+		response := r.ToResponse(viewerID)
+		response.Tags = tagsByResource[r.ID]
+		resResponse = append(resResponse, response)
+	}
+
+	reactionCounts, likesCount := like.CountReactions(counts.ReactionCounts)
+	likesCount, likeCountHidden := post.LikeCountFor(viewerID, likesCount)
+	if likeCountHidden {
+		reactionCounts = nil
+	}
 	return &DetailResponse{
-		PostID:         post.ID,
-		Caption:        post.Caption,
-		Resources:      resResponse,
-		LikesCount:     likesCount,
-		ViewerHasLiked: hasViewerLiked,
-		CommentsCount:  commentsCount,
-		CreatedAt:      post.CreatedAt,
-		UpdatedAt:      post.UpdatedAt,
+		PostID:           post.ID,
+		Caption:          post.Caption,
+		Resources:        resResponse,
+		LikesCount:       likesCount,
+		LikeCountHidden:  likeCountHidden,
+		ReactionCounts:   reactionCounts,
+		ViewerHasLiked:   viewerReaction != "",
+		ViewerReaction:   viewerReaction,
+		CommentsDisabled: post.CommentsDisabled,
+		IsArchived:       post.IsArchived,
+		IsPinned:         post.PinnedAt != nil,
+		CommentsCount:    counts.CommentsCount,
+		Location:         cached.Location.ToResponse(),
+		IsEdited:         cached.IsEdited,
+		Status:           post.Status,
+		PublishAt:        post.PublishAt,
+		PublishError:     post.PublishError,
+		CreatedAt:        post.CreatedAt,
+		UpdatedAt:        post.UpdatedAt,
+	}
+}
+
+// cachedPost is what the detail of a post shows the same way to every viewer.
+type cachedPost struct {
+	Post      *Post
+	Resources []*resource.Resource
+	Tags      []*tag.Tag
+	Location  *location.Location
+	IsEdited  bool
+}
+
+// cachedCounts are kept apart from cachedPost since likes and comments change far more often.
+type cachedCounts struct {
+	ReactionCounts map[string]int64
+	CommentsCount  int64
+}
+
+func (s *serviceImpl) loadPost(tx *gorm.DB, postID string) *cachedPost {
+	post := s.postRepository.FindByPostID(tx, postID)
+	if post.ID == "" {
+		panic(exception.NotFoundError{Message: "post not found"})
+	}
+
+	var loc *location.Location
+	if post.LocationID != "" {
+		loc = s.locationRepository.FindByLocationID(tx, post.LocationID)
+	}
+
+	return &cachedPost{
+		Post:      post,
+		Resources: s.resourceRepository.FindByPostID(tx, postID),
+		Tags:      s.tagRepository.FindByPostID(tx, postID),
+		Location:  loc,
+		IsEdited:  s.postRepository.CountRevisions(tx, postID) > 0,
+	}
+}
+
+func (s *serviceImpl) canSee(ctx context.Context, postID, viewerID string) bool {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	return s.postRepository.CanSee(tx, postID, viewerID)
+}
+
+func (s *serviceImpl) FindByUserID(ctx context.Context, userID, viewerID string) []*Response {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	posts := s.postRepository.FindByUserID(tx, userID, viewerID)
+	return s.toResponses(tx, posts, viewerID)
+}
+
+func (s *serviceImpl) FindTagged(ctx context.Context, userID, viewerID string) []*Response {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	posts := s.postRepository.FindTagged(tx, userID, viewerID)
+	return s.toResponses(tx, posts, viewerID)
+}
+
+func (s *serviceImpl) FindByLocationID(ctx context.Context, req *FindByLocationRequest) *LocationResponse {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultLocationLimit
+	}
+	if req.Before.IsZero() {
+		req.Before = time.Now()
 	}
+
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	loc := s.locationRepository.FindByLocationID(tx, req.LocationID)
+	if loc.ID == "" {
+		panic(exception.NotFoundError{Message: "location not found"})
+	}
+
+	posts := s.postRepository.FindByLocationID(tx, loc.ID, req.ViewerID, req.Before, req.Limit)
+	response := &LocationResponse{
+		Location: loc.ToResponse(),
+		Posts:    s.toResponses(tx, posts, req.ViewerID),
+	}
+	if len(posts) == req.Limit {
+		response.NextBefore = &posts[len(posts)-1].CreatedAt
+	}
+	return response
+}
+
+// Reindex loads every published post into index, see search.Service.Rebuild.
+func (s *serviceImpl) Reindex(ctx context.Context, index search.Service) {
+	s.index(ctx, index, time.Time{})
 }
 
-func (s *serviceImpl) FindByUserID(ctx context.Context, userID string) []*Response {
-	tx := app.GetDB().WithContext(ctx).Begin()
+// index loads the posts published since since into index.
+func (s *serviceImpl) index(ctx context.Context, index search.Service, since time.Time) {
+	tx := app.ReadTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
+	for offset := 0; ; offset += reindexBatchSize {
+		posts := s.postRepository.FindAll(tx, since, offset, reindexBatchSize)
+		thumbnails := s.resourceRepository.FindFirstByPostIDs(tx, postIDs(posts))
+		for _, p := range posts {
+			var thumbnailPath string
+			if thumbnail, ok := thumbnails[p.ID]; ok {
+				thumbnailPath = thumbnail.ThumbnailPath()
+			}
+			index.IndexPost(p.ToDocument(thumbnailPath))
+		}
+
+		if len(posts) < reindexBatchSize {
+			return
+		}
+	}
+}
+
+func (s *serviceImpl) ReconcileCounters(ctx context.Context) int {
+	drifted := 0
+	afterID := ""
+	for {
+		counters := func() []*Counters {
+			tx := app.WriteTx(ctx)
+			defer helper.TXCommitOrRollback(tx)
+
+			counters := s.postRepository.FindCounters(tx, afterID, reconcileBatchSize)
+			for _, c := range counters {
+				if !c.HasDrift() {
+					continue
+				}
+				app.Logger(ctx).Warn("post counters drifted", "post_id", c.PostID,
+					"likes", c.LikesCount, "actual_likes", c.ActualLikes,
+					"comments", c.CommentsCount, "actual_comments", c.ActualComments,
+					"resources", c.ResourceCount, "actual_resources", c.ActualResources)
+				s.postRepository.Recount(tx, c.PostID)
+				drifted++
+			}
+			return counters
+		}()
+
+		if len(counters) < reconcileBatchSize {
+			break
+		}
+		afterID = counters[len(counters)-1].PostID
+	}
+
+	if drifted > 0 {
+		app.Logger(ctx).Info("reconciled post counters", "posts", drifted)
+	}
+	return drifted
+}
+
+func (s *serviceImpl) findOwnPost(tx *gorm.DB, postID, userID string) *Post {
+	post := s.postRepository.FindForUpdate(tx, postID)
+	if post.ID == "" {
+		panic(exception.NotFoundError{Message: "post not found"})
+	}
+	if post.UserID != userID {
+		panic(exception.NoAccessError{Message: "can't update other person post"})
+	}
+	return post
+}
+
+// touch bumps UpdatedAt after a carousel edit and refreshes the thumbnail in search, it may have changed.
+func (s *serviceImpl) touch(tx *gorm.DB, post *Post, resources []*resource.Resource) {
+	post.UpdatedAt = time.Now()
+	s.postRepository.Touch(tx, post.ID, post.UpdatedAt)
+	if post.IsPublished() {
+		var thumbnailPath string
+		if len(resources) > 0 {
+			thumbnailPath = resources[0].ThumbnailPath()
+		}
+		app.AfterCommit(tx, func() { s.searchService.IndexPost(post.ToDocument(thumbnailPath)) })
+	}
+}
+
+func toResourceResponses(resources []*resource.Resource, viewerID string) []resource.Response {
+	response := make([]resource.Response, 0, len(resources))
+	for _, r := range resources {
+		response = append(response, r.ToResponse(viewerID))
+	}
+	return response
+}
+
+// toResponses reads the counters off the posts and loads every thumbnail in a single query.
+func (s *serviceImpl) toResponses(tx *gorm.DB, posts []*Post, viewerID string) []*Response {
+	thumbnails := s.resourceRepository.FindFirstByPostIDs(tx, postIDs(posts))
+
 	var response []*Response
-	posts := s.postRepository.FindByUserID(tx, userID)
 	for _, p := range posts {
-		res, resCount := s.resourceRepository.FindFirstByPostID(tx, p.ID)
-		resourceResp := &resource.Response{ShareURL: res.ShareURL}
-
-		likesCount, _ := s.likeRepository.CountByPostID(tx, p.ID, userID)
-		commentsCount := s.commentRepository.CountByPostID(tx, p.ID)
+		var thumbnail *resource.Response
+		if first, ok := thumbnails[p.ID]; ok {
+			thumbnail = first.ToThumbnail(viewerID)
+		}
+		likesCount, likeCountHidden := p.LikeCountFor(viewerID, p.LikesCount)
 
 		response = append(response, &Response{
-			PostID:        p.ID,
-			Thumbnail:     resourceResp,
-			ResourceCount: resCount,
-			LikesCount:    likesCount,
-			CommentsCount: commentsCount,
+			PostID:          p.ID,
+			Thumbnail:       thumbnail,
+			ResourceCount:   p.ResourceCount,
+			LikesCount:      likesCount,
+			CommentsCount:   p.CommentsCount,
+			LikeCountHidden: likeCountHidden,
+			IsPinned:        p.PinnedAt != nil,
 		})
 	}
 	return response
 }
+
+func postIDs(posts []*Post) []string {
+	ids := make([]string, len(posts))
+	for i, p := range posts {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// findOrCreateLocation reuses the location with the same normalized place, nil req means no location.
+func (s *serviceImpl) findOrCreateLocation(tx *gorm.DB, req *location.Request) *location.Location {
+	if req == nil {
+		return nil
+	}
+
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	name, latitude, longitude := location.Normalize(req.Name, req.Latitude, req.Longitude)
+	loc := s.locationRepository.FindByPlace(tx, name, latitude, longitude)
+	if loc.ID != "" {
+		return loc
+	}
+
+	loc = &location.Location{
+		ID:        uuid.NewV4().String(),
+		Name:      name,
+		Latitude:  latitude,
+		Longitude: longitude,
+		CreatedAt: time.Now(),
+	}
+	s.locationRepository.Create(tx, loc)
+	return loc
+}
+
+func applyAltTexts(resources []resource.Resource, altTexts []string) {
+	for i := range resources {
+		if i < len(altTexts) {
+			resources[i].AltText = altTexts[i]
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package post_test
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"go-api/app"
+	"go-api/cache"
+	"go-api/model/audit"
+	"go-api/model/blob"
+	"go-api/model/comment"
+	"go-api/model/like"
+	"go-api/model/location"
+	"go-api/model/notification"
+	"go-api/model/post"
+	"go-api/model/relation"
+	"go-api/model/resource"
+	"go-api/model/search"
+	"go-api/model/tag"
+	"go-api/model/upload"
+	"go-api/model/user"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPublishDue(t *testing.T) {
+	app.TestDBInit()
+	app.InitStorage(t.TempDir())
+	postService := post.NewService(validator.New(), post.NewRepository(), resource.NewRepository(), like.NewRepository(), comment.NewRepository(), audit.NewRepository(), search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer()), upload.NewService(validator.New(), upload.NewRepository()), blob.NewRepository(), tag.NewRepository(), location.NewRepository(), user.NewRepository(), relation.NewRepository(), notification.NewRepository(), cache.NewLoader(cache.NewLRU(100)))
+
+	now := time.Now()
+	due := now.Add(-time.Minute)
+	later := now.Add(time.Hour)
+	ownerID := uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&user.User{ID: ownerID, Email: ownerID + "@example.com", Username: ownerID, DisplayName: ownerID, Role: user.RoleUser, CreatedAt: now, UpdatedAt: now}).Error)
+
+	mediaPath := "posts/" + ownerID + "/scheduled.jpg"
+	assert.NoError(t, app.GetStorage().Save(mediaPath, bytes.NewReader([]byte("image bytes"))))
+
+	readyID, emptyID, futureID := uuid.NewV4().String(), uuid.NewV4().String(), uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&post.Post{ID: readyID, UserID: ownerID, Status: post.StatusScheduled, PublishAt: &due, CreatedAt: now, UpdatedAt: now}).Error)
+	assert.Nil(t, app.DB.Create(&post.Post{ID: emptyID, UserID: ownerID, Status: post.StatusScheduled, PublishAt: &due, CreatedAt: now, UpdatedAt: now}).Error)
+	assert.Nil(t, app.DB.Create(&post.Post{ID: futureID, UserID: ownerID, Status: post.StatusScheduled, PublishAt: &later, CreatedAt: now, UpdatedAt: now}).Error)
+	assert.Nil(t, app.DB.Create(&resource.Resource{ID: uuid.NewV4().String(), PostID: readyID, Path: mediaPath, MediaType: resource.MediaImage, CreatedAt: now}).Error)
+
+	failures := func(postID string) int64 {
+		var count int64
+		assert.Nil(t, app.DB.Model(&notification.Notification{}).
+			Where("user_id = ? AND type = ? AND target_id = ?", ownerID, notification.TypePostPublishFailed, postID).
+			Count(&count).Error)
+		return count
+	}
+
+	// every instance runs the scheduler, they race for the same posts
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			postService.PublishDue(context.Background())
+		}()
+	}
+	wg.Wait()
+
+	t.Run("due post should be published exactly once", func(t *testing.T) {
+		published := post.NewRepository().FindByPostID(app.DB, readyID)
+		assert.Equal(t, post.StatusPublished, published.Status)
+		assert.Nil(t, published.PublishAt)
+
+		postService.PublishDue(context.Background())
+		assert.Equal(t, published.CreatedAt, post.NewRepository().FindByPostID(app.DB, readyID).CreatedAt)
+	})
+
+	t.Run("post that can't go out should fail and notify its owner once", func(t *testing.T) {
+		failed := post.NewRepository().FindByPostID(app.DB, emptyID)
+		assert.Equal(t, post.StatusFailed, failed.Status)
+		assert.Equal(t, "the post has no media", failed.PublishError)
+		assert.Equal(t, int64(1), failures(emptyID))
+	})
+
+	t.Run("post that isn't due should wait", func(t *testing.T) {
+		assert.Equal(t, post.StatusScheduled, post.NewRepository().FindByPostID(app.DB, futureID).Status)
+	})
+
+	t.Run("rescheduled failed post should be published when its time comes", func(t *testing.T) {
+		assert.Nil(t, app.DB.Create(&resource.Resource{ID: uuid.NewV4().String(), PostID: emptyID, Path: mediaPath, MediaType: resource.MediaImage, CreatedAt: now}).Error)
+		postService.Schedule(context.Background(), &post.ScheduleRequest{PostID: emptyID, UserID: ownerID, PublishAt: &later})
+
+		rescheduled := post.NewRepository().FindByPostID(app.DB, emptyID)
+		assert.Equal(t, post.StatusScheduled, rescheduled.Status)
+		assert.Empty(t, rescheduled.PublishError)
+
+		// the scheduled time comes
+		assert.Nil(t, app.DB.Model(&post.Post{}).Where("post_id = ?", emptyID).Update("publish_at", due).Error)
+		postService.PublishDue(context.Background())
+		assert.Equal(t, post.StatusPublished, post.NewRepository().FindByPostID(app.DB, emptyID).Status)
+		assert.Equal(t, int64(1), failures(emptyID))
+	})
+}
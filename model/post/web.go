@@ -1,7 +1,10 @@
 package post
 
 import (
+	"go-api/model/audit"
+	"go-api/model/location"
 	"go-api/model/resource"
+	"go-api/model/tag"
 	"time"
 )
 
@@ -9,7 +12,15 @@ type (
 	CreateRequest struct {
 		Caption   string              `json:"caption" form:"caption"`
 		Resources []resource.Resource `json:"resources"`
-		UserID    string              `json:"user_id"`
+		// UploadIDs are completed resumable uploads, appended after Resources.
+		UploadIDs []string `validate:"max=10" json:"upload_ids" form:"upload_ids[]"`
+		// AltTexts describe the media in order, multipart files first and then uploads.
+		AltTexts []string          `validate:"max=10,dive,max=1000" json:"alt_texts" form:"alt_texts[]"`
+		Location *location.Request `json:"location"`
+		// Draft keeps the post to its owner, PublishAt schedules it instead of publishing right away.
+		Draft     bool       `json:"draft" form:"draft"`
+		PublishAt *time.Time `json:"publish_at" form:"publish_at" time_format:"2006-01-02T15:04:05Z07:00"`
+		UserID    string     `json:"user_id"`
 	}
 
 	FindByPostIDRequest struct {
@@ -23,7 +34,98 @@ type (
 		UserID  string `json:"user_id"`
 	}
 
+	// ScheduleRequest turns the post back into a draft when PublishAt is nil.
+	ScheduleRequest struct {
+		PostID    string     `validate:"required" json:"post_id"`
+		UserID    string     `validate:"required" json:"user_id"`
+		PublishAt *time.Time `json:"publish_at"`
+	}
+
+	PublishRequest struct {
+		PostID string `validate:"required" json:"post_id"`
+		UserID string `validate:"required" json:"user_id"`
+	}
+
+	ArchiveRequest struct {
+		PostID   string `validate:"required" json:"post_id"`
+		UserID   string `validate:"required" json:"user_id"`
+		Archived bool   `json:"archived"`
+	}
+
+	PinRequest struct {
+		PostID string `validate:"required" json:"post_id"`
+		UserID string `validate:"required" json:"user_id"`
+		Pinned bool   `json:"pinned"`
+	}
+
+	// SettingsRequest leaves a setting unchanged when it's nil.
+	SettingsRequest struct {
+		PostID           string `validate:"required" json:"post_id"`
+		UserID           string `validate:"required" json:"user_id"`
+		CommentsDisabled *bool  `json:"comments_disabled"`
+		HideLikeCount    *bool  `json:"hide_like_count"`
+	}
+
+	SettingsResponse struct {
+		CommentsDisabled bool `json:"comments_disabled"`
+		HideLikeCount    bool `json:"hide_like_count"`
+	}
+
+	RestoreRequest struct {
+		audit.Actor
+		PostID string `validate:"required" json:"post_id"`
+		UserID string `validate:"required" json:"user_id"`
+	}
+
+	ReorderRequest struct {
+		PostID      string   `validate:"required" json:"post_id"`
+		UserID      string   `validate:"required" json:"user_id"`
+		ResourceIDs []string `validate:"required,min=1" json:"resource_ids"`
+	}
+
+	RemoveResourceRequest struct {
+		PostID     string `validate:"required" json:"post_id"`
+		ResourceID string `validate:"required" json:"resource_id"`
+		UserID     string `validate:"required" json:"user_id"`
+	}
+
+	AppendResourcesRequest struct {
+		PostID    string              `validate:"required" json:"post_id"`
+		UserID    string              `validate:"required" json:"user_id"`
+		Resources []resource.Resource `json:"resources"`
+		UploadIDs []string            `validate:"max=10" json:"upload_ids" form:"upload_ids[]"`
+		AltTexts  []string            `validate:"max=10,dive,max=1000" json:"alt_texts" form:"alt_texts[]"`
+	}
+
+	UpdateResourceRequest struct {
+		PostID     string        `validate:"required" json:"post_id"`
+		ResourceID string        `validate:"required" json:"resource_id"`
+		UserID     string        `validate:"required" json:"user_id"`
+		AltText    string        `validate:"max=1000" json:"alt_text"`
+		Tags       []tag.Request `validate:"max=20,dive" json:"tags"`
+	}
+
+	RemoveTagRequest struct {
+		PostID string `validate:"required" json:"post_id"`
+		UserID string `validate:"required" json:"user_id"`
+	}
+
+	// SetLocationRequest clears the location of the post when Location is nil.
+	SetLocationRequest struct {
+		PostID   string            `validate:"required" json:"post_id"`
+		UserID   string            `validate:"required" json:"user_id"`
+		Location *location.Request `json:"location"`
+	}
+
+	FindByLocationRequest struct {
+		LocationID string    `validate:"required" json:"location_id"`
+		Before     time.Time `form:"before" time_format:"2006-01-02T15:04:05Z07:00" json:"before"`
+		Limit      int       `validate:"min=0,max=50" form:"limit" json:"limit"`
+		ViewerID   string    `json:"-"`
+	}
+
 	DeleteRequest struct {
+		audit.Actor
 		PostID string `validate:"required" json:"post_id"`
 		UserID string `validate:"required" json:"user_id"`
 	}
@@ -34,16 +136,55 @@ type (
 		ResourceCount int64              `json:"resource_count"`
 		LikesCount    int64              `json:"likes_count"`
 		CommentsCount int64              `json:"comments_count"`
+		// LikeCountHidden is set when the owner hid the count, LikesCount is 0 then.
+		LikeCountHidden bool `json:"like_count_hidden,omitempty"`
+		IsPinned        bool `json:"is_pinned,omitempty"`
 	}
 
 	DetailResponse struct {
-		PostID         string              `json:"post_id"`
-		Caption        string              `json:"caption"`
-		Resources      []resource.Response `json:"resources"`
-		LikesCount     int64               `json:"likes_count"`
-		ViewerHasLiked bool                `json:"viewer_has_liked"`
-		CommentsCount  int64               `json:"comments_count"`
-		CreatedAt      time.Time           `json:"created_at"`
-		UpdatedAt      time.Time           `json:"updated_at"`
+		PostID           string              `json:"post_id"`
+		Caption          string              `json:"caption"`
+		Resources        []resource.Response `json:"resources"`
+		LikesCount       int64               `json:"likes_count"`
+		LikeCountHidden  bool                `json:"like_count_hidden"`
+		ReactionCounts   map[string]int64    `json:"reaction_counts,omitempty"`
+		ViewerHasLiked   bool                `json:"viewer_has_liked"`
+		ViewerReaction   string              `json:"viewer_reaction,omitempty"`
+		CommentsDisabled bool                `json:"comments_disabled"`
+		IsArchived       bool                `json:"is_archived"`
+		IsPinned         bool                `json:"is_pinned"`
+		CommentsCount    int64               `json:"comments_count"`
+		Location         *location.Response  `json:"location,omitempty"`
+		IsEdited         bool                `json:"is_edited"`
+		Status           string              `json:"status"`
+		PublishAt        *time.Time          `json:"publish_at,omitempty"`
+		PublishError     string              `json:"publish_error,omitempty"`
+		CreatedAt        time.Time           `json:"created_at"`
+		UpdatedAt        time.Time           `json:"updated_at"`
+	}
+
+	DraftResponse struct {
+		Response
+		Status       string     `json:"status"`
+		PublishAt    *time.Time `json:"publish_at,omitempty"`
+		PublishError string     `json:"publish_error,omitempty"`
+		UpdatedAt    time.Time  `json:"updated_at"`
+	}
+
+	DeletedResponse struct {
+		Response
+		DeletedAt time.Time `json:"deleted_at"`
+		PurgeAt   time.Time `json:"purge_at"`
+	}
+
+	RevisionResponse struct {
+		Caption    string    `json:"caption"`
+		ReplacedAt time.Time `json:"replaced_at"`
+	}
+
+	LocationResponse struct {
+		Location   *location.Response `json:"location"`
+		Posts      []*Response        `json:"posts"`
+		NextBefore *time.Time         `json:"next_before,omitempty"`
 	}
 )
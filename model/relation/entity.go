@@ -0,0 +1,15 @@
+package relation
+
+import "time"
+
+type Follow struct {
+	FollowerID  string    `gorm:"column:follower_id; primaryKey"`
+	FollowingID string    `gorm:"column:following_id; primaryKey"`
+	CreatedAt   time.Time `gorm:"column:created_at; not null"`
+}
+
+type Block struct {
+	BlockerID string    `gorm:"column:blocker_id; primaryKey"`
+	BlockedID string    `gorm:"column:blocked_id; primaryKey"`
+	CreatedAt time.Time `gorm:"column:created_at; not null"`
+}
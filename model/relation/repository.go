@@ -0,0 +1,133 @@
+package relation
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	FindFollowingIDs(tx *gorm.DB, userID string) []string
+	FindFollowerIDs(tx *gorm.DB, userID string) []string
+	// CountFollowers returns the follower counts of userIDs, users nobody follows are left out.
+	CountFollowers(tx *gorm.DB, userIDs []string) map[string]int64
+	// FindBlockedIDs returns users blocked by userID and users who blocked userID.
+	FindBlockedIDs(tx *gorm.DB, userID string) []string
+	IsFollowing(tx *gorm.DB, followerID, followingID string) bool
+	// IsBlocked reports whether either user blocked the other.
+	IsBlocked(tx *gorm.DB, userID, otherID string) bool
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (*repositoryImpl) FindFollowingIDs(tx *gorm.DB, userID string) []string {
+	var ids []string
+	err := tx.Model(&Follow{}).
+		Where("follower_id = ?", userID).
+		Pluck("following_id", &ids).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return ids
+}
+
+func (*repositoryImpl) FindFollowerIDs(tx *gorm.DB, userID string) []string {
+	var ids []string
+	err := tx.Model(&Follow{}).
+		Where("following_id = ?", userID).
+		Pluck("follower_id", &ids).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return ids
+}
+
+func (*repositoryImpl) FindBlockedIDs(tx *gorm.DB, userID string) []string {
+	var blocked, blockers []string
+	err := tx.Model(&Block{}).
+		Where("blocker_id = ?", userID).
+		Pluck("blocked_id", &blocked).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	err = tx.Model(&Block{}).
+		Where("blocked_id = ?", userID).
+		Pluck("blocker_id", &blockers).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return append(blocked, blockers...)
+}
+
+func (*repositoryImpl) CountFollowers(tx *gorm.DB, userIDs []string) map[string]int64 {
+	counts := map[string]int64{}
+	if len(userIDs) == 0 {
+		return counts
+	}
+
+	var rows []struct {
+		FollowingID string
+		Followers   int64
+	}
+	err := tx.Model(&Follow{}).
+		Select("following_id, COUNT(*) AS followers").
+		Where("following_id IN ?", userIDs).
+		Group("following_id").
+		Find(&rows).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	for _, row := range rows {
+		counts[row.FollowingID] = row.Followers
+	}
+	return counts
+}
+
+func (*repositoryImpl) IsFollowing(tx *gorm.DB, followerID, followingID string) bool {
+	var count int64
+	err := tx.Model(&Follow{}).
+		Where("follower_id = ? AND following_id = ?", followerID, followingID).
+		Count(&count).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return count > 0
+}
+
+func (*repositoryImpl) IsBlocked(tx *gorm.DB, userID, otherID string) bool {
+	var count int64
+	err := tx.Model(&Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", userID, otherID, otherID, userID).
+		Count(&count).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return count > 0
+}
+
+// VisiblePosts hides deleted, unpublished and archived posts, posts of suspended owners, of private owners
+// the viewer doesn't follow and of owners blocked either way. The query has to name the posts table p.
+func VisiblePosts(viewerID string) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Joins("JOIN users u ON u.user_id = p.user_id").
+			Where("p.deleted_at IS NULL AND p.status = ? AND p.is_archived = ? AND u.is_suspended = ?", "published", false, false).
+			Where("u.is_private = ? OR p.user_id = ? OR EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.following_id = p.user_id)",
+				false, viewerID, viewerID).
+			Where("NOT EXISTS (SELECT 1 FROM blocks b WHERE (b.blocker_id = p.user_id AND b.blocked_id = ?) OR (b.blocker_id = ? AND b.blocked_id = p.user_id))",
+				viewerID, viewerID)
+	}
+}
+
+// NotBlocked hides rows whose column holds a user blocked either way by viewerID.
+func NotBlocked(column, viewerID string) func(tx *gorm.DB) *gorm.DB {
+	return func(tx *gorm.DB) *gorm.DB {
+		return tx.Where("NOT EXISTS (SELECT 1 FROM blocks b WHERE (b.blocker_id = "+column+" AND b.blocked_id = ?) OR (b.blocker_id = ? AND b.blocked_id = "+column+"))",
+			viewerID, viewerID)
+	}
+}
@@ -1,12 +1,67 @@
 package resource
 
-import "time"
+import (
+	"go-api/helper"
+	"time"
+)
 
 type Resource struct {
-	ID          string `gorm:"column:resource_id"`
-	IndexInPost int    `gorm:"column:index_in_post"`
+	ID          string    `gorm:"column:resource_id"`
+	IndexInPost int       `gorm:"column:index_in_post"`
 	Path        string    `gorm:"column:path"`
+	Hash        string    `gorm:"column:hash"`
 	ShareURL    string    `gorm:"column:share_url"`
+	MediaType   string    `gorm:"column:media_type"`
+	Duration    float64   `gorm:"column:duration"`
+	Width       int       `gorm:"column:width"`
+	Height      int       `gorm:"column:height"`
+	PosterPath  string    `gorm:"column:poster_path"`
+	PosterHash  string    `gorm:"column:poster_hash"`
+	PosterURL   string    `gorm:"column:poster_url"`
+	AltText     string    `gorm:"column:alt_text"`
 	PostID      string    `gorm:"column:post_id"`
 	CreatedAt   time.Time `gorm:"column:created_at"`
 }
+
+// ToResponse signs the media links for the viewer, ShareURL and PosterURL stay unsigned in storage.
+func (r *Resource) ToResponse(viewerID string) Response {
+	return Response{
+		ID:        r.ID,
+		ShareURL:  helper.SignMediaURL(r.Path, viewerID),
+		MediaType: r.MediaType,
+		Duration:  r.Duration,
+		Width:     r.Width,
+		Height:    r.Height,
+		PosterURL: helper.SignMediaURL(r.PosterPath, viewerID),
+		AltText:   r.AltText,
+	}
+}
+
+// Hashes are the blobs the resource holds a reference to.
+func (r *Resource) Hashes() map[string]string {
+	hashes := map[string]string{}
+	if r.Hash != "" {
+		hashes[r.Hash] = r.Path
+	}
+	if r.PosterHash != "" {
+		hashes[r.PosterHash] = r.PosterPath
+	}
+	return hashes
+}
+
+// ThumbnailPath is the poster frame for videos and the media itself otherwise.
+func (r *Resource) ThumbnailPath() string {
+	if r.MediaType == MediaVideo && r.PosterPath != "" {
+		return r.PosterPath
+	}
+	return r.Path
+}
+
+// ToThumbnail is the thumbnail of a post listing, signed for the viewer.
+func (r *Resource) ToThumbnail(viewerID string) *Response {
+	return &Response{
+		ShareURL:  helper.SignMediaURL(r.ThumbnailPath(), viewerID),
+		MediaType: r.MediaType,
+		AltText:   r.AltText,
+	}
+}
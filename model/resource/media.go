@@ -0,0 +1,227 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"go-api/app"
+	"go-api/exception"
+	"go-api/model/blob"
+	"gorm.io/gorm"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	// decoders for image.DecodeConfig
+	_ "image/gif"
+	_ "image/png"
+)
+
+const (
+	MediaImage = "image"
+	MediaVideo = "video"
+
+	MaxImageBytes = 10 << 20
+	MaxVideoBytes = 50 << 20
+	// MaxVideoDuration is in seconds.
+	MaxVideoDuration = 60
+	// MaxVideoDimension bounds the width and height of videos in pixels.
+	MaxVideoDimension = 4096
+
+	posterTimeout = 30 * time.Second
+	posterWidth   = 640
+	// maxPosterHeight keeps the blank poster of a video with an extreme aspect ratio small.
+	maxPosterHeight = 4 * posterWidth
+)
+
+var imageExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// videoCodecs are the accepted sample entry types, H.264 and HEVC.
+var videoCodecs = map[string]bool{
+	"avc1": true,
+	"avc3": true,
+	"hvc1": true,
+	"hev1": true,
+}
+
+// MediaInfo is what Probe found out about an upload.
+type MediaInfo struct {
+	MediaType   string
+	ContentType string
+	Extension   string
+	Duration    float64
+	Width       int
+	Height      int
+}
+
+// Probe sniffs the upload content and checks it against the limits of its media type.
+func Probe(r io.ReaderAt, size int64) *MediaInfo {
+	head := make([]byte, 512)
+	n, err := r.ReadAt(head, 0)
+	if err != nil && err != io.EOF {
+		panic(err)
+	}
+	head = head[:n]
+
+	contentType := http.DetectContentType(head)
+	if ext, ok := imageExtensions[contentType]; ok {
+		return probeImage(r, size, contentType, ext)
+	}
+
+	info, err := parseMP4(r, size)
+	if err == errNotMP4 {
+		panic(exception.FieldError{Field: "media", Message: "media must be a jpeg, png or gif image or an mp4 video"})
+	}
+	if err != nil {
+		panic(exception.FieldError{Field: "media", Message: err.Error()})
+	}
+	return checkVideo(info, size)
+}
+
+func probeImage(r io.ReaderAt, size int64, contentType, ext string) *MediaInfo {
+	if size > MaxImageBytes {
+		panic(exception.FieldError{Field: "media", Message: "image is too large"})
+	}
+
+	config, _, err := image.DecodeConfig(io.NewSectionReader(r, 0, size))
+	if err != nil {
+		panic(exception.FieldError{Field: "media", Message: "image can't be decoded"})
+	}
+	return &MediaInfo{
+		MediaType:   MediaImage,
+		ContentType: contentType,
+		Extension:   ext,
+		Width:       config.Width,
+		Height:      config.Height,
+	}
+}
+
+func checkVideo(info *mp4Info, size int64) *MediaInfo {
+	if size > MaxVideoBytes {
+		panic(exception.FieldError{Field: "media", Message: "video is too large"})
+	}
+	if !videoCodecs[info.Codec] {
+		panic(exception.FieldError{Field: "media", Message: "video must be encoded with h.264 or hevc"})
+	}
+	if info.Duration <= 0 || info.Duration > MaxVideoDuration {
+		panic(exception.FieldError{Field: "media", Message: "video must be at most 60 seconds long"})
+	}
+	if info.Width <= 0 || info.Height <= 0 {
+		panic(exception.FieldError{Field: "media", Message: "video has no dimensions"})
+	}
+	if info.Width > MaxVideoDimension || info.Height > MaxVideoDimension {
+		panic(exception.FieldError{Field: "media", Message: "video must be at most 4096 pixels wide and high"})
+	}
+
+	contentType, ext := "video/mp4", ".mp4"
+	if info.Brand == "qt  " {
+		contentType, ext = "video/quicktime", ".mov"
+	}
+	return &MediaInfo{
+		MediaType:   MediaVideo,
+		ContentType: contentType,
+		Extension:   ext,
+		Duration:    info.Duration,
+		Width:       info.Width,
+		Height:      info.Height,
+	}
+}
+
+// Store probes the media and saves it content addressed, videos get a poster frame too.
+// The caller acquires the blobs of the returned resource when it's saved.
+func Store(db *gorm.DB, src io.ReaderAt, size int64) *Resource {
+	info := Probe(src, size)
+	media := blob.Put(db, src, size, info.Extension)
+	r := &Resource{
+		Path:      media.Path,
+		Hash:      media.Hash,
+		ShareURL:  app.GetStorage().URL(media.Path),
+		MediaType: info.MediaType,
+		Duration:  info.Duration,
+		Width:     info.Width,
+		Height:    info.Height,
+	}
+
+	if info.MediaType == MediaVideo {
+		poster := Poster(io.NewSectionReader(src, 0, size), info)
+		posterBlob := blob.Put(db, bytes.NewReader(poster), int64(len(poster)), ".jpg")
+		r.PosterPath = posterBlob.Path
+		r.PosterHash = posterBlob.Hash
+		r.PosterURL = app.GetStorage().URL(posterBlob.Path)
+	}
+	return r
+}
+
+// Poster returns a jpeg of the first video frame, it falls back to a blank frame of the
+// same aspect ratio when ffmpeg isn't installed or can't decode the video.
+func Poster(r io.Reader, info *MediaInfo) []byte {
+	if frame, err := ffmpegPoster(r); err == nil && len(frame) > 0 {
+		return frame
+	}
+	return blankPoster(info.Width, info.Height)
+}
+
+func ffmpegPoster(r io.Reader) ([]byte, error) {
+	ffmpeg, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := ioutil.TempFile("", "video-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), posterTimeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpeg, "-v", "error", "-i", tmp.Name(),
+		"-frames:v", "1", "-vf", "scale='min(640,iw)':-2", "-f", "image2", "-c:v", "mjpeg", "pipe:1")
+	cmd.Stdout = &out
+	err = cmd.Run()
+	return out.Bytes(), err
+}
+
+// blankPoster is posterWidth wide, its height follows the video's aspect ratio up to maxPosterHeight.
+func blankPoster(width, height int) []byte {
+	w, h := posterWidth, posterWidth
+	if width > 0 && height > 0 {
+		h = int(math.Round(float64(posterWidth) * float64(height) / float64(width)))
+	}
+	if h < 1 {
+		h = 1
+	}
+	if h > maxPosterHeight {
+		h = maxPosterHeight
+	}
+
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = color.Gray{Y: 32}.Y
+	}
+
+	var buf bytes.Buffer
+	err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 80})
+	if err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
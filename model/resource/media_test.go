@@ -0,0 +1,125 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func box(boxType string, payload ...[]byte) []byte {
+	body := bytes.Join(payload, nil)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(len(body)+8))
+	copy(header[4:], boxType)
+	return append(header, body...)
+}
+
+func u32(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v)
+	return b
+}
+
+// testMP4 builds the boxes parseMP4 reads, with mdat placed before moov like most encoders do.
+func testMP4(codec string, seconds uint32, width, height uint32) []byte {
+	mvhd := box("mvhd", u32(0), u32(0), u32(0), u32(1000), u32(seconds*1000), make([]byte, 80))
+	tkhd := box("tkhd", u32(0), make([]byte, 72), u32(width<<16), u32(height<<16))
+	hdlr := box("hdlr", u32(0), u32(0), []byte("vide"), make([]byte, 12))
+	stsd := box("stsd", u32(0), u32(1), box(codec, make([]byte, 78)))
+	trak := box("trak", tkhd, box("mdia", hdlr, box("minf", box("stbl", stsd))))
+
+	return bytes.Join([][]byte{
+		box("ftyp", []byte("isom"), u32(512), []byte("isomavc1")),
+		box("mdat", make([]byte, 1024)),
+		box("moov", mvhd, trak),
+	}, nil)
+}
+
+func TestProbe(t *testing.T) {
+	t.Run("mp4 should return video info", func(t *testing.T) {
+		data := testMP4("avc1", 15, 1080, 1920)
+		info := Probe(bytes.NewReader(data), int64(len(data)))
+		assert.Equal(t, MediaVideo, info.MediaType)
+		assert.Equal(t, "video/mp4", info.ContentType)
+		assert.Equal(t, float64(15), info.Duration)
+		assert.Equal(t, 1080, info.Width)
+		assert.Equal(t, 1920, info.Height)
+	})
+
+	t.Run("too long video should panic", func(t *testing.T) {
+		data := testMP4("avc1", MaxVideoDuration+1, 1080, 1920)
+		assert.Panics(t, func() {
+			Probe(bytes.NewReader(data), int64(len(data)))
+		})
+	})
+
+	t.Run("oversized video should panic", func(t *testing.T) {
+		data := testMP4("avc1", 15, 1080, MaxVideoDimension+1)
+		assert.Panics(t, func() {
+			Probe(bytes.NewReader(data), int64(len(data)))
+		})
+	})
+
+	t.Run("unsupported codec should panic", func(t *testing.T) {
+		data := testMP4("mp4v", 15, 1080, 1920)
+		assert.Panics(t, func() {
+			Probe(bytes.NewReader(data), int64(len(data)))
+		})
+	})
+
+	t.Run("truncated mp4 should panic", func(t *testing.T) {
+		data := testMP4("avc1", 15, 1080, 1920)
+		data = data[:len(data)-40]
+		assert.Panics(t, func() {
+			Probe(bytes.NewReader(data), int64(len(data)))
+		})
+	})
+
+	t.Run("png should return image info", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 30, 20))))
+		info := Probe(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+		assert.Equal(t, MediaImage, info.MediaType)
+		assert.Equal(t, ".png", info.Extension)
+		assert.Equal(t, 30, info.Width)
+		assert.Equal(t, 20, info.Height)
+	})
+
+	t.Run("unknown content should panic", func(t *testing.T) {
+		data := []byte("plain text is not media")
+		assert.Panics(t, func() {
+			Probe(bytes.NewReader(data), int64(len(data)))
+		})
+	})
+}
+
+func TestPoster(t *testing.T) {
+	data := testMP4("avc1", 15, 1080, 1920)
+	poster := Poster(bytes.NewReader(data), &MediaInfo{Width: 1080, Height: 1920})
+
+	config, err := jpeg.DecodeConfig(bytes.NewReader(poster))
+	assert.NoError(t, err)
+	assert.Equal(t, posterWidth, config.Width)
+
+	t.Run("blank poster of an extreme aspect ratio should be clamped", func(t *testing.T) {
+		config, err := jpeg.DecodeConfig(bytes.NewReader(blankPoster(1, 1<<30)))
+		assert.NoError(t, err)
+		assert.Equal(t, maxPosterHeight, config.Height)
+
+		config, err = jpeg.DecodeConfig(bytes.NewReader(blankPoster(1<<30, 1)))
+		assert.NoError(t, err)
+		assert.Equal(t, 1, config.Height)
+	})
+}
+
+func TestResource_ThumbnailPath(t *testing.T) {
+	video := &Resource{MediaType: MediaVideo, Path: "video.mp4", PosterPath: "poster.jpg"}
+	assert.Equal(t, "poster.jpg", video.ThumbnailPath())
+
+	photo := &Resource{MediaType: MediaImage, Path: "photo.jpg"}
+	assert.Equal(t, "photo.jpg", photo.ThumbnailPath())
+}
@@ -0,0 +1,209 @@
+package resource
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+// mp4Info is what's read from the boxes of an ISO base media (mp4, mov) file.
+type mp4Info struct {
+	Brand    string
+	Codec    string
+	Duration float64
+	Width    int
+	Height   int
+}
+
+type mp4Track struct {
+	handler string
+	codec   string
+	width   int
+	height  int
+}
+
+var errNotMP4 = errors.New("not an mp4 container")
+
+const maxMP4Boxes = 10000
+
+// parseMP4 walks the box tree looking only at the headers it needs, mdat is skipped without reading it.
+func parseMP4(r io.ReaderAt, size int64) (*mp4Info, error) {
+	p := &mp4Parser{r: r}
+
+	boxType, start, end, err := p.box(0, size)
+	if err != nil || boxType != "ftyp" || end-start < 4 {
+		return nil, errNotMP4
+	}
+	brand, err := p.read(start, 4)
+	if err != nil {
+		return nil, errNotMP4
+	}
+
+	info := &mp4Info{Brand: string(brand)}
+	err = p.walk(0, size, info, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, track := range p.tracks {
+		if track.handler == "vide" {
+			info.Codec = track.codec
+			info.Width = track.width
+			info.Height = track.height
+			return info, nil
+		}
+	}
+	return nil, errors.New("mp4 has no video track")
+}
+
+type mp4Parser struct {
+	r      io.ReaderAt
+	tracks []*mp4Track
+	boxes  int
+}
+
+func (p *mp4Parser) read(offset int64, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := p.r.ReadAt(buf, offset)
+	if err != nil {
+		return nil, err
+	}
+	return buf, nil
+}
+
+// box reads the header at offset and returns the type and the payload bounds.
+func (p *mp4Parser) box(offset, limit int64) (string, int64, int64, error) {
+	header, err := p.read(offset, 8)
+	if err != nil {
+		return "", 0, 0, err
+	}
+
+	size := int64(binary.BigEndian.Uint32(header[:4]))
+	boxType := string(header[4:8])
+	start := offset + 8
+	switch size {
+	case 0:
+		size = limit - offset
+	case 1:
+		large, err := p.read(start, 8)
+		if err != nil {
+			return "", 0, 0, err
+		}
+		size = int64(binary.BigEndian.Uint64(large))
+		start += 8
+	}
+
+	end := offset + size
+	if end < start || end > limit {
+		return "", 0, 0, errors.New("mp4 box " + boxType + " is out of bounds")
+	}
+	return boxType, start, end, nil
+}
+
+func (p *mp4Parser) walk(offset, limit int64, info *mp4Info, track *mp4Track) error {
+	for offset+8 <= limit {
+		p.boxes++
+		if p.boxes > maxMP4Boxes {
+			return errors.New("mp4 has too many boxes")
+		}
+
+		boxType, start, end, err := p.box(offset, limit)
+		if err != nil {
+			return err
+		}
+
+		switch boxType {
+		case "moov", "mdia", "minf", "stbl":
+			err = p.walk(start, end, info, track)
+		case "trak":
+			track = &mp4Track{}
+			p.tracks = append(p.tracks, track)
+			err = p.walk(start, end, info, track)
+		case "mvhd":
+			err = p.mvhd(start, info)
+		case "tkhd":
+			err = p.tkhd(start, track)
+		case "hdlr":
+			err = p.hdlr(start, track)
+		case "stsd":
+			err = p.stsd(start, track)
+		}
+		if err != nil {
+			return err
+		}
+		offset = end
+	}
+	return nil
+}
+
+func (p *mp4Parser) mvhd(start int64, info *mp4Info) error {
+	b, err := p.read(start, 32)
+	if err != nil {
+		return err
+	}
+
+	var timescale, duration uint64
+	if b[0] == 1 {
+		timescale = uint64(binary.BigEndian.Uint32(b[20:24]))
+		duration = binary.BigEndian.Uint64(b[24:32])
+	} else {
+		timescale = uint64(binary.BigEndian.Uint32(b[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(b[16:20]))
+	}
+	if timescale == 0 {
+		return errors.New("mp4 has no timescale")
+	}
+	info.Duration = float64(duration) / float64(timescale)
+	return nil
+}
+
+func (p *mp4Parser) tkhd(start int64, track *mp4Track) error {
+	if track == nil {
+		return nil
+	}
+
+	version, err := p.read(start, 1)
+	if err != nil {
+		return err
+	}
+
+	// width and height are 16.16 fixed point numbers after the matrix
+	offset := start + 76
+	if version[0] == 1 {
+		offset = start + 88
+	}
+	b, err := p.read(offset, 8)
+	if err != nil {
+		return err
+	}
+	track.width = int(binary.BigEndian.Uint32(b[0:4]) >> 16)
+	track.height = int(binary.BigEndian.Uint32(b[4:8]) >> 16)
+	return nil
+}
+
+func (p *mp4Parser) hdlr(start int64, track *mp4Track) error {
+	if track == nil {
+		return nil
+	}
+
+	b, err := p.read(start+8, 4)
+	if err != nil {
+		return err
+	}
+	track.handler = string(b)
+	return nil
+}
+
+func (p *mp4Parser) stsd(start int64, track *mp4Track) error {
+	if track == nil {
+		return nil
+	}
+
+	// version, flags and entry count come before the first sample entry header
+	b, err := p.read(start+12, 4)
+	if err != nil {
+		return err
+	}
+	track.codec = string(b)
+	return nil
+}
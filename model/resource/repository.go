@@ -11,6 +11,16 @@ type Repository interface {
 	FindByResourceID(tx *gorm.DB, resourceID string) *Resource
 	FindByPostID(tx *gorm.DB, postID string) []*Resource
 	FindFirstByPostID(tx *gorm.DB, postID string) (*Resource, int64)
+	// FindFirstByPostIDs loads the first resource of each post in one query, keyed by post ID.
+	FindFirstByPostIDs(tx *gorm.DB, postIDs []string) map[string]*Resource
+	UpdateIndex(tx *gorm.DB, resourceID string, index int)
+	UpdateAltText(tx *gorm.DB, resourceID, altText string)
+	// FindByPath returns resources whose media or poster is stored at path, blobs can be shared.
+	FindByPath(tx *gorm.DB, path string) []*Resource
+	// FindOrphans returns resources whose post no longer exists.
+	FindOrphans(tx *gorm.DB, limit int) []*Resource
+	// FindReferencedPaths returns the paths that are the media or poster of some resource.
+	FindReferencedPaths(tx *gorm.DB, paths []string) []string
 }
 
 type repositoryImpl struct {
@@ -49,7 +59,7 @@ func (*repositoryImpl) FindByPostID(tx *gorm.DB, postID string) []*Resource {
 	var resources []*Resource
 	err := tx.
 		Where("post_id = ?", postID).
-		Order("created_at desc").
+		Order("index_in_post asc").
 		Find(&resources).Error
 	if err != nil {
 		panic(exception.DatabaseError{Message: err.Error()})
@@ -69,3 +79,89 @@ func (*repositoryImpl) FindFirstByPostID(tx *gorm.DB, postID string) (*Resource,
 	}
 	return &resource, resourcesCount
 }
+
+func (*repositoryImpl) FindFirstByPostIDs(tx *gorm.DB, postIDs []string) map[string]*Resource {
+	firsts := map[string]*Resource{}
+	if len(postIDs) == 0 {
+		return firsts
+	}
+
+	var resources []*Resource
+	err := tx.
+		Where("post_id IN ?", postIDs).
+		Where("index_in_post = (SELECT MIN(r.index_in_post) FROM resources r WHERE r.post_id = resources.post_id)").
+		Find(&resources).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	for _, r := range resources {
+		firsts[r.PostID] = r
+	}
+	return firsts
+}
+
+func (*repositoryImpl) UpdateIndex(tx *gorm.DB, resourceID string, index int) {
+	err := tx.Model(&Resource{}).
+		Where("resource_id = ?", resourceID).
+		Update("index_in_post", index).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) UpdateAltText(tx *gorm.DB, resourceID, altText string) {
+	err := tx.Model(&Resource{}).
+		Where("resource_id = ?", resourceID).
+		Update("alt_text", altText).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindByPath(tx *gorm.DB, path string) []*Resource {
+	var resources []*Resource
+	err := tx.Where("path = ? OR poster_path = ?", path, path).
+		Find(&resources).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return resources
+}
+
+func (*repositoryImpl) FindOrphans(tx *gorm.DB, limit int) []*Resource {
+	var resources []*Resource
+	err := tx.Table("resources r").
+		Select("r.*").
+		Joins("LEFT JOIN posts p ON p.post_id = r.post_id").
+		Where("p.post_id IS NULL").
+		Limit(limit).
+		Find(&resources).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return resources
+}
+
+func (*repositoryImpl) FindReferencedPaths(tx *gorm.DB, paths []string) []string {
+	var referenced []string
+	if len(paths) == 0 {
+		return referenced
+	}
+
+	err := tx.Model(&Resource{}).
+		Where("path IN ?", paths).
+		Pluck("path", &referenced).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	var posters []string
+	err = tx.Model(&Resource{}).
+		Where("poster_path IN ?", paths).
+		Pluck("poster_path", &posters).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return append(referenced, posters...)
+}
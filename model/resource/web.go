@@ -1,7 +1,17 @@
 package resource
 
+import "go-api/model/tag"
+
 type (
 	Response struct {
-		ShareURL string `json:"share_url"`
+		ID        string         `json:"resource_id"`
+		ShareURL  string         `json:"share_url"`
+		MediaType string         `json:"media_type,omitempty"`
+		Duration  float64        `json:"duration,omitempty"`
+		Width     int            `json:"width,omitempty"`
+		Height    int            `json:"height,omitempty"`
+		PosterURL string         `json:"poster_url,omitempty"`
+		AltText   string         `json:"alt_text,omitempty"`
+		Tags      []tag.Response `json:"tags,omitempty"`
 	}
 )
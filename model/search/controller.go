@@ -0,0 +1,52 @@
+package search
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/model"
+	"net/http"
+)
+
+type Controller interface {
+	Search(ctx *gin.Context)
+	Suggest(ctx *gin.Context)
+}
+
+type controllerImpl struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return &controllerImpl{service: service}
+}
+
+func (c *controllerImpl) Search(ctx *gin.Context) {
+	var req Request
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		panic(err)
+	}
+
+	req.ViewerID = ctx.GetHeader("User_id")
+	res := c.service.Search(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) Suggest(ctx *gin.Context) {
+	var req Request
+	err := ctx.ShouldBindQuery(&req)
+	if err != nil {
+		panic(err)
+	}
+
+	req.ViewerID = ctx.GetHeader("User_id")
+	res := c.service.Suggest(ctx, &req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
@@ -0,0 +1,306 @@
+package search
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	TypeUser    = "user"
+	TypePost    = "post"
+	TypeHashtag = "hashtag"
+)
+
+const (
+	titleWeight = 3
+	textWeight  = 1
+)
+
+// Document is what the index stores and ranks, Title is the handle matched exactly
+// (username or hashtag) and Text is the free text around it.
+type Document struct {
+	Type       string
+	ID         string
+	Title      string
+	Text       string
+	ImageURL   string
+	ImagePath  string // stored media, signed for the viewer when returned
+	OwnerID    string
+	Tags       []string
+	IsVerified bool
+	// Popularity is the follower count of users and the post count of hashtags.
+	Popularity int64
+	CreatedAt  time.Time
+}
+
+type Hit struct {
+	*Document
+	Score int
+	Exact bool
+}
+
+// Indexer is the search backend used by the service, the memory one is embedded in the process.
+type Indexer interface {
+	Index(doc *Document)
+	Remove(docType, id string)
+	Get(docType, id string) *Document
+	Search(query, docType string, offset, limit int) []*Hit
+	Suggest(prefix, docType string, limit int) []*Hit
+}
+
+type memoryIndexer struct {
+	mu       sync.RWMutex
+	docs     map[string]*Document
+	postings map[string]map[string]int
+	// terms and titles are kept sorted for prefix lookups, they're rebuilt lazily once dirty.
+	terms  []string
+	titles []title
+	dirty  bool
+}
+
+// title is the lowercased title of the document at key.
+type title struct {
+	title string
+	key   string
+}
+
+func NewMemoryIndexer() Indexer {
+	return &memoryIndexer{
+		docs:     map[string]*Document{},
+		postings: map[string]map[string]int{},
+	}
+}
+
+func docKey(docType, id string) string {
+	return docType + ":" + id
+}
+
+// Tokenize lowercases text and splits it on anything that can't be part of a handle.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+}
+
+func (m *memoryIndexer) Index(doc *Document) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := docKey(doc.Type, doc.ID)
+	if old := m.remove(key); old == nil || old.Title != doc.Title {
+		m.dirty = true
+	}
+	m.docs[key] = doc
+
+	weights := map[string]int{}
+	for _, term := range Tokenize(doc.Title) {
+		weights[term] += titleWeight
+	}
+	for _, term := range Tokenize(doc.Text) {
+		weights[term] += textWeight
+	}
+	for term, weight := range weights {
+		if m.postings[term] == nil {
+			m.postings[term] = map[string]int{}
+			m.dirty = true
+		}
+		m.postings[term][key] = weight
+	}
+}
+
+func (m *memoryIndexer) Remove(docType, id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if doc := m.remove(docKey(docType, id)); doc != nil && doc.Title != "" {
+		m.dirty = true
+	}
+}
+
+// remove drops the document at key from the postings, returning it or nil when there's none.
+func (m *memoryIndexer) remove(key string) *Document {
+	doc, ok := m.docs[key]
+	if !ok {
+		return nil
+	}
+
+	for _, term := range append(Tokenize(doc.Title), Tokenize(doc.Text)...) {
+		delete(m.postings[term], key)
+		if len(m.postings[term]) == 0 {
+			delete(m.postings, term)
+			m.dirty = true
+		}
+	}
+	delete(m.docs, key)
+	return doc
+}
+
+func (m *memoryIndexer) Get(docType, id string) *Document {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.docs[docKey(docType, id)]
+}
+
+// Search requires every query term to match, the last one as a prefix so results follow typing.
+func (m *memoryIndexer) Search(query, docType string, offset, limit int) []*Hit {
+	terms := Tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+
+	m.rlockSorted()
+	defer m.mu.RUnlock()
+
+	var scores map[string]int
+	for i, term := range terms {
+		matched := map[string]int{}
+		expanded := []string{term}
+		if i == len(terms)-1 {
+			expanded = m.withPrefix(term)
+		}
+		for _, t := range expanded {
+			for key, weight := range m.postings[t] {
+				if weight > matched[key] {
+					matched[key] = weight
+				}
+			}
+		}
+
+		if scores == nil {
+			scores = matched
+			continue
+		}
+		for key := range scores {
+			if weight, ok := matched[key]; ok {
+				scores[key] += weight
+			} else {
+				delete(scores, key)
+			}
+		}
+	}
+
+	whole := strings.Join(terms, "")
+	var hits []*Hit
+	for key, score := range scores {
+		doc := m.docs[key]
+		if docType != "" && doc.Type != docType {
+			continue
+		}
+		hits = append(hits, &Hit{
+			Document: doc,
+			Score:    score,
+			Exact:    doc.Title != "" && strings.ToLower(doc.Title) == whole,
+		})
+	}
+	rank(hits)
+	return page(hits, offset, limit)
+}
+
+// Suggest autocompletes handles, so only titles are matched.
+func (m *memoryIndexer) Suggest(prefix, docType string, limit int) []*Hit {
+	prefix = strings.ToLower(strings.TrimLeft(prefix, "#@ "))
+	if prefix == "" {
+		return nil
+	}
+
+	m.rlockSorted()
+	defer m.mu.RUnlock()
+
+	var hits []*Hit
+	i := sort.Search(len(m.titles), func(i int) bool { return m.titles[i].title >= prefix })
+	for ; i < len(m.titles) && strings.HasPrefix(m.titles[i].title, prefix); i++ {
+		doc := m.docs[m.titles[i].key]
+		if docType != "" && doc.Type != docType {
+			continue
+		}
+		hits = append(hits, &Hit{Document: doc, Score: titleWeight, Exact: m.titles[i].title == prefix})
+	}
+	rank(hits)
+	return page(hits, 0, limit)
+}
+
+// rlockSorted read-locks the index once its sorted terms and titles are up to date.
+func (m *memoryIndexer) rlockSorted() {
+	for {
+		m.mu.RLock()
+		if !m.dirty {
+			return
+		}
+		m.mu.RUnlock()
+
+		m.mu.Lock()
+		m.sortIndex()
+		m.mu.Unlock()
+	}
+}
+
+func (m *memoryIndexer) sortIndex() {
+	if !m.dirty {
+		return
+	}
+
+	m.terms = m.terms[:0]
+	for term := range m.postings {
+		m.terms = append(m.terms, term)
+	}
+	sort.Strings(m.terms)
+
+	m.titles = m.titles[:0]
+	for key, doc := range m.docs {
+		if doc.Title != "" {
+			m.titles = append(m.titles, title{title: strings.ToLower(doc.Title), key: key})
+		}
+	}
+	sort.Slice(m.titles, func(i, j int) bool {
+		if m.titles[i].title != m.titles[j].title {
+			return m.titles[i].title < m.titles[j].title
+		}
+		return m.titles[i].key < m.titles[j].key
+	})
+	m.dirty = false
+}
+
+// withPrefix returns every indexed term starting with prefix, the index has to be sorted.
+func (m *memoryIndexer) withPrefix(prefix string) []string {
+	var terms []string
+	for i := sort.SearchStrings(m.terms, prefix); i < len(m.terms) && strings.HasPrefix(m.terms[i], prefix); i++ {
+		terms = append(terms, m.terms[i])
+	}
+	return terms
+}
+
+// rank orders exact handle matches first, then verified accounts, then popularity and text score.
+func rank(hits []*Hit) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := hits[i], hits[j]
+		if a.Exact != b.Exact {
+			return a.Exact
+		}
+		if a.IsVerified != b.IsVerified {
+			return a.IsVerified
+		}
+		if a.Popularity != b.Popularity {
+			return a.Popularity > b.Popularity
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return docKey(a.Type, a.ID) < docKey(b.Type, b.ID)
+	})
+}
+
+func page(hits []*Hit, offset, limit int) []*Hit {
+	if offset >= len(hits) {
+		return nil
+	}
+	hits = hits[offset:]
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits
+}
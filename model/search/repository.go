@@ -0,0 +1,51 @@
+package search
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+)
+
+// Owner says how a viewer may see a user of the results and their posts.
+type Owner struct {
+	UserID string `gorm:"column:user_id"`
+	// Hidden owners are suspended or blocked either way, neither they nor their posts are shown.
+	Hidden bool `gorm:"column:hidden"`
+	// Locked owners are private and not followed by the viewer, they're shown but their posts aren't.
+	Locked bool `gorm:"column:locked"`
+}
+
+type Repository interface {
+	// FindOwners returns the users of userIDs that still exist, keyed by user ID.
+	FindOwners(tx *gorm.DB, viewerID string, userIDs []string) map[string]*Owner
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (*repositoryImpl) FindOwners(tx *gorm.DB, viewerID string, userIDs []string) map[string]*Owner {
+	owners := map[string]*Owner{}
+	if len(userIDs) == 0 {
+		return owners
+	}
+
+	var found []*Owner
+	err := tx.Table("users AS u").
+		Select("u.user_id, "+
+			"(u.is_suspended OR EXISTS (SELECT 1 FROM blocks b WHERE (b.blocker_id = u.user_id AND b.blocked_id = ?) OR (b.blocker_id = ? AND b.blocked_id = u.user_id))) AS hidden, "+
+			"(u.is_private AND u.user_id <> ? AND NOT EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = ? AND f.following_id = u.user_id)) AS locked",
+			viewerID, viewerID, viewerID, viewerID).
+		Where("u.user_id IN ?", userIDs).
+		Find(&found).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+
+	for _, o := range found {
+		owners[o.UserID] = o
+	}
+	return owners
+}
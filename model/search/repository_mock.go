@@ -0,0 +1,18 @@
+package search
+
+import (
+	"github.com/stretchr/testify/mock"
+	"gorm.io/gorm"
+)
+
+type RepositoryMock struct {
+	mock.Mock
+}
+
+func (r *RepositoryMock) FindOwners(tx *gorm.DB, viewerID string, userIDs []string) map[string]*Owner {
+	args := r.Called(viewerID, userIDs)
+	if args.Get(0) != nil {
+		return args.Get(0).(map[string]*Owner)
+	}
+	return map[string]*Owner{}
+}
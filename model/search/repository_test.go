@@ -0,0 +1,53 @@
+package search_test
+
+import (
+	"github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"go-api/app"
+	"go-api/model/relation"
+	"go-api/model/search"
+	"go-api/model/user"
+	"testing"
+	"time"
+)
+
+func TestRepositoryImpl_FindOwners(t *testing.T) {
+	app.TestDBInit()
+	repository := search.NewRepository()
+	now := time.Now()
+
+	newUser := uuid.NewV4().String
+	viewerID, openID, privateID, followedID, suspendedID, blockedID, blockerID := newUser(), newUser(), newUser(), newUser(), newUser(), newUser(), newUser()
+	for _, id := range []string{viewerID, openID, privateID, followedID, suspendedID, blockedID, blockerID} {
+		assert.Nil(t, app.DB.Create(&user.User{
+			ID: id, Email: id + "@example.com", Username: id, DisplayName: id, Role: user.RoleUser,
+			IsPrivate: id == privateID || id == followedID, IsSuspended: id == suspendedID,
+			CreatedAt: now, UpdatedAt: now,
+		}).Error)
+	}
+	assert.Nil(t, app.DB.Create(&relation.Follow{FollowerID: viewerID, FollowingID: followedID, CreatedAt: now}).Error)
+	assert.Nil(t, app.DB.Create(&relation.Block{BlockerID: viewerID, BlockedID: blockedID, CreatedAt: now}).Error)
+	assert.Nil(t, app.DB.Create(&relation.Block{BlockerID: blockerID, BlockedID: viewerID, CreatedAt: now}).Error)
+
+	owners := repository.FindOwners(app.DB, viewerID, []string{viewerID, openID, privateID, followedID, suspendedID, blockedID, blockerID, "deleted"})
+
+	t.Run("visible users should be neither hidden nor locked", func(t *testing.T) {
+		assert.Equal(t, &search.Owner{UserID: openID}, owners[openID])
+		assert.Equal(t, &search.Owner{UserID: viewerID}, owners[viewerID])
+	})
+
+	t.Run("private users should be locked until followed", func(t *testing.T) {
+		assert.True(t, owners[privateID].Locked)
+		assert.False(t, owners[followedID].Locked)
+	})
+
+	t.Run("suspended and blocked users should be hidden", func(t *testing.T) {
+		assert.True(t, owners[suspendedID].Hidden)
+		assert.True(t, owners[blockedID].Hidden)
+		assert.True(t, owners[blockerID].Hidden)
+	})
+
+	t.Run("missing users should be left out", func(t *testing.T) {
+		assert.NotContains(t, owners, "deleted")
+	})
+}
@@ -0,0 +1,9 @@
+package search
+
+import "github.com/gin-gonic/gin"
+
+func InitRoutes(router *gin.RouterGroup, controller Controller) {
+	searchGroup := router.Group("/search")
+	searchGroup.GET("", controller.Search)
+	searchGroup.GET("/suggest", controller.Suggest)
+}
@@ -0,0 +1,289 @@
+package search
+
+import (
+	"context"
+	"github.com/go-playground/validator"
+	"go-api/app"
+	"go-api/helper"
+	"gorm.io/gorm"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultLimit = 20
+	suggestLimit = 10
+	// ownerBatchSize is how many hits are checked against the viewer at a time.
+	ownerBatchSize = 100
+	// RebuildInterval is how often each instance rebuilds its index from the database, that's how
+	// it picks up what was changed or removed through the other instances.
+	RebuildInterval = 5 * time.Minute
+)
+
+var hashtagPattern = regexp.MustCompile(`#([\pL\pN_]+)`)
+
+type Service interface {
+	IndexUser(doc *UserDocument)
+	RemoveUser(userID string)
+	IndexPost(doc *PostDocument)
+	RemovePost(postID string)
+	Search(ctx context.Context, req *Request) []*Response
+	Suggest(ctx context.Context, req *Request) []*Response
+	// Rebuild has load fill a new memory index and swaps it in, changes made meanwhile are applied to it too.
+	Rebuild(load func(index Service))
+}
+
+type serviceImpl struct {
+	validate   *validator.Validate
+	repository Repository
+	// mu serializes changes, which keeps hashtag counts consistent, and guards the swap of the indexer.
+	mu      sync.RWMutex
+	indexer Indexer
+	// rebuilding is set while Rebuild runs, the changes made meanwhile are kept in pending.
+	rebuilding bool
+	pending    []func(indexer Indexer)
+}
+
+func NewService(validate *validator.Validate, repository Repository, indexer Indexer) Service {
+	return &serviceImpl{validate: validate, repository: repository, indexer: indexer}
+}
+
+// Hashtags returns the distinct lowercased hashtags of a caption.
+func Hashtags(caption string) []string {
+	seen := map[string]bool{}
+	var tags []string
+	for _, match := range hashtagPattern.FindAllStringSubmatch(caption, -1) {
+		tag := strings.ToLower(match[1])
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+func (s *serviceImpl) IndexUser(doc *UserDocument) {
+	s.change(func(indexer Indexer) {
+		indexer.Index(&Document{
+			Type:       TypeUser,
+			ID:         doc.UserID,
+			Title:      doc.Username,
+			Text:       doc.DisplayName,
+			ImageURL:   doc.AvatarURL,
+			IsVerified: doc.IsVerified,
+			Popularity: doc.FollowerCount,
+		})
+	})
+}
+
+func (s *serviceImpl) RemoveUser(userID string) {
+	s.change(func(indexer Indexer) {
+		indexer.Remove(TypeUser, userID)
+	})
+}
+
+// IndexPost also keeps the hashtag documents and their post counts in step with the caption.
+func (s *serviceImpl) IndexPost(doc *PostDocument) {
+	s.change(func(indexer Indexer) {
+		tags := Hashtags(doc.Caption)
+		old := indexer.Get(TypePost, doc.PostID)
+
+		indexer.Index(&Document{
+			Type:      TypePost,
+			ID:        doc.PostID,
+			Text:      doc.Caption,
+			ImagePath: doc.ThumbnailPath,
+			OwnerID:   doc.UserID,
+			Tags:      tags,
+			CreatedAt: doc.CreatedAt,
+		})
+
+		if old != nil {
+			countHashtags(indexer, old.Tags, -1)
+		}
+		countHashtags(indexer, tags, 1)
+	})
+}
+
+func (s *serviceImpl) RemovePost(postID string) {
+	s.change(func(indexer Indexer) {
+		old := indexer.Get(TypePost, postID)
+		if old == nil {
+			return
+		}
+
+		indexer.Remove(TypePost, postID)
+		countHashtags(indexer, old.Tags, -1)
+	})
+}
+
+func countHashtags(indexer Indexer, tags []string, delta int64) {
+	for _, tag := range tags {
+		var count int64
+		if doc := indexer.Get(TypeHashtag, tag); doc != nil {
+			count = doc.Popularity
+		}
+
+		count += delta
+		if count <= 0 {
+			indexer.Remove(TypeHashtag, tag)
+			continue
+		}
+		indexer.Index(&Document{Type: TypeHashtag, ID: tag, Title: tag, Popularity: count})
+	}
+}
+
+// change applies fn to the index. Changes are replayed on the index being rebuilt, so they have to
+// give the same result when the rebuild already loaded them.
+func (s *serviceImpl) change(fn func(indexer Indexer)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	fn(s.indexer)
+	if s.rebuilding {
+		s.pending = append(s.pending, fn)
+	}
+}
+
+func (s *serviceImpl) Rebuild(load func(index Service)) {
+	s.mu.Lock()
+	if s.rebuilding {
+		s.mu.Unlock()
+		return
+	}
+	s.rebuilding = true
+	s.mu.Unlock()
+
+	swapped := false
+	defer func() {
+		if !swapped {
+			s.mu.Lock()
+			s.rebuilding, s.pending = false, nil
+			s.mu.Unlock()
+		}
+	}()
+
+	fresh := &serviceImpl{validate: s.validate, repository: s.repository, indexer: NewMemoryIndexer()}
+	load(fresh)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, fn := range s.pending {
+		fn(fresh.indexer)
+	}
+	s.indexer = fresh.indexer
+	s.rebuilding, s.pending = false, nil
+	swapped = true
+}
+
+func (s *serviceImpl) current() Indexer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.indexer
+}
+
+func (s *serviceImpl) Search(ctx context.Context, req *Request) []*Response {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	if req.Limit == 0 {
+		req.Limit = defaultLimit
+	}
+	hits := s.visible(ctx, s.current().Search(req.Query, req.Type, 0, 0), req.ViewerID, req.Offset+req.Limit)
+	return toResponses(page(hits, req.Offset, req.Limit), req.ViewerID)
+}
+
+func (s *serviceImpl) Suggest(ctx context.Context, req *Request) []*Response {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	if req.Limit == 0 || req.Limit > suggestLimit {
+		req.Limit = suggestLimit
+	}
+	hits := s.visible(ctx, s.current().Suggest(req.Query, req.Type, 0), req.ViewerID, req.Limit)
+	return toResponses(page(hits, 0, req.Limit), req.ViewerID)
+}
+
+// visible keeps the hits viewerID may see, in order, until it has want of them. Users that are
+// suspended or blocked either way are left out with their posts, posts of private users the viewer
+// doesn't follow are left out too.
+func (s *serviceImpl) visible(ctx context.Context, hits []*Hit, viewerID string, want int) []*Hit {
+	if len(hits) == 0 {
+		return nil
+	}
+
+	// hashtags alone don't need the database
+	var tx *gorm.DB
+	var kept []*Hit
+	for start := 0; start < len(hits) && len(kept) < want; start += ownerBatchSize {
+		batch := hits[start:]
+		if len(batch) > ownerBatchSize {
+			batch = batch[:ownerBatchSize]
+		}
+
+		var userIDs []string
+		for _, hit := range batch {
+			if userID := ownerOf(hit); userID != "" {
+				userIDs = append(userIDs, userID)
+			}
+		}
+		owners := map[string]*Owner{}
+		if len(userIDs) > 0 {
+			if tx == nil {
+				tx = app.ReadTx(ctx)
+				defer helper.TXCommitOrRollback(tx)
+			}
+			owners = s.repository.FindOwners(tx, viewerID, userIDs)
+		}
+
+		for _, hit := range batch {
+			if userID := ownerOf(hit); userID != "" {
+				owner, ok := owners[userID]
+				if !ok || owner.Hidden || (hit.Type == TypePost && owner.Locked) {
+					continue
+				}
+			}
+			kept = append(kept, hit)
+		}
+	}
+	return kept
+}
+
+// ownerOf returns the user a hit is shown for, hashtags have none.
+func ownerOf(hit *Hit) string {
+	switch hit.Type {
+	case TypeUser:
+		return hit.ID
+	case TypePost:
+		return hit.OwnerID
+	}
+	return ""
+}
+
+func toResponses(hits []*Hit, viewerID string) []*Response {
+	var response []*Response
+	for _, hit := range hits {
+		imageURL := hit.ImageURL
+		if hit.ImagePath != "" {
+			imageURL = helper.SignMediaURL(hit.ImagePath, viewerID)
+		}
+
+		response = append(response, &Response{
+			Type:       hit.Type,
+			ID:         hit.ID,
+			Title:      hit.Title,
+			Text:       hit.Text,
+			ImageURL:   imageURL,
+			OwnerID:    hit.OwnerID,
+			IsVerified: hit.IsVerified,
+			Count:      hit.Popularity,
+		})
+	}
+	return response
+}
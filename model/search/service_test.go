@@ -0,0 +1,145 @@
+package search
+
+import (
+	"context"
+	"github.com/go-playground/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go-api/app"
+	"testing"
+)
+
+func TestServiceImpl_IndexPost(t *testing.T) {
+	indexer := NewMemoryIndexer()
+	service := NewService(validator.New(), &RepositoryMock{}, indexer)
+
+	service.IndexPost(&PostDocument{PostID: "1", Caption: "sunset at the #Beach"})
+	service.IndexPost(&PostDocument{PostID: "2", Caption: "#beach #summer"})
+
+	t.Run("hashtags should count their posts", func(t *testing.T) {
+		assert.Equal(t, int64(2), indexer.Get(TypeHashtag, "beach").Popularity)
+		assert.Equal(t, int64(1), indexer.Get(TypeHashtag, "summer").Popularity)
+	})
+
+	t.Run("caption words should be searchable by prefix", func(t *testing.T) {
+		res := service.Search(context.Background(), &Request{Query: "suns", Type: TypePost})
+		assert.Len(t, res, 1)
+		assert.Equal(t, "1", res[0].ID)
+	})
+
+	t.Run("editing and removing posts should update hashtag counts", func(t *testing.T) {
+		service.IndexPost(&PostDocument{PostID: "2", Caption: "#beach"})
+		assert.Nil(t, indexer.Get(TypeHashtag, "summer"))
+
+		service.RemovePost("1")
+		assert.Equal(t, int64(1), indexer.Get(TypeHashtag, "beach").Popularity)
+	})
+
+	t.Run("suggest should autocomplete hashtags", func(t *testing.T) {
+		res := service.Suggest(context.Background(), &Request{Query: "#bea", Type: TypeHashtag})
+		assert.Len(t, res, 1)
+		assert.Equal(t, "beach", res[0].Title)
+	})
+
+	t.Run("suggest should follow renamed users", func(t *testing.T) {
+		service.IndexUser(&UserDocument{UserID: "1", Username: "beachboy"})
+		service.IndexUser(&UserDocument{UserID: "2", Username: "beacon"})
+		assert.Len(t, indexer.Suggest("@beac", TypeUser, 0), 2)
+
+		service.IndexUser(&UserDocument{UserID: "2", Username: "lighthouse"})
+		hits := indexer.Suggest("@beac", TypeUser, 0)
+		assert.Len(t, hits, 1)
+		assert.Equal(t, "beachboy", hits[0].Title)
+		hits = indexer.Suggest("light", TypeUser, 0)
+		assert.Len(t, hits, 1)
+		assert.Equal(t, "2", hits[0].ID)
+	})
+
+	t.Run("users should rank by their followers", func(t *testing.T) {
+		service.IndexUser(&UserDocument{UserID: "3", Username: "beachgirl", FollowerCount: 10})
+		hits := indexer.Suggest("beach", TypeUser, 0)
+		assert.Len(t, hits, 2)
+		assert.Equal(t, "beachgirl", hits[0].Title)
+	})
+}
+
+func TestServiceImpl_Visible(t *testing.T) {
+	app.TestDBInit()
+	repository := &RepositoryMock{}
+	repository.On("FindOwners", "viewer", mock.Anything).Return(map[string]*Owner{
+		"open":      {UserID: "open"},
+		"private":   {UserID: "private", Locked: true},
+		"suspended": {UserID: "suspended", Hidden: true},
+	})
+	service := NewService(validator.New(), repository, NewMemoryIndexer())
+
+	for _, userID := range []string{"open", "private", "suspended", "deleted"} {
+		service.IndexUser(&UserDocument{UserID: userID, Username: "sun" + userID})
+		service.IndexPost(&PostDocument{PostID: userID, UserID: userID, Caption: "sunny #day"})
+	}
+
+	t.Run("hidden and deleted users should be left out", func(t *testing.T) {
+		res := service.Search(context.Background(), &Request{Query: "sun", Type: TypeUser, ViewerID: "viewer"})
+		var ids []string
+		for _, r := range res {
+			ids = append(ids, r.ID)
+		}
+		assert.ElementsMatch(t, []string{"open", "private"}, ids)
+	})
+
+	t.Run("posts of locked users should be left out", func(t *testing.T) {
+		res := service.Search(context.Background(), &Request{Query: "sunny", Type: TypePost, ViewerID: "viewer"})
+		assert.Len(t, res, 1)
+		assert.Equal(t, "open", res[0].ID)
+	})
+
+	t.Run("paging should count the visible hits only", func(t *testing.T) {
+		res := service.Search(context.Background(), &Request{Query: "sun", Type: TypeUser, ViewerID: "viewer", Offset: 1, Limit: 5})
+		assert.Len(t, res, 1)
+	})
+}
+
+func TestServiceImpl_Rebuild(t *testing.T) {
+	service := NewService(validator.New(), &RepositoryMock{}, NewMemoryIndexer())
+	service.IndexPost(&PostDocument{PostID: "gone", Caption: "#old"})
+
+	hashtags := func() map[string]int64 {
+		counts := map[string]int64{}
+		for _, tag := range []string{"#old", "#new", "#later"} {
+			for _, res := range service.Suggest(context.Background(), &Request{Query: tag, Type: TypeHashtag}) {
+				counts[res.Title] = res.Count
+			}
+		}
+		return counts
+	}
+
+	t.Run("rebuild should replace the index with what was loaded", func(t *testing.T) {
+		service.Rebuild(func(index Service) {
+			index.IndexPost(&PostDocument{PostID: "1", Caption: "#new"})
+		})
+		assert.Equal(t, map[string]int64{"new": 1}, hashtags())
+	})
+
+	t.Run("changes made during the rebuild should be kept", func(t *testing.T) {
+		service.Rebuild(func(index Service) {
+			index.IndexPost(&PostDocument{PostID: "1", Caption: "#new"})
+			index.IndexPost(&PostDocument{PostID: "2", Caption: "#new"})
+			service.IndexPost(&PostDocument{PostID: "2", Caption: "#new"})
+			service.IndexPost(&PostDocument{PostID: "3", Caption: "#new #later"})
+			service.RemovePost("1")
+		})
+		assert.Equal(t, map[string]int64{"new": 2, "later": 1}, hashtags())
+	})
+
+	t.Run("a failed rebuild should keep the old index", func(t *testing.T) {
+		assert.Panics(t, func() {
+			service.Rebuild(func(index Service) {
+				panic("database is down")
+			})
+		})
+		assert.Equal(t, map[string]int64{"new": 2, "later": 1}, hashtags())
+
+		service.Rebuild(func(index Service) {})
+		assert.Empty(t, hashtags())
+	})
+}
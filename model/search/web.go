@@ -0,0 +1,42 @@
+package search
+
+import "time"
+
+type (
+	UserDocument struct {
+		UserID        string
+		Username      string
+		DisplayName   string
+		AvatarURL     string
+		IsVerified    bool
+		FollowerCount int64
+	}
+
+	PostDocument struct {
+		PostID        string
+		UserID        string
+		Caption       string
+		ThumbnailPath string
+		CreatedAt     time.Time
+	}
+
+	Request struct {
+		Query  string `validate:"required,max=100" form:"q" json:"q"`
+		Type   string `validate:"omitempty,oneof=user post hashtag" form:"type" json:"type"`
+		Offset int    `validate:"min=0" form:"offset" json:"offset"`
+		Limit  int    `validate:"min=0,max=50" form:"limit" json:"limit"`
+		// ViewerID is who post thumbnails get signed for.
+		ViewerID string `json:"-"`
+	}
+
+	Response struct {
+		Type       string `json:"type"`
+		ID         string `json:"id"`
+		Title      string `json:"title,omitempty"`
+		Text       string `json:"text,omitempty"`
+		ImageURL   string `json:"image_url,omitempty"`
+		OwnerID    string `json:"user_id,omitempty"`
+		IsVerified bool   `json:"is_verified,omitempty"`
+		Count      int64  `json:"count,omitempty"`
+	}
+)
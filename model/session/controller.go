@@ -0,0 +1,42 @@
+package session
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/model"
+	"net/http"
+)
+
+type Controller interface {
+	FindAll(ctx *gin.Context)
+	Revoke(ctx *gin.Context)
+}
+
+type controllerImpl struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return &controllerImpl{service: service}
+}
+
+func (c *controllerImpl) FindAll(ctx *gin.Context) {
+	userID := ctx.GetHeader("User_id")
+	sessionID := ctx.GetHeader("Session_id")
+	res := c.service.FindByUserID(ctx, userID, sessionID)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) Revoke(ctx *gin.Context) {
+	c.service.Revoke(ctx, &RevokeRequest{
+		SessionID: ctx.Param("sessionID"),
+		UserID:    ctx.GetHeader("User_id"),
+	})
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
@@ -0,0 +1,77 @@
+package session_test
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"go-api/app"
+	"go-api/middleware"
+	"go-api/model/session"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestControllerImpl_Sessions(t *testing.T) {
+	app.TestDBInit()
+	router := gin.New()
+	router.Use(gin.CustomRecovery(middleware.PanicHandler))
+	session.InitRoutes(&router.RouterGroup, session.NewController(session.NewService(validator.New(), session.NewRepository())))
+
+	now := time.Now()
+	userID := uuid.NewV4().String()
+	current, older := session.New(userID, "phone", "10.0.0.1"), session.New(userID, "laptop", "10.0.0.2")
+	older.LastSeenAt = now.Add(-time.Hour)
+	revoked, expired, otherUsers := session.New(userID, "tablet", "10.0.0.3"), session.New(userID, "tv", "10.0.0.4"), session.New(uuid.NewV4().String(), "phone", "10.0.0.5")
+	revoked.IsRevoked = true
+	expired.ExpiresAt = now.Add(-time.Minute)
+	for _, s := range []*session.Session{current, older, revoked, expired, otherUsers} {
+		assert.Nil(t, app.DB.Create(s).Error)
+	}
+
+	findAll := func() []session.Response {
+		req := httptest.NewRequest(http.MethodGet, "/session/", nil)
+		req.Header.Set("User_id", userID)
+		req.Header.Set("Session_id", current.ID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		var res struct {
+			Data []session.Response `json:"data"`
+		}
+		assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &res))
+		return res.Data
+	}
+	revoke := func(sessionID, userID string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/session/"+sessionID, nil)
+		req.Header.Set("User_id", userID)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	t.Run("listing should show live sessions of the user, latest first", func(t *testing.T) {
+		res := findAll()
+		assert.Len(t, res, 2)
+		assert.Equal(t, current.ID, res[0].SessionID)
+		assert.True(t, res[0].IsCurrent)
+		assert.Equal(t, older.ID, res[1].SessionID)
+		assert.False(t, res[1].IsCurrent)
+	})
+
+	t.Run("session of another user should not be revoked", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, revoke(otherUsers.ID, userID))
+		assert.False(t, session.NewRepository().FindBySessionID(app.DB, otherUsers.ID).IsRevoked)
+	})
+
+	t.Run("revoked session should leave the listing", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, revoke(older.ID, userID))
+		res := findAll()
+		assert.Len(t, res, 1)
+		assert.Equal(t, current.ID, res[0].SessionID)
+	})
+}
@@ -0,0 +1,25 @@
+package session
+
+import "time"
+
+type Session struct {
+	ID         string    `gorm:"column:session_id; primaryKey"`
+	UserID     string    `gorm:"column:user_id; not null"`
+	UserAgent  string    `gorm:"column:user_agent;"`
+	IPAddress  string    `gorm:"column:ip_address;"`
+	IsRevoked  bool      `gorm:"column:is_revoked;"`
+	CreatedAt  time.Time `gorm:"column:created_at; not null"`
+	LastSeenAt time.Time `gorm:"column:last_seen_at; not null"`
+	ExpiresAt  time.Time `gorm:"column:expires_at; not null"`
+}
+
+func (s *Session) ToResponse(currentSessionID string) *Response {
+	return &Response{
+		SessionID:  s.ID,
+		UserAgent:  s.UserAgent,
+		IPAddress:  s.IPAddress,
+		IsCurrent:  s.ID == currentSessionID,
+		CreatedAt:  s.CreatedAt,
+		LastSeenAt: s.LastSeenAt,
+	}
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+	"time"
+)
+
+type Repository interface {
+	Create(tx *gorm.DB, session *Session)
+	Revoke(tx *gorm.DB, sessionID string)
+	RevokeByUserID(tx *gorm.DB, userID string)
+	Touch(tx *gorm.DB, sessionID string, lastSeenAt time.Time)
+	FindBySessionID(tx *gorm.DB, sessionID string) *Session
+	FindByUserID(tx *gorm.DB, userID string) []*Session
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (*repositoryImpl) Create(tx *gorm.DB, session *Session) {
+	err := tx.Create(&session).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Revoke(tx *gorm.DB, sessionID string) {
+	err := tx.Model(&Session{}).
+		Where("session_id = ?", sessionID).
+		Update("is_revoked", true).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Touch(tx *gorm.DB, sessionID string, lastSeenAt time.Time) {
+	err := tx.Model(&Session{}).
+		Where("session_id = ?", sessionID).
+		Update("last_seen_at", lastSeenAt).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindBySessionID(tx *gorm.DB, sessionID string) *Session {
+	var session *Session
+	err := tx.Where("session_id = ?", sessionID).
+		Limit(1).
+		Find(&session).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return session
+}
+
+func (*repositoryImpl) FindByUserID(tx *gorm.DB, userID string) []*Session {
+	var sessions []*Session
+	err := tx.Where("user_id = ? AND is_revoked = ? AND expires_at > ?", userID, false, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return sessions
+}
+
+func (*repositoryImpl) RevokeByUserID(tx *gorm.DB, userID string) {
+	err := tx.Model(&Session{}).
+		Where("user_id = ?", userID).
+		Update("is_revoked", true).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
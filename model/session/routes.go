@@ -0,0 +1,9 @@
+package session
+
+import "github.com/gin-gonic/gin"
+
+func InitRoutes(router *gin.RouterGroup, controller Controller) {
+	sessionGroup := router.Group("/session")
+	sessionGroup.GET("/", controller.FindAll)
+	sessionGroup.DELETE("/:sessionID", controller.Revoke)
+}
@@ -0,0 +1,98 @@
+package session
+
+import (
+	"context"
+	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"go-api/app"
+	"go-api/exception"
+	"go-api/helper"
+	"time"
+)
+
+// touchInterval limits how often requests on the same session write last_seen_at.
+const touchInterval = time.Minute
+
+type Service interface {
+	FindByUserID(ctx context.Context, userID, currentSessionID string) []*Response
+	Revoke(ctx context.Context, req *RevokeRequest)
+	Validate(ctx context.Context, sessionID, userID string)
+}
+
+type serviceImpl struct {
+	validate          *validator.Validate
+	sessionRepository Repository
+}
+
+func NewService(validate *validator.Validate, sessionRepository Repository) Service {
+	return &serviceImpl{validate: validate, sessionRepository: sessionRepository}
+}
+
+// New builds a session for a fresh login, it lives as long as the JWT issued with it.
+func New(userID, userAgent, ipAddress string) *Session {
+	now := time.Now()
+	return &Session{
+		ID:         uuid.NewV4().String(),
+		UserID:     userID,
+		UserAgent:  userAgent,
+		IPAddress:  ipAddress,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(helper.JWTLifetime),
+	}
+}
+
+func (s *serviceImpl) FindByUserID(ctx context.Context, userID, currentSessionID string) []*Response {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	var response []*Response
+	sessions := s.sessionRepository.FindByUserID(tx, userID)
+	for _, session := range sessions {
+		response = append(response, session.ToResponse(currentSessionID))
+	}
+	return response
+}
+
+func (s *serviceImpl) Revoke(ctx context.Context, req *RevokeRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	session := s.sessionRepository.FindBySessionID(tx, req.SessionID)
+	if session.ID == "" || session.UserID != req.UserID {
+		panic(exception.NotFoundError{Message: "session not found"})
+	}
+
+	s.sessionRepository.Revoke(tx, session.ID)
+}
+
+// Validate panics with a TokenError when the session was revoked, expired or belongs to someone else.
+func (s *serviceImpl) Validate(ctx context.Context, sessionID, userID string) {
+	if sessionID == "" {
+		panic(exception.TokenError{Message: "token has no session"})
+	}
+
+	db := app.GetDB().WithContext(ctx)
+	session := s.sessionRepository.FindBySessionID(db, sessionID)
+	if session.ID == "" || session.UserID != userID {
+		panic(exception.TokenError{Message: "session not found"})
+	}
+
+	if session.IsRevoked {
+		panic(exception.TokenError{Message: "session has been revoked"})
+	}
+
+	now := time.Now()
+	if now.After(session.ExpiresAt) {
+		panic(exception.TokenError{Message: "session has expired"})
+	}
+
+	if now.Sub(session.LastSeenAt) >= touchInterval {
+		s.sessionRepository.Touch(db, session.ID, now)
+	}
+}
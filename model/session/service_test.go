@@ -0,0 +1,63 @@
+package session_test
+
+import (
+	"context"
+	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"go-api/app"
+	"go-api/exception"
+	"go-api/model/session"
+	"testing"
+	"time"
+)
+
+func TestServiceImpl_Validate(t *testing.T) {
+	app.TestDBInit()
+	service := session.NewService(validator.New(), session.NewRepository())
+	userID := uuid.NewV4().String()
+
+	assertRejected := func(t *testing.T, sessionID, userID string) {
+		defer func() {
+			_, ok := recover().(exception.TokenError)
+			assert.True(t, ok)
+		}()
+		service.Validate(context.Background(), sessionID, userID)
+	}
+
+	t.Run("live session should be accepted and touched when stale", func(t *testing.T) {
+		stale := session.New(userID, "phone", "10.0.0.1")
+		stale.LastSeenAt = time.Now().Add(-time.Hour)
+		assert.Nil(t, app.DB.Create(stale).Error)
+
+		assert.NotPanics(t, func() {
+			service.Validate(context.Background(), stale.ID, userID)
+		})
+		lastSeen := session.NewRepository().FindBySessionID(app.DB, stale.ID).LastSeenAt
+		assert.WithinDuration(t, time.Now(), lastSeen, time.Minute)
+	})
+
+	t.Run("token without session should be rejected", func(t *testing.T) {
+		assertRejected(t, "", userID)
+	})
+
+	t.Run("session of another user should be rejected", func(t *testing.T) {
+		s := session.New(userID, "phone", "10.0.0.1")
+		assert.Nil(t, app.DB.Create(s).Error)
+		assertRejected(t, s.ID, uuid.NewV4().String())
+	})
+
+	t.Run("revoked session should be rejected", func(t *testing.T) {
+		s := session.New(userID, "phone", "10.0.0.1")
+		s.IsRevoked = true
+		assert.Nil(t, app.DB.Create(s).Error)
+		assertRejected(t, s.ID, userID)
+	})
+
+	t.Run("expired session should be rejected", func(t *testing.T) {
+		s := session.New(userID, "phone", "10.0.0.1")
+		s.ExpiresAt = time.Now().Add(-time.Minute)
+		assert.Nil(t, app.DB.Create(s).Error)
+		assertRejected(t, s.ID, userID)
+	})
+}
@@ -0,0 +1,19 @@
+package session
+
+import "time"
+
+type (
+	RevokeRequest struct {
+		SessionID string `validate:"required" json:"session_id"`
+		UserID    string `validate:"required" json:"user_id"`
+	}
+
+	Response struct {
+		SessionID  string    `json:"session_id"`
+		UserAgent  string    `json:"user_agent"`
+		IPAddress  string    `json:"ip_address"`
+		IsCurrent  bool      `json:"is_current"`
+		CreatedAt  time.Time `json:"created_at"`
+		LastSeenAt time.Time `json:"last_seen_at"`
+	}
+)
@@ -0,0 +1,114 @@
+package story
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/exception"
+	"go-api/model"
+	"net/http"
+)
+
+type Controller interface {
+	Create(ctx *gin.Context)
+	FindTray(ctx *gin.Context)
+	FindByUserID(ctx *gin.Context)
+	View(ctx *gin.Context)
+	FindViewers(ctx *gin.Context)
+	Delete(ctx *gin.Context)
+	Archive(ctx *gin.Context)
+	FindArchive(ctx *gin.Context)
+}
+
+type controllerImpl struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return &controllerImpl{service: service}
+}
+
+func (c *controllerImpl) Create(ctx *gin.Context) {
+	file, err := ctx.FormFile("image")
+	if err != nil {
+		panic(exception.FieldError{Field: "image", Message: "story image is required"})
+	}
+	if file.Size > maxStoryBytes {
+		panic(exception.FieldError{Field: "image", Message: "story image is too large"})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		panic(err)
+	}
+	defer src.Close()
+
+	res := c.service.Create(ctx, &CreateRequest{
+		UserID: ctx.GetHeader("User_id"),
+		File:   src,
+	})
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusCreated,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) FindTray(ctx *gin.Context) {
+	res := c.service.FindTray(ctx, ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) FindByUserID(ctx *gin.Context) {
+	res := c.service.FindByUserID(ctx, ctx.Param("userID"), ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) View(ctx *gin.Context) {
+	res := c.service.View(ctx, ctx.Param("storyID"), ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) FindViewers(ctx *gin.Context) {
+	res := c.service.FindViewers(ctx, ctx.Param("storyID"), ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) Delete(ctx *gin.Context) {
+	c.service.Delete(ctx, ctx.Param("storyID"), ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) Archive(ctx *gin.Context) {
+	c.service.Archive(ctx, ctx.Param("storyID"), ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
+
+func (c *controllerImpl) FindArchive(ctx *gin.Context) {
+	res := c.service.FindArchive(ctx, ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
@@ -0,0 +1,50 @@
+package story
+
+import (
+	"go-api/helper"
+	"time"
+)
+
+type Story struct {
+	ID     string `gorm:"column:story_id; primaryKey"`
+	UserID string `gorm:"column:user_id; not null"`
+	Path   string `gorm:"column:path; not null"`
+	// Hash is the blob the story holds a reference to, stories from before blobs have none and own their file.
+	Hash       string    `gorm:"column:hash"`
+	ShareURL   string    `gorm:"column:share_url; not null"`
+	IsArchived bool      `gorm:"column:is_archived;"`
+	CreatedAt  time.Time `gorm:"column:created_at; not null"`
+	ExpiresAt  time.Time `gorm:"column:expires_at; not null"`
+}
+
+type View struct {
+	StoryID  string    `gorm:"column:story_id; primaryKey"`
+	ViewerID string    `gorm:"column:viewer_id; primaryKey"`
+	ViewedAt time.Time `gorm:"column:viewed_at; not null"`
+}
+
+func (View) TableName() string {
+	return "story_views"
+}
+
+// TrayItem is an account with active stories, as seen by one viewer.
+type TrayItem struct {
+	UserID      string    `gorm:"column:user_id"`
+	UnseenCount int64     `gorm:"column:unseen_count"`
+	LatestAt    time.Time `gorm:"column:latest_at"`
+}
+
+func (s *Story) IsExpired(now time.Time) bool {
+	return !now.Before(s.ExpiresAt)
+}
+
+func (s *Story) ToResponse(viewerID string) *Response {
+	return &Response{
+		StoryID:    s.ID,
+		UserID:     s.UserID,
+		ShareURL:   helper.SignMediaURL(s.Path, viewerID),
+		IsArchived: s.IsArchived,
+		CreatedAt:  s.CreatedAt,
+		ExpiresAt:  s.ExpiresAt,
+	}
+}
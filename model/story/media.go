@@ -0,0 +1,33 @@
+package story
+
+import (
+	"bufio"
+	"go-api/exception"
+	"io"
+	"net/http"
+)
+
+const maxStoryBytes = 10 << 20
+
+// storyTypes maps the accepted sniffed content types to the stored file extension.
+var storyTypes = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+	"image/webp": ".webp",
+}
+
+// sniffImage checks the content rather than the filename and returns a reader that still starts at the first byte.
+func sniffImage(r io.Reader) (io.Reader, string) {
+	br := bufio.NewReaderSize(r, 512)
+	head, err := br.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		panic(err)
+	}
+
+	ext, ok := storyTypes[http.DetectContentType(head)]
+	if !ok {
+		panic(exception.FieldError{Field: "image", Message: "story must be a jpeg, png, gif or webp image"})
+	}
+	return br, ext
+}
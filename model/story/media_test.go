@@ -0,0 +1,40 @@
+package story
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestSniffImage(t *testing.T) {
+	t.Run("png should keep every byte", func(t *testing.T) {
+		var buf bytes.Buffer
+		assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+		data := buf.Bytes()
+
+		r, ext := sniffImage(bytes.NewReader(data))
+		assert.Equal(t, ".png", ext)
+
+		read, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, data, read)
+	})
+
+	t.Run("non image should panic", func(t *testing.T) {
+		assert.Panics(t, func() {
+			sniffImage(bytes.NewReader([]byte("#!/bin/sh\necho not an image")))
+		})
+	})
+}
+
+func TestStory_IsExpired(t *testing.T) {
+	now := time.Date(2021, 11, 20, 10, 0, 0, 0, time.UTC)
+	story := &Story{CreatedAt: now, ExpiresAt: now.Add(Lifetime)}
+
+	assert.False(t, story.IsExpired(now.Add(23*time.Hour)))
+	assert.True(t, story.IsExpired(now.Add(Lifetime)))
+}
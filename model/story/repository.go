@@ -0,0 +1,155 @@
+package story
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type Repository interface {
+	Create(tx *gorm.DB, story *Story)
+	Delete(tx *gorm.DB, storyID string)
+	SetArchived(tx *gorm.DB, storyID string, archived bool)
+	FindByStoryID(tx *gorm.DB, storyID string) *Story
+	// FindByPath returns every story showing the file, stories with the same content share a blob.
+	FindByPath(tx *gorm.DB, path string) []*Story
+	FindActiveByUserID(tx *gorm.DB, userID string, now time.Time) []*Story
+	FindArchivedByUserID(tx *gorm.DB, userID string) []*Story
+	// FindExpired returns expired stories that weren't archived by their owner.
+	FindExpired(tx *gorm.DB, now time.Time, limit int) []*Story
+	// FindTray returns the accounts in userIDs that have active stories the viewer hasn't seen.
+	FindTray(tx *gorm.DB, userIDs []string, viewerID string, now time.Time) []*TrayItem
+	CreateView(tx *gorm.DB, view *View)
+	FindViews(tx *gorm.DB, storyID string) []*View
+	DeleteViews(tx *gorm.DB, storyID string)
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (*repositoryImpl) Create(tx *gorm.DB, story *Story) {
+	err := tx.Create(&story).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Delete(tx *gorm.DB, storyID string) {
+	err := tx.Where("story_id = ?", storyID).Delete(&Story{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) SetArchived(tx *gorm.DB, storyID string, archived bool) {
+	err := tx.Model(&Story{}).
+		Where("story_id = ?", storyID).
+		Update("is_archived", archived).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindByStoryID(tx *gorm.DB, storyID string) *Story {
+	var story Story
+	err := tx.Where("story_id = ?", storyID).
+		Limit(1).
+		Find(&story).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return &story
+}
+
+func (*repositoryImpl) FindByPath(tx *gorm.DB, path string) []*Story {
+	var stories []*Story
+	err := tx.Where("path = ?", path).Find(&stories).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return stories
+}
+
+func (*repositoryImpl) FindActiveByUserID(tx *gorm.DB, userID string, now time.Time) []*Story {
+	var stories []*Story
+	err := tx.Where("user_id = ? AND expires_at > ?", userID, now).
+		Order("created_at asc").
+		Find(&stories).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return stories
+}
+
+func (*repositoryImpl) FindArchivedByUserID(tx *gorm.DB, userID string) []*Story {
+	var stories []*Story
+	err := tx.Where("user_id = ? AND is_archived = ?", userID, true).
+		Order("created_at desc").
+		Find(&stories).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return stories
+}
+
+func (*repositoryImpl) FindExpired(tx *gorm.DB, now time.Time, limit int) []*Story {
+	var stories []*Story
+	err := tx.Where("expires_at <= ? AND is_archived = ?", now, false).
+		Order("expires_at asc").
+		Limit(limit).
+		Find(&stories).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return stories
+}
+
+func (*repositoryImpl) FindTray(tx *gorm.DB, userIDs []string, viewerID string, now time.Time) []*TrayItem {
+	var items []*TrayItem
+	if len(userIDs) == 0 {
+		return items
+	}
+
+	err := tx.Table("stories s").
+		Select("s.user_id, SUM(CASE WHEN v.viewer_id IS NULL THEN 1 ELSE 0 END) AS unseen_count, MAX(s.created_at) AS latest_at").
+		Joins("LEFT JOIN story_views v ON v.story_id = s.story_id AND v.viewer_id = ?", viewerID).
+		Where("s.user_id IN ? AND s.expires_at > ?", userIDs, now).
+		Group("s.user_id").
+		Having("unseen_count > 0").
+		Order("latest_at desc").
+		Find(&items).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return items
+}
+
+func (*repositoryImpl) CreateView(tx *gorm.DB, view *View) {
+	err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&view).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindViews(tx *gorm.DB, storyID string) []*View {
+	var views []*View
+	err := tx.Where("story_id = ?", storyID).
+		Order("viewed_at desc").
+		Find(&views).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return views
+}
+
+func (*repositoryImpl) DeleteViews(tx *gorm.DB, storyID string) {
+	err := tx.Where("story_id = ?", storyID).Delete(&View{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
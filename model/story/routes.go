@@ -0,0 +1,15 @@
+package story
+
+import "github.com/gin-gonic/gin"
+
+func InitRoutes(router *gin.RouterGroup, controller Controller) {
+	storyGroup := router.Group("/story")
+	storyGroup.POST("/", controller.Create)
+	storyGroup.GET("/tray", controller.FindTray)
+	storyGroup.GET("/archive", controller.FindArchive)
+	storyGroup.GET("/user/:userID", controller.FindByUserID)
+	storyGroup.GET("/:storyID", controller.View)
+	storyGroup.GET("/:storyID/viewers", controller.FindViewers)
+	storyGroup.PUT("/:storyID/archive", controller.Archive)
+	storyGroup.DELETE("/:storyID", controller.Delete)
+}
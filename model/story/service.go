@@ -0,0 +1,268 @@
+package story
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"go-api/app"
+	"go-api/exception"
+	"go-api/helper"
+	"go-api/model/blob"
+	"go-api/model/relation"
+	"go-api/model/user"
+	"gorm.io/gorm"
+	"io"
+	"io/ioutil"
+	"time"
+)
+
+type Service interface {
+	Create(ctx context.Context, req *CreateRequest) *Response
+	FindTray(ctx context.Context, viewerID string) []*TrayResponse
+	FindByUserID(ctx context.Context, userID, viewerID string) []*Response
+	// View returns the story and records the viewer on it.
+	View(ctx context.Context, storyID, viewerID string) *Response
+	FindViewers(ctx context.Context, storyID, userID string) []*ViewerResponse
+	Delete(ctx context.Context, storyID, userID string)
+	Archive(ctx context.Context, storyID, userID string)
+	FindArchive(ctx context.Context, userID string) []*Response
+	// Cleanup removes expired stories that weren't archived and releases their files.
+	Cleanup(ctx context.Context)
+}
+
+const (
+	// Prefix is where story files lived in storage before they were stored as blobs.
+	Prefix          = "stories/"
+	Lifetime        = 24 * time.Hour
+	CleanupInterval = 10 * time.Minute
+	cleanupBatch    = 100
+)
+
+type serviceImpl struct {
+	validate           *validator.Validate
+	storyRepository    Repository
+	userRepository     user.Repository
+	relationRepository relation.Repository
+	blobRepository     blob.Repository
+	now                func() time.Time
+}
+
+func NewService(validate *validator.Validate, storyRepository Repository, userRepository user.Repository, relationRepository relation.Repository, blobRepository blob.Repository) Service {
+	return &serviceImpl{validate: validate, storyRepository: storyRepository, userRepository: userRepository, relationRepository: relationRepository, blobRepository: blobRepository, now: time.Now}
+}
+
+func (s *serviceImpl) Create(ctx context.Context, req *CreateRequest) *Response {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	file, ext := sniffImage(req.File)
+	content, err := ioutil.ReadAll(io.LimitReader(file, maxStoryBytes+1))
+	if err != nil {
+		panic(err)
+	}
+	if len(content) > maxStoryBytes {
+		panic(exception.FieldError{Field: "image", Message: "story image is too large"})
+	}
+
+	// registered apart from the transaction like post media, a story that isn't saved leaves an
+	// unreferenced blob the media collector takes
+	media := blob.Put(app.GetDB().WithContext(ctx), bytes.NewReader(content), int64(len(content)), ext)
+
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	now := s.now()
+	story := &Story{
+		ID:        uuid.NewV4().String(),
+		UserID:    req.UserID,
+		Path:      media.Path,
+		Hash:      media.Hash,
+		ShareURL:  app.GetStorage().URL(media.Path),
+		CreatedAt: now,
+		ExpiresAt: now.Add(Lifetime),
+	}
+	s.storyRepository.Create(tx, story)
+	s.blobRepository.Acquire(tx, story.Hash, story.Path)
+	return story.ToResponse(req.UserID)
+}
+
+func (s *serviceImpl) FindTray(ctx context.Context, viewerID string) []*TrayResponse {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	blocked := map[string]bool{}
+	for _, id := range s.relationRepository.FindBlockedIDs(tx, viewerID) {
+		blocked[id] = true
+	}
+
+	var following []string
+	for _, id := range s.relationRepository.FindFollowingIDs(tx, viewerID) {
+		if !blocked[id] {
+			following = append(following, id)
+		}
+	}
+
+	response := []*TrayResponse{}
+	for _, item := range s.storyRepository.FindTray(tx, following, viewerID, s.now()) {
+		owner := s.userRepository.FindById(tx, item.UserID)
+		if owner.ID == "" || owner.IsSuspended {
+			continue
+		}
+
+		response = append(response, &TrayResponse{
+			UserID:            owner.ID,
+			Username:          owner.Username,
+			DisplayName:       owner.DisplayName,
+			ProfilePictureURL: owner.AvatarURLs().Small,
+			UnseenCount:       item.UnseenCount,
+			LatestAt:          item.LatestAt,
+		})
+	}
+	return response
+}
+
+func (s *serviceImpl) FindByUserID(ctx context.Context, userID, viewerID string) []*Response {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	s.checkAccess(tx, userID, viewerID)
+
+	response := []*Response{}
+	for _, story := range s.storyRepository.FindActiveByUserID(tx, userID, s.now()) {
+		response = append(response, story.ToResponse(viewerID))
+	}
+	return response
+}
+
+func (s *serviceImpl) View(ctx context.Context, storyID, viewerID string) *Response {
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	now := s.now()
+	story := s.storyRepository.FindByStoryID(tx, storyID)
+	if story.ID == "" || (story.UserID != viewerID && story.IsExpired(now)) {
+		panic(exception.NotFoundError{Message: "story not found"})
+	}
+
+	s.checkAccess(tx, story.UserID, viewerID)
+	if story.UserID != viewerID {
+		s.storyRepository.CreateView(tx, &View{
+			StoryID:  story.ID,
+			ViewerID: viewerID,
+			ViewedAt: now,
+		})
+	}
+	return story.ToResponse(viewerID)
+}
+
+func (s *serviceImpl) FindViewers(ctx context.Context, storyID, userID string) []*ViewerResponse {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	story := s.findOwnStory(tx, storyID, userID)
+
+	response := []*ViewerResponse{}
+	for _, view := range s.storyRepository.FindViews(tx, story.ID) {
+		viewer := s.userRepository.FindById(tx, view.ViewerID)
+		if viewer.ID == "" {
+			continue
+		}
+
+		response = append(response, &ViewerResponse{
+			UserID:            viewer.ID,
+			Username:          viewer.Username,
+			DisplayName:       viewer.DisplayName,
+			ProfilePictureURL: viewer.AvatarURLs().Small,
+			ViewedAt:          view.ViewedAt,
+		})
+	}
+	return response
+}
+
+func (s *serviceImpl) Delete(ctx context.Context, storyID, userID string) {
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	story := s.findOwnStory(tx, storyID, userID)
+	s.delete(tx, story)
+}
+
+func (s *serviceImpl) Archive(ctx context.Context, storyID, userID string) {
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	story := s.findOwnStory(tx, storyID, userID)
+	s.storyRepository.SetArchived(tx, story.ID, true)
+}
+
+func (s *serviceImpl) FindArchive(ctx context.Context, userID string) []*Response {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	response := []*Response{}
+	for _, story := range s.storyRepository.FindArchivedByUserID(tx, userID) {
+		response = append(response, story.ToResponse(userID))
+	}
+	return response
+}
+
+func (s *serviceImpl) Cleanup(ctx context.Context) {
+	for {
+		stories := func() []*Story {
+			tx := app.WriteTx(ctx)
+			defer helper.TXCommitOrRollback(tx)
+
+			stories := s.storyRepository.FindExpired(tx, s.now(), cleanupBatch)
+			for _, story := range stories {
+				s.delete(tx, story)
+			}
+			return stories
+		}()
+
+		if len(stories) < cleanupBatch {
+			return
+		}
+	}
+}
+
+// delete removes the story and releases its blob, the media collector takes the blob once nothing
+// references it. Files of stories from before blobs go only once tx is committed, a leftover file
+// is harmless while a dangling row isn't.
+func (s *serviceImpl) delete(tx *gorm.DB, story *Story) {
+	s.storyRepository.DeleteViews(tx, story.ID)
+	s.storyRepository.Delete(tx, story.ID)
+	if story.Hash != "" {
+		s.blobRepository.Release(tx, story.Hash)
+		return
+	}
+	app.AfterCommit(tx, func() { _ = app.GetStorage().Delete(story.Path) })
+}
+
+func (s *serviceImpl) findOwnStory(tx *gorm.DB, storyID, userID string) *Story {
+	story := s.storyRepository.FindByStoryID(tx, storyID)
+	if story.ID == "" {
+		panic(exception.NotFoundError{Message: "story not found"})
+	}
+	if story.UserID != userID {
+		panic(exception.NoAccessError{Message: "can't manage other person story"})
+	}
+	return story
+}
+
+// checkAccess allows the owner, and otherwise needs an unblocked viewer that follows private accounts.
+func (s *serviceImpl) checkAccess(tx *gorm.DB, ownerID, viewerID string) {
+	if ownerID == viewerID {
+		return
+	}
+
+	owner := s.userRepository.FindById(tx, ownerID)
+	if owner.ID == "" || owner.IsSuspended || s.relationRepository.IsBlocked(tx, ownerID, viewerID) {
+		panic(exception.NotFoundError{Message: "user not found"})
+	}
+	if owner.IsPrivate && !s.relationRepository.IsFollowing(tx, viewerID, ownerID) {
+		panic(exception.NoAccessError{Message: "this account is private"})
+	}
+}
@@ -0,0 +1,127 @@
+package story
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"github.com/stretchr/testify/assert"
+	"go-api/app"
+	"go-api/model/blob"
+	"go-api/model/relation"
+	"go-api/model/user"
+	"image"
+	"image/png"
+	"testing"
+	"time"
+)
+
+func setupServiceTest(t *testing.T) (*serviceImpl, *time.Time) {
+	app.TestDBInit()
+	app.InitStorage(t.TempDir())
+	service := NewService(validator.New(), NewRepository(), user.NewRepository(), relation.NewRepository(), blob.NewRepository()).(*serviceImpl)
+	now := time.Now().Truncate(time.Second)
+	service.now = func() time.Time { return now }
+	return service, &now
+}
+
+func TestServiceImpl_Tray(t *testing.T) {
+	service, now := setupServiceTest(t)
+
+	var viewerID, earlierID, laterID string
+	for _, id := range []*string{&viewerID, &earlierID, &laterID} {
+		*id = uuid.NewV4().String()
+		assert.Nil(t, app.DB.Create(&user.User{ID: *id, Email: *id + "@example.com", Username: *id, DisplayName: *id, Role: user.RoleUser, CreatedAt: *now, UpdatedAt: *now}).Error)
+	}
+	for _, id := range []string{earlierID, laterID} {
+		assert.Nil(t, app.DB.Create(&relation.Follow{FollowerID: viewerID, FollowingID: id, CreatedAt: *now}).Error)
+	}
+
+	stories := map[string]*Story{}
+	for ownerID, age := range map[string]time.Duration{earlierID: 2 * time.Hour, laterID: time.Hour} {
+		stories[ownerID] = &Story{ID: uuid.NewV4().String(), UserID: ownerID, Path: Prefix + ownerID + "/story.png", CreatedAt: now.Add(-age), ExpiresAt: now.Add(Lifetime - age)}
+		assert.Nil(t, app.DB.Create(stories[ownerID]).Error)
+	}
+	trayIDs := func() []string {
+		ids := []string{}
+		for _, item := range service.FindTray(context.Background(), viewerID) {
+			ids = append(ids, item.UserID)
+		}
+		return ids
+	}
+
+	t.Run("tray should list the latest stories first", func(t *testing.T) {
+		tray := service.FindTray(context.Background(), viewerID)
+		assert.Equal(t, []string{laterID, earlierID}, trayIDs())
+		assert.Equal(t, int64(1), tray[0].UnseenCount)
+	})
+
+	t.Run("seen accounts should leave the tray", func(t *testing.T) {
+		service.View(context.Background(), stories[laterID].ID, viewerID)
+		assert.Equal(t, []string{earlierID}, trayIDs())
+	})
+
+	t.Run("expired stories should leave the tray and the listing", func(t *testing.T) {
+		*now = now.Add(Lifetime - time.Hour)
+		assert.Empty(t, trayIDs())
+		assert.Empty(t, service.FindByUserID(context.Background(), earlierID, viewerID))
+		assert.Panics(t, func() {
+			service.View(context.Background(), stories[earlierID].ID, viewerID)
+		})
+		assert.NotPanics(t, func() {
+			service.View(context.Background(), stories[earlierID].ID, earlierID)
+		})
+	})
+}
+
+func TestServiceImpl_Lifecycle(t *testing.T) {
+	service, now := setupServiceTest(t)
+	ownerID := uuid.NewV4().String()
+	assert.Nil(t, app.DB.Create(&user.User{ID: ownerID, Email: ownerID + "@example.com", Username: ownerID, DisplayName: ownerID, Role: user.RoleUser, CreatedAt: *now, UpdatedAt: *now}).Error)
+
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 4, 4))))
+	create := func() *Response {
+		return service.Create(context.Background(), &CreateRequest{UserID: ownerID, File: bytes.NewReader(buf.Bytes())})
+	}
+	refCount := func(storyID string) int64 {
+		story := service.storyRepository.FindByStoryID(app.DB, storyID)
+		var b blob.Blob
+		assert.Nil(t, app.DB.Where("hash = ?", story.Hash).Find(&b).Error)
+		return b.RefCount
+	}
+
+	kept, dropped := create(), create()
+
+	t.Run("same image should be stored once as a blob", func(t *testing.T) {
+		keptStory := service.storyRepository.FindByStoryID(app.DB, kept.StoryID)
+		droppedStory := service.storyRepository.FindByStoryID(app.DB, dropped.StoryID)
+		assert.Equal(t, keptStory.Path, droppedStory.Path)
+		assert.Contains(t, keptStory.Path, blob.Prefix)
+		assert.Equal(t, int64(2), refCount(kept.StoryID))
+	})
+
+	t.Run("archived stories should outlive cleanup", func(t *testing.T) {
+		service.Archive(context.Background(), kept.StoryID, ownerID)
+		*now = now.Add(Lifetime)
+		service.Cleanup(context.Background())
+
+		archive := service.FindArchive(context.Background(), ownerID)
+		var ids []string
+		for _, res := range archive {
+			ids = append(ids, res.StoryID)
+		}
+		assert.Contains(t, ids, kept.StoryID)
+		assert.Empty(t, service.storyRepository.FindByStoryID(app.DB, dropped.StoryID).ID)
+	})
+
+	t.Run("cleanup and delete should release the blob", func(t *testing.T) {
+		assert.Equal(t, int64(1), refCount(kept.StoryID))
+
+		hash := service.storyRepository.FindByStoryID(app.DB, kept.StoryID).Hash
+		service.Delete(context.Background(), kept.StoryID, ownerID)
+		var b blob.Blob
+		assert.Nil(t, app.DB.Where("hash = ?", hash).Find(&b).Error)
+		assert.Zero(t, b.RefCount)
+	})
+}
@@ -0,0 +1,39 @@
+package story
+
+import (
+	"io"
+	"time"
+)
+
+type (
+	CreateRequest struct {
+		UserID string    `validate:"required" json:"user_id"`
+		File   io.Reader `validate:"required" json:"-"`
+	}
+
+	Response struct {
+		StoryID    string    `json:"story_id"`
+		UserID     string    `json:"user_id"`
+		ShareURL   string    `json:"share_url"`
+		IsArchived bool      `json:"is_archived"`
+		CreatedAt  time.Time `json:"created_at"`
+		ExpiresAt  time.Time `json:"expires_at"`
+	}
+
+	TrayResponse struct {
+		UserID            string    `json:"user_id"`
+		Username          string    `json:"username"`
+		DisplayName       string    `json:"display_name"`
+		ProfilePictureURL string    `json:"profile_picture_url"`
+		UnseenCount       int64     `json:"unseen_count"`
+		LatestAt          time.Time `json:"latest_at"`
+	}
+
+	ViewerResponse struct {
+		UserID            string    `json:"user_id"`
+		Username          string    `json:"username"`
+		DisplayName       string    `json:"display_name"`
+		ProfilePictureURL string    `json:"profile_picture_url"`
+		ViewedAt          time.Time `json:"viewed_at"`
+	}
+)
@@ -0,0 +1,30 @@
+package tag
+
+import "time"
+
+// MaxPerResource is how many people can be tagged on a single photo or video.
+const MaxPerResource = 20
+
+// Tag marks a user at a point of a resource, X and Y are fractions of its width and height.
+type Tag struct {
+	ResourceID string    `gorm:"column:resource_id; primaryKey"`
+	UserID     string    `gorm:"column:user_id; primaryKey"`
+	PostID     string    `gorm:"column:post_id; not null"`
+	X          float64   `gorm:"column:x; not null"`
+	Y          float64   `gorm:"column:y; not null"`
+	CreatedAt  time.Time `gorm:"column:created_at; not null"`
+	Username   string    `gorm:"->; column:username"`
+}
+
+func (Tag) TableName() string {
+	return "resource_tags"
+}
+
+func (t *Tag) ToResponse() Response {
+	return Response{
+		UserID:   t.UserID,
+		Username: t.Username,
+		X:        t.X,
+		Y:        t.Y,
+	}
+}
@@ -0,0 +1,71 @@
+package tag
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+)
+
+type Repository interface {
+	// ReplaceForResource swaps every tag of the resource for tags.
+	ReplaceForResource(tx *gorm.DB, resourceID string, tags []*Tag)
+	DeleteByResourceID(tx *gorm.DB, resourceID string)
+	DeleteByPostID(tx *gorm.DB, postID string)
+	// DeleteByPostAndUser removes the user's tags from every resource of the post and returns how many were removed.
+	DeleteByPostAndUser(tx *gorm.DB, postID, userID string) int64
+	FindByPostID(tx *gorm.DB, postID string) []*Tag
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (r *repositoryImpl) ReplaceForResource(tx *gorm.DB, resourceID string, tags []*Tag) {
+	r.DeleteByResourceID(tx, resourceID)
+	if len(tags) == 0 {
+		return
+	}
+
+	err := tx.Create(&tags).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) DeleteByResourceID(tx *gorm.DB, resourceID string) {
+	err := tx.Where("resource_id = ?", resourceID).Delete(&Tag{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) DeleteByPostID(tx *gorm.DB, postID string) {
+	err := tx.Where("post_id = ?", postID).Delete(&Tag{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) DeleteByPostAndUser(tx *gorm.DB, postID, userID string) int64 {
+	result := tx.Where("post_id = ? AND user_id = ?", postID, userID).Delete(&Tag{})
+	if result.Error != nil {
+		panic(exception.DatabaseError{Message: result.Error.Error()})
+	}
+	return result.RowsAffected
+}
+
+func (*repositoryImpl) FindByPostID(tx *gorm.DB, postID string) []*Tag {
+	var tags []*Tag
+	err := tx.Table("resource_tags t").
+		Select("t.*, u.username").
+		Joins("JOIN users u ON u.user_id = t.user_id").
+		Where("t.post_id = ?", postID).
+		Order("t.created_at asc").
+		Find(&tags).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return tags
+}
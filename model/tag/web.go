@@ -0,0 +1,16 @@
+package tag
+
+type (
+	Request struct {
+		UserID string  `validate:"required" json:"user_id"`
+		X      float64 `validate:"min=0,max=1" json:"x"`
+		Y      float64 `validate:"min=0,max=1" json:"y"`
+	}
+
+	Response struct {
+		UserID   string  `json:"user_id"`
+		Username string  `json:"username"`
+		X        float64 `json:"x"`
+		Y        float64 `json:"y"`
+	}
+)
@@ -0,0 +1,59 @@
+package token
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"go-api/model"
+	"net/http"
+)
+
+type Controller interface {
+	Create(ctx *gin.Context)
+	FindAll(ctx *gin.Context)
+	Revoke(ctx *gin.Context)
+}
+
+type controllerImpl struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return &controllerImpl{service: service}
+}
+
+func (c *controllerImpl) Create(ctx *gin.Context) {
+	var req *CreateRequest
+	err := ctx.ShouldBindWith(&req, binding.JSON)
+	if err != nil {
+		panic(err)
+	}
+
+	req.UserID = ctx.GetHeader("User_id")
+	res := c.service.Create(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusCreated,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) FindAll(ctx *gin.Context) {
+	userID := ctx.GetHeader("User_id")
+	res := c.service.FindByUserID(ctx, userID)
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) Revoke(ctx *gin.Context) {
+	c.service.Revoke(ctx, &RevokeRequest{
+		TokenID: ctx.Param("tokenID"),
+		UserID:  ctx.GetHeader("User_id"),
+	})
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+	})
+}
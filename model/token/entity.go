@@ -0,0 +1,47 @@
+package token
+
+import (
+	"strings"
+	"time"
+)
+
+type Token struct {
+	ID         string     `gorm:"column:token_id; primaryKey"`
+	UserID     string     `gorm:"column:user_id; not null"`
+	Name       string     `gorm:"column:name; not null"`
+	Prefix     string     `gorm:"column:prefix; not null"`
+	Hash       string     `gorm:"column:hash; not null"`
+	Scopes     string     `gorm:"column:scopes; not null"`
+	IsRevoked  bool       `gorm:"column:is_revoked;"`
+	ExpiresAt  *time.Time `gorm:"column:expires_at;"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at;"`
+	CreatedAt  time.Time  `gorm:"column:created_at; not null"`
+}
+
+func (t *Token) ScopeList() []string {
+	if t.Scopes == "" {
+		return nil
+	}
+	return strings.Split(t.Scopes, ",")
+}
+
+func (t *Token) HasScope(scope string) bool {
+	for _, s := range t.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *Token) ToResponse() *Response {
+	return &Response{
+		TokenID:    t.ID,
+		Name:       t.Name,
+		Prefix:     t.Prefix,
+		Scopes:     t.ScopeList(),
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+		CreatedAt:  t.CreatedAt,
+	}
+}
@@ -0,0 +1,91 @@
+package token
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+	"time"
+)
+
+type Repository interface {
+	Create(tx *gorm.DB, token *Token)
+	Revoke(tx *gorm.DB, tokenID string)
+	RevokeByUserID(tx *gorm.DB, userID string)
+	Touch(tx *gorm.DB, tokenID string, lastUsedAt time.Time)
+	FindByTokenID(tx *gorm.DB, tokenID string) *Token
+	FindByHash(tx *gorm.DB, hash string) *Token
+	FindByUserID(tx *gorm.DB, userID string) []*Token
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (*repositoryImpl) Create(tx *gorm.DB, token *Token) {
+	err := tx.Create(&token).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Revoke(tx *gorm.DB, tokenID string) {
+	err := tx.Model(&Token{}).
+		Where("token_id = ?", tokenID).
+		Update("is_revoked", true).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Touch(tx *gorm.DB, tokenID string, lastUsedAt time.Time) {
+	err := tx.Model(&Token{}).
+		Where("token_id = ?", tokenID).
+		Update("last_used_at", lastUsedAt).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindByTokenID(tx *gorm.DB, tokenID string) *Token {
+	var token *Token
+	err := tx.Where("token_id = ?", tokenID).
+		Limit(1).
+		Find(&token).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return token
+}
+
+func (*repositoryImpl) FindByHash(tx *gorm.DB, hash string) *Token {
+	var token *Token
+	err := tx.Where("hash = ?", hash).
+		Limit(1).
+		Find(&token).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return token
+}
+
+func (*repositoryImpl) FindByUserID(tx *gorm.DB, userID string) []*Token {
+	var tokens []*Token
+	err := tx.Where("user_id = ? AND is_revoked = ?", userID, false).
+		Order("created_at desc").
+		Find(&tokens).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return tokens
+}
+
+func (*repositoryImpl) RevokeByUserID(tx *gorm.DB, userID string) {
+	err := tx.Model(&Token{}).
+		Where("user_id = ?", userID).
+		Update("is_revoked", true).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
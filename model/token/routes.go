@@ -0,0 +1,10 @@
+package token
+
+import "github.com/gin-gonic/gin"
+
+func InitRoutes(router *gin.RouterGroup, controller Controller) {
+	tokenGroup := router.Group("/token")
+	tokenGroup.GET("/", controller.FindAll)
+	tokenGroup.POST("/", controller.Create)
+	tokenGroup.DELETE("/:tokenID", controller.Revoke)
+}
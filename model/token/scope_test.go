@@ -0,0 +1,47 @@
+package token
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"testing"
+)
+
+func TestScopeFor(t *testing.T) {
+	t.Run("reads should need read scope", func(t *testing.T) {
+		scope, ok := ScopeFor(http.MethodGet, "/api/post/:postID")
+		assert.True(t, ok)
+		assert.Equal(t, ScopeRead, scope)
+	})
+
+	t.Run("writes should need the scope of their route", func(t *testing.T) {
+		scope, ok := ScopeFor(http.MethodPost, "/api/comment/:postID")
+		assert.True(t, ok)
+		assert.Equal(t, ScopeCommentWrite, scope)
+
+		scope, ok = ScopeFor(http.MethodPut, "/api/user/edit/")
+		assert.True(t, ok)
+		assert.Equal(t, ScopeUserWrite, scope)
+	})
+
+	t.Run("sensitive routes should not be allowed", func(t *testing.T) {
+		_, ok := ScopeFor(http.MethodPut, "/api/user/password/")
+		assert.False(t, ok)
+
+		_, ok = ScopeFor(http.MethodGet, "/api/session/")
+		assert.False(t, ok)
+
+		_, ok = ScopeFor(http.MethodPost, "/api/token/")
+		assert.False(t, ok)
+	})
+
+	t.Run("routes that aren't listed should not be allowed", func(t *testing.T) {
+		_, ok := ScopeFor(http.MethodGet, "/api/post/:postID/unlisted")
+		assert.False(t, ok)
+
+		_, ok = ScopeFor(http.MethodPost, "/api/user/")
+		assert.False(t, ok)
+
+		_, ok = ScopeFor(http.MethodPut, "/api/user/avatar/default/:username")
+		assert.False(t, ok)
+	})
+}
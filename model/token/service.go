@@ -0,0 +1,136 @@
+package token
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"go-api/app"
+	"go-api/exception"
+	"go-api/helper"
+	"strings"
+	"time"
+)
+
+const (
+	// RawPrefix tells personal access tokens apart from JWTs in the Authorization header.
+	RawPrefix = "pat_"
+
+	touchInterval = time.Minute
+)
+
+type Service interface {
+	Create(ctx context.Context, req *CreateRequest) *CreateResponse
+	Revoke(ctx context.Context, req *RevokeRequest)
+	FindByUserID(ctx context.Context, userID string) []*Response
+	Authenticate(ctx context.Context, raw string) *Token
+}
+
+type serviceImpl struct {
+	validate        *validator.Validate
+	tokenRepository Repository
+}
+
+func NewService(validate *validator.Validate, tokenRepository Repository) Service {
+	return &serviceImpl{validate: validate, tokenRepository: tokenRepository}
+}
+
+func Hash(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+func generate() string {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		panic(err)
+	}
+	return RawPrefix + base64.RawURLEncoding.EncodeToString(b)
+}
+
+func (s *serviceImpl) Create(ctx context.Context, req *CreateRequest) *CreateResponse {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	raw := generate()
+	token := &Token{
+		ID:        uuid.NewV4().String(),
+		UserID:    req.UserID,
+		Name:      req.Name,
+		Prefix:    raw[:len(RawPrefix)+8],
+		Hash:      Hash(raw),
+		Scopes:    strings.Join(req.Scopes, ","),
+		CreatedAt: time.Now(),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := token.CreatedAt.AddDate(0, 0, req.ExpiresInDays)
+		token.ExpiresAt = &expiresAt
+	}
+	s.tokenRepository.Create(tx, token)
+
+	return &CreateResponse{
+		Token:    raw,
+		Response: token.ToResponse(),
+	}
+}
+
+func (s *serviceImpl) Revoke(ctx context.Context, req *RevokeRequest) {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	token := s.tokenRepository.FindByTokenID(tx, req.TokenID)
+	if token.ID == "" || token.UserID != req.UserID {
+		panic(exception.NotFoundError{Message: "token not found"})
+	}
+
+	s.tokenRepository.Revoke(tx, token.ID)
+}
+
+func (s *serviceImpl) FindByUserID(ctx context.Context, userID string) []*Response {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	var response []*Response
+	tokens := s.tokenRepository.FindByUserID(tx, userID)
+	for _, t := range tokens {
+		response = append(response, t.ToResponse())
+	}
+	return response
+}
+
+// Authenticate panics with a TokenError when the raw token is unknown, revoked or expired.
+func (s *serviceImpl) Authenticate(ctx context.Context, raw string) *Token {
+	db := app.GetDB().WithContext(ctx)
+	token := s.tokenRepository.FindByHash(db, Hash(raw))
+	if token.ID == "" {
+		panic(exception.TokenError{Message: "invalid access token"})
+	}
+
+	if token.IsRevoked {
+		panic(exception.TokenError{Message: "access token has been revoked"})
+	}
+
+	now := time.Now()
+	if token.ExpiresAt != nil && now.After(*token.ExpiresAt) {
+		panic(exception.TokenError{Message: "access token has expired"})
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= touchInterval {
+		s.tokenRepository.Touch(db, token.ID, now)
+	}
+	return token
+}
@@ -0,0 +1,33 @@
+package token
+
+import "time"
+
+type (
+	CreateRequest struct {
+		UserID        string   `validate:"required" json:"user_id"`
+		Name          string   `validate:"required,max=64" json:"name"`
+		Scopes        []string `validate:"required,min=1,dive,oneof=read post:write comment:write like:write user:write" json:"scopes"`
+		ExpiresInDays int      `validate:"min=0,max=365" json:"expires_in_days"`
+	}
+
+	RevokeRequest struct {
+		TokenID string `validate:"required" json:"token_id"`
+		UserID  string `validate:"required" json:"user_id"`
+	}
+
+	// CreateResponse is the only place the raw token is ever returned.
+	CreateResponse struct {
+		Token string `json:"token"`
+		*Response
+	}
+
+	Response struct {
+		TokenID    string     `json:"token_id"`
+		Name       string     `json:"name"`
+		Prefix     string     `json:"prefix"`
+		Scopes     []string   `json:"scopes"`
+		ExpiresAt  *time.Time `json:"expires_at"`
+		LastUsedAt *time.Time `json:"last_used_at"`
+		CreatedAt  time.Time  `json:"created_at"`
+	}
+)
@@ -0,0 +1,133 @@
+package upload
+
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/exception"
+	"go-api/model"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// Controller speaks the tus resumable upload protocol, its responses carry state in headers instead of a body.
+type Controller interface {
+	Options(ctx *gin.Context)
+	Create(ctx *gin.Context)
+	Head(ctx *gin.Context)
+	Patch(ctx *gin.Context)
+	Terminate(ctx *gin.Context)
+}
+
+type controllerImpl struct {
+	service Service
+}
+
+func NewController(service Service) Controller {
+	return &controllerImpl{service: service}
+}
+
+func (c *controllerImpl) Options(ctx *gin.Context) {
+	ctx.Header("Tus-Resumable", TusVersion)
+	ctx.Header("Tus-Version", TusVersion)
+	ctx.Header("Tus-Extension", TusExtensions)
+	ctx.Header("Tus-Max-Size", strconv.FormatInt(MaxSize, 10))
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *controllerImpl) Create(ctx *gin.Context) {
+	if !checkVersion(ctx) {
+		return
+	}
+
+	length, err := strconv.ParseInt(ctx.GetHeader("Upload-Length"), 10, 64)
+	if err != nil {
+		panic(exception.FieldError{Field: "Upload-Length", Message: "upload length is required"})
+	}
+
+	metadata := ctx.GetHeader("Upload-Metadata")
+	if _, err = ParseMetadata(metadata); err != nil {
+		panic(exception.FieldError{Field: "Upload-Metadata", Message: err.Error()})
+	}
+
+	upload := c.service.Create(ctx, &CreateRequest{
+		UserID:   ctx.GetHeader("User_id"),
+		Length:   length,
+		Metadata: metadata,
+	})
+	ctx.Header("Location", ctx.Request.URL.Path+upload.ID)
+	ctx.Header("Upload-Offset", "0")
+	ctx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	ctx.Status(http.StatusCreated)
+}
+
+func (c *controllerImpl) Head(ctx *gin.Context) {
+	if !checkVersion(ctx) {
+		return
+	}
+
+	upload := c.service.Find(ctx, ctx.Param("uploadID"), ctx.GetHeader("User_id"))
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	ctx.Header("Upload-Length", strconv.FormatInt(upload.Length, 10))
+	ctx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	if upload.Metadata != "" {
+		ctx.Header("Upload-Metadata", upload.Metadata)
+	}
+	ctx.Status(http.StatusOK)
+}
+
+func (c *controllerImpl) Patch(ctx *gin.Context) {
+	if !checkVersion(ctx) {
+		return
+	}
+
+	contentType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+	if contentType != OffsetContentType {
+		abort(ctx, http.StatusUnsupportedMediaType, "Unsupported Media Type", "chunks must be sent as "+OffsetContentType)
+		return
+	}
+
+	offset, err := strconv.ParseInt(ctx.GetHeader("Upload-Offset"), 10, 64)
+	if err != nil {
+		panic(exception.FieldError{Field: "Upload-Offset", Message: "upload offset is required"})
+	}
+
+	upload := c.service.Append(ctx, &AppendRequest{
+		UploadID: ctx.Param("uploadID"),
+		UserID:   ctx.GetHeader("User_id"),
+		Offset:   offset,
+		Body:     ctx.Request.Body,
+	})
+	ctx.Header("Upload-Offset", strconv.FormatInt(upload.Offset, 10))
+	ctx.Header("Upload-Expires", upload.ExpiresAt.UTC().Format(http.TimeFormat))
+	ctx.Status(http.StatusNoContent)
+}
+
+func (c *controllerImpl) Terminate(ctx *gin.Context) {
+	if !checkVersion(ctx) {
+		return
+	}
+
+	c.service.Terminate(ctx, ctx.Param("uploadID"), ctx.GetHeader("User_id"))
+	ctx.Status(http.StatusNoContent)
+}
+
+// checkVersion answers 412 when the client speaks another protocol version.
+func checkVersion(ctx *gin.Context) bool {
+	ctx.Header("Tus-Resumable", TusVersion)
+	if ctx.GetHeader("Tus-Resumable") == TusVersion {
+		return true
+	}
+
+	ctx.Header("Tus-Version", TusVersion)
+	abort(ctx, http.StatusPreconditionFailed, "Precondition Failed", "unsupported tus version")
+	return false
+}
+
+func abort(ctx *gin.Context, code int, status, message string) {
+	model.Abort(ctx, &model.WebResponse{
+		Code:   code,
+		Status: status,
+		Errors: []map[string]string{{"error": message}},
+	})
+}
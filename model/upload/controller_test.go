@@ -0,0 +1,68 @@
+package upload_test
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"go-api/middleware"
+	"go-api/model/upload"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// setupControllerTest has no service, every case here is answered before it's reached.
+func setupControllerTest() *gin.Engine {
+	router := gin.New()
+	router.Use(gin.CustomRecovery(middleware.PanicHandler))
+	upload.InitRoutes(router.Group("/api"), upload.NewController(nil))
+	return router
+}
+
+func TestControllerImpl_Options(t *testing.T) {
+	router := setupControllerTest()
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/api/upload/", nil))
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, upload.TusVersion, rec.Header().Get("Tus-Version"))
+	assert.Equal(t, upload.TusExtensions, rec.Header().Get("Tus-Extension"))
+	assert.NotEmpty(t, rec.Header().Get("Tus-Max-Size"))
+}
+
+func TestControllerImpl_Create(t *testing.T) {
+	t.Run("missing tus version should return precondition failed", func(t *testing.T) {
+		router := setupControllerTest()
+		req := httptest.NewRequest(http.MethodPost, "/api/upload/", nil)
+		req.Header.Set("Upload-Length", "100")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+		assert.Equal(t, upload.TusVersion, rec.Header().Get("Tus-Version"))
+	})
+
+	t.Run("missing length should return bad request", func(t *testing.T) {
+		router := setupControllerTest()
+		req := httptest.NewRequest(http.MethodPost, "/api/upload/", nil)
+		req.Header.Set("Tus-Resumable", upload.TusVersion)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+func TestControllerImpl_Patch(t *testing.T) {
+	t.Run("wrong content type should return unsupported media type", func(t *testing.T) {
+		router := setupControllerTest()
+		req := httptest.NewRequest(http.MethodPatch, "/api/upload/some-id", strings.NewReader("chunk"))
+		req.Header.Set("Tus-Resumable", upload.TusVersion)
+		req.Header.Set("Upload-Offset", "0")
+		req.Header.Set("Content-Type", "application/json")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	})
+}
@@ -0,0 +1,18 @@
+package upload
+
+import "time"
+
+type Upload struct {
+	ID        string    `gorm:"column:upload_id; primaryKey"`
+	UserID    string    `gorm:"column:user_id; not null"`
+	Length    int64     `gorm:"column:length; not null"`
+	Offset    int64     `gorm:"column:offset; not null"`
+	Metadata  string    `gorm:"column:metadata;"`
+	Path      string    `gorm:"column:path; not null"`
+	CreatedAt time.Time `gorm:"column:created_at; not null"`
+	ExpiresAt time.Time `gorm:"column:expires_at; not null"`
+}
+
+func (u *Upload) IsComplete() bool {
+	return u.Offset == u.Length
+}
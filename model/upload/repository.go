@@ -0,0 +1,83 @@
+package upload
+
+import (
+	"go-api/exception"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"time"
+)
+
+type Repository interface {
+	Create(tx *gorm.DB, upload *Upload)
+	Delete(tx *gorm.DB, uploadID string)
+	UpdateOffset(tx *gorm.DB, uploadID string, offset int64, expiresAt time.Time)
+	FindByUploadID(tx *gorm.DB, uploadID string) *Upload
+	// FindForUpdate locks the upload row so concurrent chunks of the same upload are written one at a time.
+	FindForUpdate(tx *gorm.DB, uploadID string) *Upload
+	FindExpired(tx *gorm.DB, now time.Time, limit int) []*Upload
+}
+
+type repositoryImpl struct {
+}
+
+func NewRepository() Repository {
+	return &repositoryImpl{}
+}
+
+func (*repositoryImpl) Create(tx *gorm.DB, upload *Upload) {
+	err := tx.Create(&upload).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Delete(tx *gorm.DB, uploadID string) {
+	err := tx.Where("upload_id = ?", uploadID).Delete(&Upload{}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) UpdateOffset(tx *gorm.DB, uploadID string, offset int64, expiresAt time.Time) {
+	err := tx.Model(&Upload{}).
+		Where("upload_id = ?", uploadID).
+		Updates(map[string]interface{}{"offset": offset, "expires_at": expiresAt}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) FindByUploadID(tx *gorm.DB, uploadID string) *Upload {
+	var upload Upload
+	err := tx.Where("upload_id = ?", uploadID).
+		Limit(1).
+		Find(&upload).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return &upload
+}
+
+func (*repositoryImpl) FindForUpdate(tx *gorm.DB, uploadID string) *Upload {
+	var upload Upload
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("upload_id = ?", uploadID).
+		Limit(1).
+		Find(&upload).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return &upload
+}
+
+func (*repositoryImpl) FindExpired(tx *gorm.DB, now time.Time, limit int) []*Upload {
+	var uploads []*Upload
+	err := tx.Where("expires_at <= ?", now).
+		Order("expires_at asc").
+		Limit(limit).
+		Find(&uploads).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return uploads
+}
@@ -0,0 +1,12 @@
+package upload
+
+import "github.com/gin-gonic/gin"
+
+func InitRoutes(router *gin.RouterGroup, controller Controller) {
+	uploadGroup := router.Group("/upload")
+	uploadGroup.OPTIONS("/", controller.Options)
+	uploadGroup.POST("/", controller.Create)
+	uploadGroup.HEAD("/:uploadID", controller.Head)
+	uploadGroup.PATCH("/:uploadID", controller.Patch)
+	uploadGroup.DELETE("/:uploadID", controller.Terminate)
+}
@@ -0,0 +1,221 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"github.com/go-playground/validator"
+	uuid "github.com/satori/go.uuid"
+	"go-api/app"
+	"go-api/exception"
+	"go-api/helper"
+	"go-api/metrics"
+	"go-api/model/resource"
+	"gorm.io/gorm"
+	"io"
+	"os"
+	"time"
+)
+
+type Service interface {
+	Create(ctx context.Context, req *CreateRequest) *Upload
+	Find(ctx context.Context, uploadID, userID string) *Upload
+	// Append writes a chunk at the given offset and returns the upload with its new offset.
+	Append(ctx context.Context, req *AppendRequest) *Upload
+	Terminate(ctx context.Context, uploadID, userID string)
+	// Claim turns a complete upload into a stored resource within tx, the caller's transaction, so the
+	// upload is only used up if that commits. The caller releases it once it has.
+	Claim(tx *gorm.DB, uploadID, userID string) *Claimed
+	// Release removes the files of uploads whose claiming transaction committed.
+	Release(claimed []*Claimed)
+	// Cleanup removes uploads that weren't completed or claimed before they expired.
+	Cleanup(ctx context.Context)
+}
+
+const (
+	// Lifetime is how long an upload is kept after its last chunk.
+	Lifetime        = 24 * time.Hour
+	CleanupInterval = time.Hour
+	cleanupBatch    = 100
+)
+
+var receivedBytes = metrics.NewCounter("upload_received_bytes_total", "Bytes of upload chunks written to storage.")
+
+type serviceImpl struct {
+	validate         *validator.Validate
+	uploadRepository Repository
+	now              func() time.Time
+}
+
+func NewService(validate *validator.Validate, uploadRepository Repository) Service {
+	return &serviceImpl{validate: validate, uploadRepository: uploadRepository, now: time.Now}
+}
+
+func (s *serviceImpl) Create(ctx context.Context, req *CreateRequest) *Upload {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	if req.Length > MaxSize {
+		panic(exception.FieldError{Field: "Upload-Length", Message: "upload is too large"})
+	}
+
+	id := uuid.NewV4().String()
+	upload := &Upload{
+		ID:        id,
+		UserID:    req.UserID,
+		Length:    req.Length,
+		Metadata:  req.Metadata,
+		Path:      "uploads/" + id,
+		CreatedAt: s.now(),
+		ExpiresAt: s.now().Add(Lifetime),
+	}
+
+	err = app.GetStorage().Save(upload.Path, bytes.NewReader(nil))
+	if err != nil {
+		panic(err)
+	}
+
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	s.uploadRepository.Create(tx, upload)
+	return upload
+}
+
+func (s *serviceImpl) Find(ctx context.Context, uploadID, userID string) *Upload {
+	tx := app.ReadPrimaryTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	upload := s.uploadRepository.FindByUploadID(tx, uploadID)
+	checkOwner(upload, userID)
+	return upload
+}
+
+func (s *serviceImpl) Append(ctx context.Context, req *AppendRequest) *Upload {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	upload, err := func() (*Upload, error) {
+		tx := app.WriteTx(ctx)
+		defer helper.TXCommitOrRollback(tx)
+
+		upload := s.uploadRepository.FindForUpdate(tx, req.UploadID)
+		checkOwner(upload, req.UserID)
+
+		// the stored file is the source of truth, a chunk interrupted by a crash may have gone past the row
+		upload.Offset = storedSize(upload.Path)
+		if req.Offset != upload.Offset {
+			panic(exception.ConflictError{Message: "upload offset doesn't match"})
+		}
+
+		written, err := app.GetStorage().Append(upload.Path, io.LimitReader(req.Body, upload.Length-upload.Offset))
+		receivedBytes.Add(float64(written))
+		upload.Offset += written
+		upload.ExpiresAt = s.now().Add(Lifetime)
+
+		// whatever arrived before a broken connection is kept so the client can resume from it
+		s.uploadRepository.UpdateOffset(tx, upload.ID, upload.Offset, upload.ExpiresAt)
+		return upload, err
+	}()
+	if err != nil {
+		panic(err)
+	}
+	return upload
+}
+
+func (s *serviceImpl) Terminate(ctx context.Context, uploadID, userID string) {
+	upload := func() *Upload {
+		tx := app.WriteTx(ctx)
+		defer helper.TXCommitOrRollback(tx)
+
+		upload := s.uploadRepository.FindForUpdate(tx, uploadID)
+		checkOwner(upload, userID)
+		s.uploadRepository.Delete(tx, upload.ID)
+		return upload
+	}()
+
+	_ = app.GetStorage().Delete(upload.Path)
+}
+
+// Claimed is an upload stored as Resource, its file stays until it's released.
+type Claimed struct {
+	Resource *resource.Resource
+	path     string
+}
+
+func (s *serviceImpl) Claim(tx *gorm.DB, uploadID, userID string) *Claimed {
+	upload := s.uploadRepository.FindForUpdate(tx, uploadID)
+	checkOwner(upload, userID)
+	if !upload.IsComplete() {
+		panic(exception.FieldError{Field: "upload_ids", Message: "upload " + upload.ID + " is not complete"})
+	}
+
+	// a rollback keeps the upload row and its file, so the client can still use it
+	res := s.store(app.GetDB().WithContext(tx.Statement.Context), upload)
+	s.uploadRepository.Delete(tx, upload.ID)
+	return &Claimed{Resource: res, path: upload.Path}
+}
+
+func (s *serviceImpl) Release(claimed []*Claimed) {
+	for _, c := range claimed {
+		_ = app.GetStorage().Delete(c.path)
+	}
+}
+
+func (s *serviceImpl) Cleanup(ctx context.Context) {
+	for {
+		uploads := func() []*Upload {
+			tx := app.WriteTx(ctx)
+			defer helper.TXCommitOrRollback(tx)
+
+			uploads := s.uploadRepository.FindExpired(tx, s.now(), cleanupBatch)
+			for _, upload := range uploads {
+				s.uploadRepository.Delete(tx, upload.ID)
+			}
+			return uploads
+		}()
+
+		for _, upload := range uploads {
+			_ = app.GetStorage().Delete(upload.Path)
+		}
+
+		if len(uploads) < cleanupBatch {
+			return
+		}
+	}
+}
+
+// store registers the blobs on db apart from the claim, the claim's transaction would keep them locked.
+func (s *serviceImpl) store(db *gorm.DB, upload *Upload) *resource.Resource {
+	object, err := app.GetStorage().Open(upload.Path)
+	if err != nil {
+		panic(err)
+	}
+	defer object.Close()
+
+	return resource.Store(db, object, object.Size)
+}
+
+func checkOwner(upload *Upload, userID string) {
+	if upload.ID == "" {
+		panic(exception.NotFoundError{Message: "upload not found"})
+	}
+	if upload.UserID != userID {
+		panic(exception.NoAccessError{Message: "can't use other person upload"})
+	}
+}
+
+func storedSize(path string) int64 {
+	object, err := app.GetStorage().Open(path)
+	if os.IsNotExist(err) {
+		return 0
+	}
+	if err != nil {
+		panic(err)
+	}
+	defer object.Close()
+	return object.Size
+}
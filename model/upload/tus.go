@@ -0,0 +1,43 @@
+package upload
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+const (
+	TusVersion    = "1.0.0"
+	TusExtensions = "creation,termination,expiration"
+	// OffsetContentType is the only content type a chunk can be sent with.
+	OffsetContentType = "application/offset+octet-stream"
+)
+
+// ParseMetadata decodes an Upload-Metadata header, comma separated keys each followed by an optional base64 value.
+func ParseMetadata(raw string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if strings.TrimSpace(raw) == "" {
+		return metadata, nil
+	}
+
+	for _, pair := range strings.Split(raw, ",") {
+		fields := strings.Fields(pair)
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, errors.New("malformed upload metadata")
+		}
+
+		var value []byte
+		if len(fields) == 2 {
+			var err error
+			value, err = base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, errors.New("upload metadata value of " + fields[0] + " isn't base64")
+			}
+		}
+		if _, ok := metadata[fields[0]]; ok {
+			return nil, errors.New("upload metadata key " + fields[0] + " is repeated")
+		}
+		metadata[fields[0]] = string(value)
+	}
+	return metadata, nil
+}
@@ -0,0 +1,33 @@
+package upload
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestParseMetadata(t *testing.T) {
+	t.Run("pairs and bare keys should decode", func(t *testing.T) {
+		metadata, err := ParseMetadata("filename d29ybGRfZG9taW5hdGlvbl9wbGFuLnBkZg==,is_confidential")
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{
+			"filename":        "world_domination_plan.pdf",
+			"is_confidential": "",
+		}, metadata)
+	})
+
+	t.Run("empty header should return empty metadata", func(t *testing.T) {
+		metadata, err := ParseMetadata("")
+		assert.NoError(t, err)
+		assert.Empty(t, metadata)
+	})
+
+	t.Run("invalid base64 should fail", func(t *testing.T) {
+		_, err := ParseMetadata("filename not-base64!")
+		assert.Error(t, err)
+	})
+
+	t.Run("repeated key should fail", func(t *testing.T) {
+		_, err := ParseMetadata("filename YQ==,filename Yg==")
+		assert.Error(t, err)
+	})
+}
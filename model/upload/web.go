@@ -0,0 +1,24 @@
+package upload
+
+import (
+	"go-api/model/resource"
+	"io"
+)
+
+type (
+	CreateRequest struct {
+		UserID   string `validate:"required"`
+		Length   int64  `validate:"min=1"`
+		Metadata string `validate:"max=1024"`
+	}
+
+	AppendRequest struct {
+		UploadID string    `validate:"required"`
+		UserID   string    `validate:"required"`
+		Offset   int64     `validate:"min=0"`
+		Body     io.Reader `validate:"required"`
+	}
+)
+
+// MaxSize is the largest upload accepted, the biggest media a post can hold.
+const MaxSize = resource.MaxVideoBytes
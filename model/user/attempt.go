@@ -0,0 +1,219 @@
+package user
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// AttemptStore keeps failed login counters, keyed by account or client IP.
+type AttemptStore interface {
+	Get(key string) Attempt
+	// Update stores what fn makes of the stored attempt, concurrent updates of a key don't overwrite each other.
+	Update(key string, fn func(attempt Attempt) Attempt)
+	Delete(key string)
+	// Sweep deletes the attempts of keys starting with prefix that expired reports as over.
+	Sweep(prefix string, expired func(attempt Attempt) bool)
+}
+
+type Attempt struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time
+}
+
+type AttemptPolicy struct {
+	// BackoffAfter is the number of failures allowed before a delay is enforced.
+	BackoffAfter int
+	BaseDelay    time.Duration
+	MaxDelay     time.Duration
+	// LockoutAfter is the number of failures that locks the key for LockoutDuration.
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	// Window is how long a failure is remembered when no further attempt is made.
+	Window time.Duration
+}
+
+// AttemptCleanupInterval is how often attempts past their lockout and window are dropped.
+const AttemptCleanupInterval = 10 * time.Minute
+
+var (
+	AccountAttemptPolicy = AttemptPolicy{
+		BackoffAfter:    3,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    10,
+		LockoutDuration: 15 * time.Minute,
+		Window:          time.Hour,
+	}
+
+	// IPAttemptPolicy is looser than the account one since many clients can share an address.
+	IPAttemptPolicy = AttemptPolicy{
+		BackoffAfter:    10,
+		BaseDelay:       time.Second,
+		MaxDelay:        time.Minute,
+		LockoutAfter:    50,
+		LockoutDuration: 30 * time.Minute,
+		Window:          time.Hour,
+	}
+)
+
+type memoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempt
+}
+
+func NewMemoryAttemptStore() AttemptStore {
+	return &memoryAttemptStore{attempts: map[string]Attempt{}}
+}
+
+func (s *memoryAttemptStore) Get(key string) Attempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[key]
+}
+
+func (s *memoryAttemptStore) Update(key string, fn func(attempt Attempt) Attempt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[key] = fn(s.attempts[key])
+}
+
+func (s *memoryAttemptStore) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+}
+
+func (s *memoryAttemptStore) Sweep(prefix string, expired func(attempt Attempt) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, attempt := range s.attempts {
+		if strings.HasPrefix(key, prefix) && expired(attempt) {
+			delete(s.attempts, key)
+		}
+	}
+}
+
+// Throttle applies an AttemptPolicy on top of an AttemptStore, its keys are stored under prefix
+// so throttles with different policies can share a store.
+type Throttle struct {
+	store  AttemptStore
+	prefix string
+	policy AttemptPolicy
+	now    func() time.Time
+}
+
+func NewThrottle(store AttemptStore, prefix string, policy AttemptPolicy) *Throttle {
+	return &Throttle{store: store, prefix: prefix, policy: policy, now: time.Now}
+}
+
+// Wait returns how long the key has to wait before another attempt is allowed, zero when allowed.
+func (t *Throttle) Wait(key string) time.Duration {
+	return t.wait(t.current(key), t.now())
+}
+
+// Reserve counts an attempt against key before it's checked, in the same update that checks the key
+// isn't throttled, so concurrent attempts can't all pass before any of them fails. It returns how long
+// to wait when the key is throttled, the attempt isn't counted then.
+func (t *Throttle) Reserve(key string) time.Duration {
+	now := t.now()
+	var wait time.Duration
+	t.store.Update(t.prefix+key, func(attempt Attempt) Attempt {
+		if t.expired(attempt, now) {
+			attempt = Attempt{}
+		}
+		if wait = t.wait(attempt, now); wait > 0 {
+			return attempt
+		}
+		return t.fail(attempt, now)
+	})
+	return wait
+}
+
+// Release takes back an attempt Reserve counted, once it turned out to succeed.
+func (t *Throttle) Release(key string) {
+	t.store.Update(t.prefix+key, func(attempt Attempt) Attempt {
+		if attempt.Failures > 0 {
+			attempt.Failures--
+		}
+		if attempt.Failures < t.policy.LockoutAfter {
+			attempt.LockedUntil = time.Time{}
+		}
+		return attempt
+	})
+}
+
+func (t *Throttle) Fail(key string) {
+	now := t.now()
+	t.store.Update(t.prefix+key, func(attempt Attempt) Attempt {
+		if t.expired(attempt, now) {
+			attempt = Attempt{}
+		}
+		return t.fail(attempt, now)
+	})
+}
+
+func (t *Throttle) Reset(key string) {
+	t.store.Delete(t.prefix + key)
+}
+
+// Sweep drops the attempts whose lockout or window has passed, so keys that never come back don't pile up.
+func (t *Throttle) Sweep() {
+	now := t.now()
+	t.store.Sweep(t.prefix, func(attempt Attempt) bool {
+		return t.expired(attempt, now)
+	})
+}
+
+// current returns the stored attempt, or none once its lockout or window has passed.
+func (t *Throttle) current(key string) Attempt {
+	attempt := t.store.Get(t.prefix + key)
+	if t.expired(attempt, t.now()) {
+		return Attempt{}
+	}
+	return attempt
+}
+
+func (t *Throttle) wait(attempt Attempt, now time.Time) time.Duration {
+	if now.Before(attempt.LockedUntil) {
+		return attempt.LockedUntil.Sub(now)
+	}
+
+	if attempt.Failures < t.policy.BackoffAfter {
+		return 0
+	}
+
+	next := attempt.LastFailure.Add(t.delay(attempt.Failures))
+	if now.Before(next) {
+		return next.Sub(now)
+	}
+	return 0
+}
+
+func (t *Throttle) fail(attempt Attempt, now time.Time) Attempt {
+	attempt.Failures++
+	attempt.LastFailure = now
+	if attempt.Failures >= t.policy.LockoutAfter {
+		attempt.LockedUntil = now.Add(t.policy.LockoutDuration)
+	}
+	return attempt
+}
+
+func (t *Throttle) expired(attempt Attempt, now time.Time) bool {
+	if !attempt.LockedUntil.IsZero() {
+		return !now.Before(attempt.LockedUntil)
+	}
+	return !attempt.LastFailure.IsZero() && now.Sub(attempt.LastFailure) > t.policy.Window
+}
+
+func (t *Throttle) delay(failures int) time.Duration {
+	delay := t.policy.BaseDelay
+	for i := t.policy.BackoffAfter; i < failures; i++ {
+		delay *= 2
+		if delay >= t.policy.MaxDelay {
+			return t.policy.MaxDelay
+		}
+	}
+	return delay
+}
@@ -0,0 +1,148 @@
+package user
+
+import (
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+func setupThrottleTest() (*Throttle, *time.Time) {
+	now := time.Date(2021, 11, 20, 10, 0, 0, 0, time.UTC)
+	throttle := NewThrottle(NewMemoryAttemptStore(), "test:", AttemptPolicy{
+		BackoffAfter:    2,
+		BaseDelay:       time.Second,
+		MaxDelay:        4 * time.Second,
+		LockoutAfter:    5,
+		LockoutDuration: time.Minute,
+		Window:          time.Hour,
+	})
+	throttle.now = func() time.Time { return now }
+	return throttle, &now
+}
+
+func TestThrottle(t *testing.T) {
+	t.Run("failures below threshold should not wait", func(t *testing.T) {
+		throttle, _ := setupThrottleTest()
+		throttle.Fail("key")
+		assert.Zero(t, throttle.Wait("key"))
+	})
+
+	t.Run("failures above threshold should back off exponentially", func(t *testing.T) {
+		throttle, now := setupThrottleTest()
+		throttle.Fail("key")
+		throttle.Fail("key")
+		assert.Equal(t, time.Second, throttle.Wait("key"))
+
+		throttle.Fail("key")
+		assert.Equal(t, 2*time.Second, throttle.Wait("key"))
+
+		*now = now.Add(2 * time.Second)
+		assert.Zero(t, throttle.Wait("key"))
+	})
+
+	t.Run("reaching lockout should lock until duration passed", func(t *testing.T) {
+		throttle, now := setupThrottleTest()
+		for i := 0; i < 5; i++ {
+			throttle.Fail("key")
+		}
+		assert.Equal(t, time.Minute, throttle.Wait("key"))
+
+		*now = now.Add(time.Minute)
+		assert.Zero(t, throttle.Wait("key"))
+
+		throttle.Fail("key")
+		assert.Zero(t, throttle.Wait("key"))
+		assert.Equal(t, 1, throttle.store.Get("test:key").Failures)
+	})
+
+	t.Run("reset should clear failures", func(t *testing.T) {
+		throttle, _ := setupThrottleTest()
+		throttle.Fail("key")
+		throttle.Fail("key")
+		throttle.Reset("key")
+		assert.Zero(t, throttle.Wait("key"))
+	})
+
+	t.Run("old failures should be forgotten after window", func(t *testing.T) {
+		throttle, now := setupThrottleTest()
+		throttle.Fail("key")
+		throttle.Fail("key")
+		*now = now.Add(2 * time.Hour)
+		assert.Zero(t, throttle.Wait("key"))
+
+		throttle.Fail("key")
+		assert.Equal(t, 1, throttle.store.Get("test:key").Failures)
+	})
+
+	t.Run("sweep should drop only expired attempts of its own keys", func(t *testing.T) {
+		throttle, now := setupThrottleTest()
+		other := NewThrottle(throttle.store, "other:", throttle.policy)
+		other.now = throttle.now
+		throttle.Fail("old")
+		other.Fail("old")
+		*now = now.Add(2 * time.Hour)
+		throttle.Fail("recent")
+
+		throttle.Sweep()
+		assert.Zero(t, throttle.store.Get("test:old").Failures)
+		assert.Equal(t, 1, throttle.store.Get("test:recent").Failures)
+		assert.Equal(t, 1, throttle.store.Get("other:old").Failures)
+	})
+
+	t.Run("concurrent failures should all be counted", func(t *testing.T) {
+		throttle, _ := setupThrottleTest()
+		var wg sync.WaitGroup
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				throttle.Fail("key")
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 4, throttle.store.Get("test:key").Failures)
+	})
+
+	t.Run("reserve should count the attempt unless throttled", func(t *testing.T) {
+		throttle, _ := setupThrottleTest()
+		assert.Zero(t, throttle.Reserve("key"))
+		assert.Zero(t, throttle.Reserve("key"))
+		assert.Equal(t, time.Second, throttle.Reserve("key"))
+		assert.Equal(t, 2, throttle.store.Get("test:key").Failures)
+	})
+
+	t.Run("concurrent reservations should not pass the threshold", func(t *testing.T) {
+		throttle, _ := setupThrottleTest()
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		allowed := 0
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if throttle.Reserve("key") == 0 {
+					mu.Lock()
+					allowed++
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, 2, allowed)
+	})
+
+	t.Run("release should take back a reservation and its lockout", func(t *testing.T) {
+		throttle, now := setupThrottleTest()
+		for i := 0; i < 4; i++ {
+			throttle.Fail("key")
+			*now = now.Add(time.Minute)
+		}
+		assert.Zero(t, throttle.Reserve("key"))
+		assert.Equal(t, time.Minute, throttle.Wait("key"))
+
+		throttle.Release("key")
+		assert.Equal(t, 4, throttle.store.Get("test:key").Failures)
+		assert.True(t, throttle.store.Get("test:key").LockedUntil.IsZero())
+	})
+}
@@ -0,0 +1,115 @@
+package user
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"github.com/disintegration/imaging"
+	"go-api/app"
+	"go-api/exception"
+	"image"
+	"image/color"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/url"
+)
+
+const (
+	AvatarSmall  = 64
+	AvatarMedium = 150
+	AvatarLarge  = 320
+
+	maxAvatarBytes = 5 << 20
+	// maxAvatarPixels rejects decompression bombs before the image is decoded.
+	maxAvatarPixels = 6000 * 6000
+	avatarQuality   = 85
+)
+
+var avatarSizes = []int{AvatarSmall, AvatarMedium, AvatarLarge}
+
+// avatarPath returns where the avatar of the given size is stored, key already carries the version.
+func avatarPath(key string, size int) string {
+	return fmt.Sprintf("%s_%d.jpg", key, size)
+}
+
+// processAvatar crops the image to a centered square and encodes every avatar size as JPEG.
+func processAvatar(r io.Reader) map[int][]byte {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		panic(err)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		panic(exception.FieldError{Field: "avatar", Message: "file is not a supported image"})
+	}
+	if config.Width*config.Height > maxAvatarPixels {
+		panic(exception.FieldError{Field: "avatar", Message: "image dimensions are too large"})
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(data), imaging.AutoOrientation(true))
+	if err != nil {
+		panic(exception.FieldError{Field: "avatar", Message: "file is not a supported image"})
+	}
+
+	encoded := map[int][]byte{}
+	for _, size := range avatarSizes {
+		var buf bytes.Buffer
+		err = jpeg.Encode(&buf, imaging.Fill(img, size, size, imaging.Center, imaging.Lanczos), &jpeg.Options{Quality: avatarQuality})
+		if err != nil {
+			panic(err)
+		}
+		encoded[size] = buf.Bytes()
+	}
+	return encoded
+}
+
+// Identicon draws the generated default avatar, a mirrored 5x5 grid colored from the seed hash.
+func Identicon(seed string, size int) image.Image {
+	sum := sha256.Sum256([]byte(seed))
+	fg := color.RGBA{R: sum[0], G: sum[1], B: sum[2], A: 255}
+	bg := color.RGBA{R: 240, G: 240, B: 240, A: 255}
+
+	const grid = 5
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	cell := float64(size) / grid
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			col := int(float64(x) / cell)
+			row := int(float64(y) / cell)
+			if col > grid/2 {
+				col = grid - 1 - col
+			}
+
+			c := bg
+			if sum[3+row*3+col]%2 == 0 {
+				c = fg
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func (u *User) AvatarURLs() *AvatarURLs {
+	if u.AvatarKey == "" {
+		return &AvatarURLs{
+			Small:  defaultAvatarURL(u.Username, AvatarSmall),
+			Medium: defaultAvatarURL(u.Username, AvatarMedium),
+			Large:  defaultAvatarURL(u.Username, AvatarLarge),
+		}
+	}
+
+	storage := app.GetStorage()
+	return &AvatarURLs{
+		Small:  storage.URL(avatarPath(u.AvatarKey, AvatarSmall)),
+		Medium: storage.URL(avatarPath(u.AvatarKey, AvatarMedium)),
+		Large:  storage.URL(avatarPath(u.AvatarKey, AvatarLarge)),
+	}
+}
+
+func defaultAvatarURL(username string, size int) string {
+	return fmt.Sprintf("%sapi/user/avatar/default/%s?size=%d", app.BaseURL, url.PathEscape(username), size)
+}
@@ -0,0 +1,44 @@
+package user
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func TestProcessAvatar(t *testing.T) {
+	t.Run("image should be cropped square in every size", func(t *testing.T) {
+		src := image.NewRGBA(image.Rect(0, 0, 400, 200))
+		var buf bytes.Buffer
+		assert.NoError(t, png.Encode(&buf, src))
+
+		images := processAvatar(&buf)
+		assert.Len(t, images, len(avatarSizes))
+		for size, data := range images {
+			img, err := jpeg.Decode(bytes.NewReader(data))
+			assert.NoError(t, err)
+			assert.Equal(t, size, img.Bounds().Dx())
+			assert.Equal(t, size, img.Bounds().Dy())
+		}
+	})
+
+	t.Run("non image should panic", func(t *testing.T) {
+		assert.Panics(t, func() {
+			processAvatar(bytes.NewReader([]byte("not an image")))
+		})
+	})
+}
+
+func TestIdenticon(t *testing.T) {
+	first := Identicon("testservice", AvatarSmall)
+	second := Identicon("testservice", AvatarSmall)
+	assert.Equal(t, first, second)
+	assert.Equal(t, AvatarSmall, first.Bounds().Dx())
+
+	// left and right halves are mirrored
+	assert.Equal(t, color.RGBAModel.Convert(first.At(0, 0)), color.RGBAModel.Convert(first.At(AvatarSmall-1, 0)))
+}
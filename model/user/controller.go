@@ -1,11 +1,15 @@
 package user
 
 import (
-	"context"
+	"bytes"
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
+	"go-api/exception"
 	"go-api/model"
+	"go-api/model/audit"
+	"image/png"
 	"net/http"
+	"strconv"
 )
 
 type Controller interface {
@@ -15,6 +19,9 @@ type Controller interface {
 	UpdatePassword(ctx *gin.Context)
 	Search(ctx *gin.Context)
 	FindByUsername(ctx *gin.Context)
+	UpdateAvatar(ctx *gin.Context)
+	RemoveAvatar(ctx *gin.Context)
+	DefaultAvatar(ctx *gin.Context)
 }
 
 type controllerImpl struct {
@@ -32,9 +39,11 @@ func (c *controllerImpl) Register(ctx *gin.Context) {
 		panic(err)
 	}
 
-	res := c.service.Register(context.Background(), req)
+	req.IPAddress = ctx.ClientIP()
+	req.UserAgent = ctx.Request.UserAgent()
+	res := c.service.Register(ctx, req)
 	ctx.SetCookie("token", res.Token, 3600, "/", "", false, false)
-	ctx.IndentedJSON(http.StatusCreated, &model.WebResponse{
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusCreated,
 		Status: "ok",
 		Data:   res,
@@ -48,9 +57,11 @@ func (c *controllerImpl) Login(ctx *gin.Context) {
 		panic(err)
 	}
 
-	res := c.service.Login(context.Background(), req)
+	req.IPAddress = ctx.ClientIP()
+	req.UserAgent = ctx.Request.UserAgent()
+	res := c.service.Login(ctx, req)
 	ctx.SetCookie("token", res.Token, 3600, "/", "", false, false)
-	ctx.IndentedJSON(http.StatusOK, &model.WebResponse{
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusOK,
 		Status: "ok",
 		Data:   res,
@@ -65,8 +76,9 @@ func (c *controllerImpl) UpdateProfile(ctx *gin.Context) {
 	}
 
 	req.UserID = ctx.Request.Header.Get("User_id")
-	c.service.UpdateProfile(context.Background(), req)
-	ctx.IndentedJSON(http.StatusOK, &model.WebResponse{
+	req.Actor = audit.ActorFrom(ctx)
+	c.service.UpdateProfile(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusOK,
 		Status: "ok",
 	})
@@ -80,8 +92,9 @@ func (c *controllerImpl) UpdatePassword(ctx *gin.Context) {
 	}
 
 	req.UserID = ctx.Request.Header.Get("User_id")
-	c.service.UpdatePassword(context.Background(), req)
-	ctx.IndentedJSON(http.StatusOK, &model.WebResponse{
+	req.Actor = audit.ActorFrom(ctx)
+	c.service.UpdatePassword(ctx, req)
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusOK,
 		Status: "ok",
 	})
@@ -89,8 +102,8 @@ func (c *controllerImpl) UpdatePassword(ctx *gin.Context) {
 
 func (c *controllerImpl) Search(ctx *gin.Context) {
 	keyword := ctx.Query("handler")
-	users := c.service.SearchLike(context.Background(), keyword)
-	ctx.IndentedJSON(http.StatusOK, &model.WebResponse{
+	users := c.service.SearchLike(ctx, keyword)
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusOK,
 		Status: "ok",
 		Data:   users,
@@ -99,10 +112,61 @@ func (c *controllerImpl) Search(ctx *gin.Context) {
 
 func (c *controllerImpl) FindByUsername(ctx *gin.Context) {
 	username := ctx.Param("username")
-	user := c.service.FindByUsername(context.Background(), username)
-	ctx.IndentedJSON(http.StatusOK, &model.WebResponse{
+	user := c.service.FindByUsername(ctx, username)
+	model.Respond(ctx, &model.WebResponse{
 		Code:   http.StatusOK,
 		Status: "ok",
 		Data:   user,
 	})
 }
+
+func (c *controllerImpl) UpdateAvatar(ctx *gin.Context) {
+	file, err := ctx.FormFile("avatar")
+	if err != nil {
+		panic(exception.FieldError{Field: "avatar", Message: "avatar image is required"})
+	}
+	if file.Size > maxAvatarBytes {
+		panic(exception.FieldError{Field: "avatar", Message: "avatar image is too large"})
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		panic(err)
+	}
+	defer src.Close()
+
+	res := c.service.UpdateAvatar(ctx, &AvatarRequest{
+		UserID: ctx.GetHeader("User_id"),
+		File:   src,
+	})
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) RemoveAvatar(ctx *gin.Context) {
+	res := c.service.RemoveAvatar(ctx, ctx.GetHeader("User_id"))
+	model.Respond(ctx, &model.WebResponse{
+		Code:   http.StatusOK,
+		Status: "ok",
+		Data:   res,
+	})
+}
+
+func (c *controllerImpl) DefaultAvatar(ctx *gin.Context) {
+	size, err := strconv.Atoi(ctx.DefaultQuery("size", strconv.Itoa(AvatarMedium)))
+	if err != nil || size <= 0 || size > AvatarLarge {
+		size = AvatarMedium
+	}
+
+	var buf bytes.Buffer
+	err = png.Encode(&buf, Identicon(ctx.Param("username"), size))
+	if err != nil {
+		panic(err)
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=86400")
+	ctx.Data(http.StatusOK, "image/png", buf.Bytes())
+}
@@ -7,9 +7,15 @@ import (
 	"github.com/go-playground/validator"
 	"github.com/stretchr/testify/assert"
 	"go-api/app"
+	"go-api/cache"
 	"go-api/helper"
 	"go-api/middleware"
 	"go-api/model"
+	"go-api/model/audit"
+	"go-api/model/relation"
+	"go-api/model/search"
+	"go-api/model/session"
+	"go-api/model/token"
 	"go-api/model/user"
 	"io/ioutil"
 	"net/http"
@@ -20,11 +26,11 @@ import (
 func setupControllerTest() (*gin.Engine, user.Service) {
 	app.TestDBInit()
 	repository := user.NewRepository()
-	service := user.NewService(validator.New(), repository)
+	service := user.NewService(validator.New(), repository, session.NewRepository(), audit.NewRepository(), relation.NewRepository(), search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer()), user.NewMemoryAttemptStore(), cache.NewLoader(cache.NewLRU(100)))
 	controller := user.NewController(service)
 
 	router := gin.Default()
-	router.Use(middleware.JWTValidator())
+	router.Use(middleware.JWTValidator(session.NewService(validator.New(), session.NewRepository()), token.NewService(validator.New(), token.NewRepository())))
 	router.Use(gin.CustomRecovery(middleware.PanicHandler))
 
 	router.POST("/user/login", controller.Login)
@@ -202,7 +208,7 @@ func TestControllerImpl_Login(t *testing.T) {
 		t.Log(webResponse)
 	})
 
-	t.Run("not registered username or password should return not found", func(t *testing.T) {
+	t.Run("not registered username or password should return unauthorized", func(t *testing.T) {
 		router, _ := setupControllerTest()
 		req := httptest.NewRequest(
 			"POST",
@@ -213,7 +219,7 @@ func TestControllerImpl_Login(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		res := w.Result()
-		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
 
 		resBody, err := ioutil.ReadAll(res.Body)
 		assert.Nil(t, err)
@@ -230,7 +236,7 @@ func TestControllerImpl_Login(t *testing.T) {
 		t.Log(webResponse)
 	})
 
-	t.Run("wrong password should return bad request", func(t *testing.T) {
+	t.Run("wrong password should return unauthorized", func(t *testing.T) {
 		router, service := setupControllerTest()
 		service.Register(context.Background(), registerValid)
 		req := httptest.NewRequest(
@@ -242,7 +248,7 @@ func TestControllerImpl_Login(t *testing.T) {
 		router.ServeHTTP(w, req)
 
 		res := w.Result()
-		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
 		assert.Empty(t, res.Cookies())
 
 		resBody, err := ioutil.ReadAll(res.Body)
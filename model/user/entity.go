@@ -1,6 +1,9 @@
 package user
 
-import "time"
+import (
+	"go-api/model/search"
+	"time"
+)
 
 type User struct {
 	ID          string    `gorm:"column:user_id; not null, primaryKey"`
@@ -9,18 +12,62 @@ type User struct {
 	DisplayName string    `gorm:"column:display_name; not null"`
 	Biography   string    `gorm:"column:biography;"`
 	ExternalUrl string    `gorm:"column:external_url"`
+	AvatarKey   string    `gorm:"column:avatar_key"`
 	IsVerified  bool      `gorm:"column:is_verified;"`
+	IsSuspended bool      `gorm:"column:is_suspended;"`
+	IsPrivate   bool      `gorm:"column:is_private;"`
+	Role        string    `gorm:"column:role; not null"`
 	Password    string    `gorm:"column:password; not null"`
 	CreatedAt   time.Time `gorm:"column:created_at; not null"`
 	UpdatedAt   time.Time `gorm:"column:updated_at; not null"`
 }
 
+type LoginAttempt struct {
+	ID        int64     `gorm:"column:login_attempt_id; primaryKey,autoIncrement"`
+	UserID    string    `gorm:"column:user_id;"`
+	Handler   string    `gorm:"column:handler; not null"`
+	IPAddress string    `gorm:"column:ip_address; not null"`
+	UserAgent string    `gorm:"column:user_agent;"`
+	Reason    string    `gorm:"column:reason; not null"`
+	CreatedAt time.Time `gorm:"column:created_at; not null"`
+}
+
+const (
+	AttemptUnknownUser   = "unknown_user"
+	AttemptWrongPassword = "wrong_password"
+	AttemptThrottled     = "throttled"
+)
+
 func (u *User) ToResponse() *Response {
+	avatars := u.AvatarURLs()
 	return &Response{
-		Username:          u.Username,
-		DisplayName:       u.DisplayName,
-		Biography:         u.Biography,
-		ExternalUrl:       u.ExternalUrl,
-		ProfilePictureURL: u.Email,
+		Username:           u.Username,
+		DisplayName:        u.DisplayName,
+		Biography:          u.Biography,
+		ExternalUrl:        u.ExternalUrl,
+		ProfilePictureURL:  avatars.Medium,
+		ProfilePictureURLs: avatars,
+		IsVerified:         u.IsVerified,
+	}
+}
+
+func (u *User) ToSearchResponse() *SearchResponse {
+	avatars := u.AvatarURLs()
+	return &SearchResponse{
+		Username:           u.Username,
+		DisplayName:        u.DisplayName,
+		ProfilePictureURL:  avatars.Small,
+		ProfilePictureURLs: avatars,
+	}
+}
+
+func (u *User) ToDocument(followerCount int64) *search.UserDocument {
+	return &search.UserDocument{
+		UserID:        u.ID,
+		Username:      u.Username,
+		DisplayName:   u.DisplayName,
+		AvatarURL:     u.AvatarURLs().Small,
+		IsVerified:    u.IsVerified,
+		FollowerCount: followerCount,
 	}
 }
@@ -3,6 +3,7 @@ package user
 import (
 	"go-api/exception"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"time"
 )
 
@@ -11,10 +12,18 @@ type Repository interface {
 	Update(tx *gorm.DB, user *User)
 	Delete(tx *gorm.DB, user *User)
 	FindById(tx *gorm.DB, id string) *User
-	FindLike(tx *gorm.DB, keyword string) []*User
+	// Lock locks the user's row so per-user limits are checked and applied one request at a time.
+	Lock(tx *gorm.DB, userID string)
+	Search(tx *gorm.DB, keyword string, offset, limit int) []*User
 	FindByEmail(tx *gorm.DB, email string) *User
 	FindByUsername(tx *gorm.DB, username string) *User
 	FindByEmailOrUsername(tx *gorm.DB, handler string) *User
+	CreateLoginAttempt(tx *gorm.DB, attempt *LoginAttempt)
+	SetRole(tx *gorm.DB, userID, role string)
+	HasRole(tx *gorm.DB, role string) bool
+	SetSuspended(tx *gorm.DB, userID string, suspended bool)
+	SetVerified(tx *gorm.DB, userID string, verified bool)
+	SetAvatar(tx *gorm.DB, userID, avatarKey string)
 }
 
 type repositoryImpl struct {
@@ -64,11 +73,25 @@ func (*repositoryImpl) FindById(tx *gorm.DB, id string) *User {
 	return user
 }
 
-func (*repositoryImpl) FindLike(tx *gorm.DB, keyword string) []*User {
+func (*repositoryImpl) Lock(tx *gorm.DB, userID string) {
+	var ids []string
+	err := tx.Model(&User{}).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ?", userID).
+		Pluck("user_id", &ids).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) Search(tx *gorm.DB, keyword string, offset, limit int) []*User {
 	var users []*User
-	query := "username LIKE ? OR display_name LIKE ?"
-	key := "%" + keyword + "%"
-	err := tx.Where(query, key, key).Find(&users).Error
+	query := tx.Order("created_at desc").Offset(offset).Limit(limit)
+	if keyword != "" {
+		key := "%" + keyword + "%"
+		query = query.Where("username LIKE ? OR display_name LIKE ? OR email LIKE ?", key, key, key)
+	}
+	err := query.Find(&users).Error
 	if err != nil {
 		panic(exception.DatabaseError{Message: err.Error()})
 	}
@@ -107,3 +130,58 @@ func (*repositoryImpl) FindByEmailOrUsername(tx *gorm.DB, handler string) *User
 	}
 	return user
 }
+
+func (*repositoryImpl) CreateLoginAttempt(tx *gorm.DB, attempt *LoginAttempt) {
+	err := tx.Create(&attempt).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) SetRole(tx *gorm.DB, userID, role string) {
+	err := tx.Model(&User{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"role": role, "updated_at": time.Now()}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) HasRole(tx *gorm.DB, role string) bool {
+	var ids []string
+	err := tx.Model(&User{}).
+		Where("role = ?", role).
+		Limit(1).
+		Pluck("user_id", &ids).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+	return len(ids) > 0
+}
+
+func (*repositoryImpl) SetSuspended(tx *gorm.DB, userID string, suspended bool) {
+	err := tx.Model(&User{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"is_suspended": suspended, "updated_at": time.Now()}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) SetVerified(tx *gorm.DB, userID string, verified bool) {
+	err := tx.Model(&User{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"is_verified": verified, "updated_at": time.Now()}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
+
+func (*repositoryImpl) SetAvatar(tx *gorm.DB, userID, avatarKey string) {
+	err := tx.Model(&User{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"avatar_key": avatarKey, "updated_at": time.Now()}).Error
+	if err != nil {
+		panic(exception.DatabaseError{Message: err.Error()})
+	}
+}
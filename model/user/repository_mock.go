@@ -29,8 +29,12 @@ func (r *RepositoryMock) FindById(tx *gorm.DB, id string) *User {
 	return nil
 }
 
-func (r *RepositoryMock) FindLike(tx *gorm.DB, keyword string) []*User {
-	args := r.Called(keyword)
+func (r *RepositoryMock) Lock(tx *gorm.DB, userID string) {
+	r.Called(userID)
+}
+
+func (r *RepositoryMock) Search(tx *gorm.DB, keyword string, offset, limit int) []*User {
+	args := r.Called(keyword, offset, limit)
 	if args.Get(0) != nil {
 		return args.Get(0).([]*User)
 	}
@@ -60,3 +64,28 @@ func (r *RepositoryMock) FindByEmailOrUsername(tx *gorm.DB, handler string) *Use
 	}
 	return nil
 }
+
+func (r *RepositoryMock) CreateLoginAttempt(tx *gorm.DB, attempt *LoginAttempt) {
+	r.Called(attempt)
+}
+
+func (r *RepositoryMock) SetRole(tx *gorm.DB, userID, role string) {
+	r.Called(userID, role)
+}
+
+func (r *RepositoryMock) HasRole(tx *gorm.DB, role string) bool {
+	args := r.Called(role)
+	return args.Bool(0)
+}
+
+func (r *RepositoryMock) SetSuspended(tx *gorm.DB, userID string, suspended bool) {
+	r.Called(userID, suspended)
+}
+
+func (r *RepositoryMock) SetVerified(tx *gorm.DB, userID string, verified bool) {
+	r.Called(userID, verified)
+}
+
+func (r *RepositoryMock) SetAvatar(tx *gorm.DB, userID, avatarKey string) {
+	r.Called(userID, avatarKey)
+}
@@ -0,0 +1,52 @@
+package user
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+const (
+	PermissionUserRead        = "user:read"
+	PermissionUserSuspend     = "user:suspend"
+	PermissionUserVerify      = "user:verify"
+	PermissionUserRole        = "user:role"
+	PermissionPostModerate    = "post:moderate"
+	PermissionCommentModerate = "comment:moderate"
+	PermissionAuditRead       = "audit:read"
+	PermissionMediaGC         = "media:gc"
+)
+
+var rolePermissions = map[string][]string{
+	RoleUser: {},
+	RoleModerator: {
+		PermissionUserRead,
+		PermissionUserSuspend,
+		PermissionPostModerate,
+		PermissionCommentModerate,
+	},
+	RoleAdmin: {
+		PermissionUserRead,
+		PermissionUserSuspend,
+		PermissionUserVerify,
+		PermissionUserRole,
+		PermissionPostModerate,
+		PermissionCommentModerate,
+		PermissionAuditRead,
+		PermissionMediaGC,
+	},
+}
+
+func IsRole(role string) bool {
+	_, ok := rolePermissions[role]
+	return ok
+}
+
+func HasPermission(role, permission string) bool {
+	for _, p := range rolePermissions[role] {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
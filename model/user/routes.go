@@ -7,6 +7,9 @@ func InitRoutes(router *gin.RouterGroup, controller Controller) {
 	userGroup.POST("/", controller.Register)
 	userGroup.PUT("/edit/", controller.UpdateProfile)
 	userGroup.PUT("/password/", controller.UpdatePassword)
+	userGroup.PUT("/avatar/", controller.UpdateAvatar)
+	userGroup.DELETE("/avatar/", controller.RemoveAvatar)
+	userGroup.GET("/avatar/default/:username", controller.DefaultAvatar)
 
 	router.POST("/register", controller.Register)
 	router.POST("/login", controller.Login)
@@ -1,13 +1,22 @@
 package user
 
 import (
+	"bytes"
 	"context"
 	"github.com/go-playground/validator"
 	uuid "github.com/satori/go.uuid"
 	"go-api/app"
+	"go-api/cache"
 	"go-api/exception"
 	"go-api/helper"
+	"go-api/metrics"
+	"go-api/model/audit"
+	"go-api/model/relation"
+	"go-api/model/search"
+	"go-api/model/session"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"strings"
 	"time"
 )
 
@@ -18,24 +27,70 @@ type Service interface {
 	UpdatePassword(ctx context.Context, req *UpdatePasswordRequest)
 	FindByUsername(ctx context.Context, username string) *Response
 	SearchLike(ctx context.Context, keyword string) []*SearchResponse
+	HasPermission(ctx context.Context, userID, permission string) bool
+	UpdateAvatar(ctx context.Context, req *AvatarRequest) *AvatarURLs
+	RemoveAvatar(ctx context.Context, userID string) *AvatarURLs
+	Reindex(ctx context.Context, index search.Service)
+	// CleanupAttempts forgets failed logins that no longer throttle anyone.
+	CleanupAttempts(ctx context.Context)
+	// BootstrapAdmin makes username an admin while there is none, so the first admin doesn't need the database edited.
+	BootstrapAdmin(ctx context.Context, username string)
 }
 
 type serviceImpl struct {
-	validate       *validator.Validate
-	userRepository Repository
+	validate           *validator.Validate
+	userRepository     Repository
+	sessionRepository  session.Repository
+	auditRepository    audit.Repository
+	relationRepository relation.Repository
+	searchService      search.Service
+	accountThrottle    *Throttle
+	ipThrottle         *Throttle
+	cache              *cache.Loader
 }
 
-func NewService(validate *validator.Validate, userRepository Repository) Service {
-	return &serviceImpl{validate: validate, userRepository: userRepository}
+const (
+	reindexBatchSize = 500
+	// CacheTTL bounds how stale a cached profile can get when an invalidation is missed.
+	CacheTTL = 5 * time.Minute
+)
+
+var (
+	registrations = metrics.NewCounter("user_registrations_total", "Accounts registered.")
+	logins        = metrics.NewCounter("user_logins_total", "Login attempts by result, succeeded, failed or throttled.", "result")
+)
+
+// dummyPassword is compared against when the handler is unknown, so both failures take the same time.
+var dummyPassword, _ = bcrypt.GenerateFromPassword([]byte("dummy-password"), bcrypt.DefaultCost)
+
+func NewService(validate *validator.Validate, userRepository Repository, sessionRepository session.Repository, auditRepository audit.Repository, relationRepository relation.Repository, searchService search.Service, attemptStore AttemptStore, cache *cache.Loader) Service {
+	return &serviceImpl{
+		validate:           validate,
+		userRepository:     userRepository,
+		sessionRepository:  sessionRepository,
+		auditRepository:    auditRepository,
+		relationRepository: relationRepository,
+		searchService:      searchService,
+		accountThrottle:    NewThrottle(attemptStore, "account:", AccountAttemptPolicy),
+		ipThrottle:         NewThrottle(attemptStore, "ip:", IPAttemptPolicy),
+		cache:              cache,
+	}
 }
 
 func (s *serviceImpl) Register(ctx context.Context, req *RegisterRequest) *AuthResponse {
+	res := s.register(ctx, req)
+	// counted once the account is committed
+	registrations.Inc()
+	return res
+}
+
+func (s *serviceImpl) register(ctx context.Context, req *RegisterRequest) *AuthResponse {
 	err := s.validate.Struct(req)
 	if err != nil {
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
 	var mErr exception.Errors
@@ -70,17 +125,15 @@ func (s *serviceImpl) Register(ctx context.Context, req *RegisterRequest) *AuthR
 		Email:       req.Email,
 		Username:    req.Username,
 		DisplayName: req.DisplayName,
+		Role:        RoleUser,
 		Password:    string(encrypt),
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	token, err := helper.GenerateJWT(eUser.ID, eUser.Username)
-	if err != nil {
-		panic(err)
-	}
-
 	s.userRepository.Create(tx, eUser)
+	token := s.createSession(tx, eUser, req.UserAgent, req.IPAddress)
+	app.AfterCommit(tx, func() { s.searchService.IndexUser(eUser.ToDocument(0)) })
 	return &AuthResponse{
 		UserID: eUser.ID,
 		Token:  token,
@@ -88,44 +141,147 @@ func (s *serviceImpl) Register(ctx context.Context, req *RegisterRequest) *AuthR
 }
 
 func (s *serviceImpl) Login(ctx context.Context, req *LoginRequest) *AuthResponse {
+	res := s.login(ctx, req)
+	// counted once the session is committed
+	logins.Inc("succeeded")
+	return res
+}
+
+func (s *serviceImpl) login(ctx context.Context, req *LoginRequest) *AuthResponse {
 	err := s.validate.Struct(req)
 	if err != nil {
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
+	// failures count against the account whether its email or its username was typed,
+	// handlers of no account are throttled as typed
 	user := s.userRepository.FindByEmailOrUsername(tx, req.Handler)
-	if user.ID == "" {
-		panic(exception.NotFoundError{
-			Message: "username or email does not match any record",
+	accountKey := "handler:" + strings.ToLower(req.Handler)
+	if user.ID != "" {
+		accountKey = "id:" + user.ID
+	}
+	ipKey := req.IPAddress
+
+	// the attempt is counted as failed up front and taken back once the password matched,
+	// checking and counting separately would let concurrent guesses through
+	wait := s.accountThrottle.Reserve(accountKey)
+	if wait == 0 {
+		if wait = s.ipThrottle.Reserve(ipKey); wait > 0 {
+			s.accountThrottle.Release(accountKey)
+		}
+	}
+	if wait > 0 {
+		s.recordLoginAttempt(ctx, req, user.ID, AttemptThrottled)
+		logins.Inc("throttled")
+		panic(exception.TooManyRequestsError{
+			Message:    "too many failed login attempts, try again later",
+			RetryAfter: wait,
 		})
 	}
 
+	if user.ID == "" {
+		_ = bcrypt.CompareHashAndPassword(dummyPassword, []byte(req.Password))
+		s.failLogin(ctx, req, "", AttemptUnknownUser)
+	}
+
 	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password))
 	if err != nil {
-		panic(exception.WrongPasswordError{Message: "password doest not match"})
+		s.failLogin(ctx, req, user.ID, AttemptWrongPassword)
 	}
 
-	token, err := helper.GenerateJWT(user.ID, user.Username)
-	if err != nil {
-		panic(err)
+	s.accountThrottle.Reset(accountKey)
+	s.ipThrottle.Release(ipKey)
+
+	if user.IsSuspended {
+		panic(exception.NoAccessError{Message: "account is suspended"})
 	}
 
+	token := s.createSession(tx, user, req.UserAgent, req.IPAddress)
+
 	return &AuthResponse{
 		UserID: user.ID,
 		Token:  token,
 	}
 }
 
+// createSession records where the user signed in from and returns a JWT bound to that session.
+func (s *serviceImpl) createSession(tx *gorm.DB, user *User, userAgent, ipAddress string) string {
+	sess := session.New(user.ID, userAgent, ipAddress)
+	s.sessionRepository.Create(tx, sess)
+
+	token, err := helper.GenerateJWT(user.ID, user.Username, sess.ID)
+	if err != nil {
+		panic(err)
+	}
+	return token
+}
+
+// failLogin records the failure, already counted against the account and the address, then panics
+// with the same credential error whichever part of the handler/password pair was wrong.
+func (s *serviceImpl) failLogin(ctx context.Context, req *LoginRequest, userID, reason string) {
+	s.recordLoginAttempt(ctx, req, userID, reason)
+	logins.Inc("failed")
+	panic(exception.CredentialError{Message: "invalid username, email or password"})
+}
+
+func (s *serviceImpl) CleanupAttempts(ctx context.Context) {
+	s.accountThrottle.Sweep()
+	s.ipThrottle.Sweep()
+}
+
+// BootstrapAdmin does nothing once some user is an admin, so the setting can stay in place and
+// a later demotion of the bootstrapped admin isn't undone by the next start.
+func (s *serviceImpl) BootstrapAdmin(ctx context.Context, username string) {
+	tx := app.WriteTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	if s.userRepository.HasRole(tx, RoleAdmin) {
+		return
+	}
+
+	user := s.userRepository.FindByUsername(tx, username)
+	if user.ID == "" {
+		app.Logger(ctx).Warn("admin to bootstrap isn't registered, it's made an admin on a start after it is", "username", username)
+		return
+	}
+
+	s.userRepository.SetRole(tx, user.ID, RoleAdmin)
+	s.auditRepository.Append(tx, audit.NewEntry(audit.Actor{}, audit.ActionUserRole, audit.TargetUser, user.ID).
+		WithChanges(
+			map[string]interface{}{"role": user.Role},
+			map[string]interface{}{"role": RoleAdmin},
+		).
+		WithDetail(map[string]interface{}{"reason": "bootstrap"}))
+	app.Logger(ctx).Info("bootstrapped admin", "user_id", user.ID, "username", username)
+}
+
+// recordLoginAttempt writes outside of the login transaction, which is rolled back on failure.
+func (s *serviceImpl) recordLoginAttempt(ctx context.Context, req *LoginRequest, userID, reason string) {
+	s.userRepository.CreateLoginAttempt(app.GetDB().WithContext(ctx), &LoginAttempt{
+		UserID:    userID,
+		Handler:   req.Handler,
+		IPAddress: req.IPAddress,
+		UserAgent: req.UserAgent,
+		Reason:    reason,
+		CreatedAt: time.Now(),
+	})
+}
+
 func (s *serviceImpl) UpdateProfile(ctx context.Context, req *UpdateProfileRequest) {
 	err := s.validate.Struct(req)
 	if err != nil {
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
+	// both usernames are dropped from the cache once the change is committed
+	var oldUsername string
+	defer func() {
+		s.cache.Invalidate(ctx, cache.ProfileKey(oldUsername), cache.ProfileKey(req.Username))
+	}()
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
 	user := s.userRepository.FindById(tx, req.UserID)
@@ -135,6 +291,9 @@ func (s *serviceImpl) UpdateProfile(ctx context.Context, req *UpdateProfileReque
 		})
 	}
 
+	oldUsername = user.Username
+	before := profileSnapshot(user)
+
 	var mErr exception.Errors
 	user.Username = req.Username
 
@@ -162,6 +321,24 @@ func (s *serviceImpl) UpdateProfile(ctx context.Context, req *UpdateProfileReque
 	user.DisplayName = req.DisplayName
 	user.Biography = req.Biography
 	s.userRepository.Update(tx, user)
+	s.auditRepository.Append(tx, audit.NewEntry(req.Actor, audit.ActionUserProfileUpdate, audit.TargetUser, user.ID).
+		WithChanges(before, profileSnapshot(user)))
+	s.index(tx, user)
+}
+
+// index puts the user into search once tx is committed, with the follower count it's ranked by.
+func (s *serviceImpl) index(tx *gorm.DB, user *User) {
+	doc := user.ToDocument(s.relationRepository.CountFollowers(tx, []string{user.ID})[user.ID])
+	app.AfterCommit(tx, func() { s.searchService.IndexUser(doc) })
+}
+
+func profileSnapshot(user *User) map[string]interface{} {
+	return map[string]interface{}{
+		"username":     user.Username,
+		"email":        user.Email,
+		"display_name": user.DisplayName,
+		"biography":    user.Biography,
+	}
 }
 
 func (s *serviceImpl) UpdatePassword(ctx context.Context, req *UpdatePasswordRequest) {
@@ -170,7 +347,7 @@ func (s *serviceImpl) UpdatePassword(ctx context.Context, req *UpdatePasswordReq
 		panic(err)
 	}
 
-	tx := app.GetDB().WithContext(ctx).Begin()
+	tx := app.WriteTx(ctx)
 	defer helper.TXCommitOrRollback(tx)
 
 	user := s.userRepository.FindById(tx, req.UserID)
@@ -192,45 +369,131 @@ func (s *serviceImpl) UpdatePassword(ctx context.Context, req *UpdatePasswordReq
 
 	user.Password = string(encrypt)
 	s.userRepository.Update(tx, user)
+	s.auditRepository.Append(tx, audit.NewEntry(req.Actor, audit.ActionUserPasswordUpdate, audit.TargetUser, user.ID))
 }
 
 func (s *serviceImpl) FindByUsername(ctx context.Context, username string) *Response {
-	tx := app.GetDB().WithContext(ctx).Begin()
-	defer helper.TXCommitOrRollback(tx)
-
-	user := s.userRepository.FindByUsername(tx, username)
-	if user.ID == "" {
-		panic(exception.NotFoundError{
-			Message: "user not found",
-		})
-	}
-
-	//TODO: Look for user followers and following
-	//TODO: check if viewer following current user
-	return &Response{
-		Username:          user.Username,
-		DisplayName:       user.DisplayName,
-		Biography:         user.Biography,
-		ExternalUrl:       user.ExternalUrl,
-		ProfilePictureURL: user.Username,
-		IsVerified:        user.IsVerified,
-		FollowedByViewer:  false,
-		FollowerCount:     0,
-		FollowingCount:    0,
-	}
+	var response Response
+	s.cache.Fetch(ctx, cache.ProfileKey(username), CacheTTL, &response, func(ctx context.Context) interface{} {
+		tx := app.ReadPrimaryTx(ctx)
+		defer helper.TXCommitOrRollback(tx)
+
+		user := s.userRepository.FindByUsername(tx, username)
+		if user.ID == "" {
+			panic(exception.NotFoundError{
+				Message: "user not found",
+			})
+		}
+
+		//TODO: Look for user followers and following
+		//TODO: check if viewer following current user
+		return user.ToResponse()
+	})
+	return &response
 }
 
+// SearchLike ranks users through the search index, display data comes from the indexed documents.
 func (s *serviceImpl) SearchLike(ctx context.Context, keyword string) []*SearchResponse {
 	var sResponse []*SearchResponse
-	tx := app.GetDB().WithContext(ctx).Begin()
+	if keyword == "" {
+		return sResponse
+	}
 
-	users := s.userRepository.FindLike(tx, keyword)
-	for _, user := range users {
+	hits := s.searchService.Search(ctx, &search.Request{Query: keyword, Type: search.TypeUser})
+	for _, hit := range hits {
 		sResponse = append(sResponse, &SearchResponse{
-			Username:          user.Username,
-			DisplayName:       user.DisplayName,
-			//ProfilePictureURL: user.ProfilePictureURL,
+			Username:          hit.Title,
+			DisplayName:       hit.Text,
+			ProfilePictureURL: hit.ImageURL,
 		})
 	}
 	return sResponse
 }
+
+func (s *serviceImpl) HasPermission(ctx context.Context, userID, permission string) bool {
+	tx := app.ReadPrimaryTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	user := s.userRepository.FindById(tx, userID)
+	if user.ID == "" || user.IsSuspended {
+		return false
+	}
+	return HasPermission(user.Role, permission)
+}
+
+// UpdateAvatar stores every size under a new key, so cached URLs of the previous avatar never serve the new one.
+func (s *serviceImpl) UpdateAvatar(ctx context.Context, req *AvatarRequest) *AvatarURLs {
+	err := s.validate.Struct(req)
+	if err != nil {
+		panic(err)
+	}
+
+	images := processAvatar(req.File)
+	key := "avatars/" + req.UserID + "/" + uuid.NewV4().String()
+	for size, data := range images {
+		err = app.GetStorage().Save(avatarPath(key, size), bytes.NewReader(data))
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	user := s.replaceAvatar(ctx, req.UserID, key)
+	return user.AvatarURLs()
+}
+
+func (s *serviceImpl) RemoveAvatar(ctx context.Context, userID string) *AvatarURLs {
+	user := s.replaceAvatar(ctx, userID, "")
+	return user.AvatarURLs()
+}
+
+// replaceAvatar commits the new avatar key before the files of the old one are removed.
+func (s *serviceImpl) replaceAvatar(ctx context.Context, userID, key string) *User {
+	user, oldKey := func() (*User, string) {
+		tx := app.WriteTx(ctx)
+		defer helper.TXCommitOrRollback(tx)
+
+		user := s.userRepository.FindById(tx, userID)
+		if user.ID == "" {
+			panic(exception.NotFoundError{Message: "user not found"})
+		}
+
+		oldKey := user.AvatarKey
+		s.userRepository.SetAvatar(tx, user.ID, key)
+		user.AvatarKey = key
+		s.index(tx, user)
+		return user, oldKey
+	}()
+	s.cache.Invalidate(ctx, cache.ProfileKey(user.Username))
+
+	if oldKey != "" {
+		for _, size := range avatarSizes {
+			_ = app.GetStorage().Delete(avatarPath(oldKey, size))
+		}
+	}
+	return user
+}
+
+// Reindex loads every active user into index, see search.Service.Rebuild.
+func (s *serviceImpl) Reindex(ctx context.Context, index search.Service) {
+	tx := app.ReadTx(ctx)
+	defer helper.TXCommitOrRollback(tx)
+
+	for offset := 0; ; offset += reindexBatchSize {
+		users := s.userRepository.Search(tx, "", offset, reindexBatchSize)
+		userIDs := make([]string, 0, len(users))
+		for _, user := range users {
+			userIDs = append(userIDs, user.ID)
+		}
+		followers := s.relationRepository.CountFollowers(tx, userIDs)
+
+		for _, user := range users {
+			if !user.IsSuspended {
+				index.IndexUser(user.ToDocument(followers[user.ID]))
+			}
+		}
+
+		if len(users) < reindexBatchSize {
+			return
+		}
+	}
+}
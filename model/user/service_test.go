@@ -7,6 +7,11 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"go-api/app"
+	"go-api/cache"
+	"go-api/model/audit"
+	"go-api/model/relation"
+	"go-api/model/search"
+	"go-api/model/session"
 	"go-api/model/user"
 	"golang.org/x/crypto/bcrypt"
 	"testing"
@@ -15,7 +20,7 @@ import (
 func setupServiceTest() (*user.RepositoryMock, user.Service) {
 	app.TestDBInit()
 	repository := &user.RepositoryMock{mock.Mock{}}
-	service := user.NewService(validator.New(), repository)
+	service := user.NewService(validator.New(), repository, session.NewRepository(), audit.NewRepository(), relation.NewRepository(), search.NewService(validator.New(), search.NewRepository(), search.NewMemoryIndexer()), user.NewMemoryAttemptStore(), cache.NewLoader(cache.NewLRU(100)))
 	return repository, service
 }
 
@@ -122,6 +127,10 @@ func TestServiceImpl_Login(t *testing.T) {
 			DisplayName: "test service",
 			Password:    string(enc),
 		}, nil)
+		repository.On("CreateLoginAttempt", mock.MatchedBy(func(a *user.LoginAttempt) bool {
+			assert.Equal(t, user.AttemptWrongPassword, a.Reason)
+			return true
+		}))
 
 		assert.Panics(t, func() {
 			res := service.Login(context.Background(), &user.LoginRequest{
@@ -290,40 +299,86 @@ func TestServiceImpl_FindByUsername(t *testing.T) {
 }
 
 func TestServiceImpl_SearchLike(t *testing.T) {
+	setupSearchTest := func() user.Service {
+		owners := &search.RepositoryMock{}
+		owners.On("FindOwners", mock.Anything, mock.Anything).Return(map[string]*search.Owner{
+			"1": {UserID: "1"}, "2": {UserID: "2"}, "3": {UserID: "3"},
+		})
+		searchService := search.NewService(validator.New(), owners, search.NewMemoryIndexer())
+		for _, u := range []*user.User{
+			{ID: "1", Username: "user1", DisplayName: "test service 1"},
+			{ID: "2", Username: "user2", DisplayName: "test service 2", IsVerified: true},
+			{ID: "3", Username: "testservice", DisplayName: "user3"},
+		} {
+			searchService.IndexUser(&search.UserDocument{UserID: u.ID, Username: u.Username, DisplayName: u.DisplayName, IsVerified: u.IsVerified})
+		}
+		return user.NewService(validator.New(), &user.RepositoryMock{}, session.NewRepository(), audit.NewRepository(), relation.NewRepository(), searchService, user.NewMemoryAttemptStore(), cache.NewLoader(cache.NewLRU(100)))
+	}
+
 	t.Run("success should return slice of user", func(t *testing.T) {
-		repository, service := setupServiceTest()
+		service := setupSearchTest()
 		keyword := "testservice"
 
-		repository.On("FindLike", keyword).Return([]*user.User{
-			{
-				Username:    "user1",
-				DisplayName: "user1",
-			},
-			{
-				Username:    "user2",
-				DisplayName: "user2",
-			},
-			{
-				Username:    "user3",
-				DisplayName: "user3",
-			},
-		})
-
 		assert.NotPanics(t, func() {
 			res := service.SearchLike(context.Background(), keyword)
 			assert.NotEmpty(t, res)
 		})
 	})
 
+	t.Run("exact username should rank first then verified", func(t *testing.T) {
+		service := setupSearchTest()
+
+		res := service.SearchLike(context.Background(), "test")
+		assert.Len(t, res, 3)
+		assert.Equal(t, "user2", res[0].Username)
+
+		res = service.SearchLike(context.Background(), "testservice")
+		assert.Equal(t, "testservice", res[0].Username)
+	})
+
 	t.Run("not found user should return empty slice", func(t *testing.T) {
-		repository, service := setupServiceTest()
+		service := setupSearchTest()
 		keyword := "notfounduser"
 
-		repository.On("FindLike", keyword).Return([]*user.User{})
-
 		assert.NotPanics(t, func() {
 			res := service.SearchLike(context.Background(), keyword)
 			assert.Empty(t, res)
 		})
 	})
 }
+
+func TestServiceImpl_BootstrapAdmin(t *testing.T) {
+	t.Run("existing admin should leave roles alone", func(t *testing.T) {
+		repository, service := setupServiceTest()
+		repository.On("HasRole", user.RoleAdmin).Return(true)
+
+		assert.NotPanics(t, func() {
+			service.BootstrapAdmin(context.Background(), "testservice")
+		})
+		repository.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything)
+	})
+
+	t.Run("unregistered user should not be promoted", func(t *testing.T) {
+		repository, service := setupServiceTest()
+		repository.On("HasRole", user.RoleAdmin).Return(false)
+		repository.On("FindByUsername", "testservice").Return(&user.User{})
+
+		assert.NotPanics(t, func() {
+			service.BootstrapAdmin(context.Background(), "testservice")
+		})
+		repository.AssertNotCalled(t, "SetRole", mock.Anything, mock.Anything)
+	})
+
+	t.Run("user should be made the first admin", func(t *testing.T) {
+		repository, service := setupServiceTest()
+		userID := uuid.NewV4().String()
+		repository.On("HasRole", user.RoleAdmin).Return(false)
+		repository.On("FindByUsername", "testservice").Return(&user.User{ID: userID, Role: user.RoleUser})
+		repository.On("SetRole", userID, user.RoleAdmin).Return()
+
+		assert.NotPanics(t, func() {
+			service.BootstrapAdmin(context.Background(), "testservice")
+		})
+		repository.AssertCalled(t, "SetRole", userID, user.RoleAdmin)
+	})
+}
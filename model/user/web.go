@@ -1,19 +1,29 @@
 package user
 
+import (
+	"go-api/model/audit"
+	"io"
+)
+
 type (
 	RegisterRequest struct {
 		Email       string `validate:"required,email" form:"email" json:"email"`
 		Username    string `validate:"required,alphanum,max=18" form:"username" json:"username"`
 		DisplayName string `validate:"required" form:"display_name" json:"display_name"`
 		Password    string `validate:"required,min=8" form:"password" json:"password"`
+		IPAddress   string `json:"-"`
+		UserAgent   string `json:"-"`
 	}
 
 	LoginRequest struct {
-		Handler  string `form:"handler" json:"handler"`
-		Password string `validate:"required" form:"password" json:"password"`
+		Handler   string `form:"handler" json:"handler"`
+		Password  string `validate:"required" form:"password" json:"password"`
+		IPAddress string `json:"-"`
+		UserAgent string `json:"-"`
 	}
 
 	UpdateProfileRequest struct {
+		audit.Actor
 		UserID      string `validate:"required,uuid4" form:"user_id" json:"user_id"`
 		Email       string `validate:"required,email" form:"email" json:"email"`
 		Username    string `validate:"required,alphanum,max=18" form:"username" json:"username"`
@@ -22,6 +32,7 @@ type (
 	}
 
 	UpdatePasswordRequest struct {
+		audit.Actor
 		UserID      string `validate:"required,uuid4" form:"user_id" json:"user_id"`
 		OldPassword string `validate:"required,min=8" form:"old_password" json:"old_password"`
 		NewPassword string `validate:"required,min=8" form:"new_password" json:"new_password"`
@@ -32,21 +43,34 @@ type (
 		Token  string `json:"token"`
 	}
 
+	AvatarRequest struct {
+		UserID string    `validate:"required" json:"user_id"`
+		File   io.Reader `validate:"required" json:"-"`
+	}
+
+	AvatarURLs struct {
+		Small  string `json:"small"`
+		Medium string `json:"medium"`
+		Large  string `json:"large"`
+	}
+
 	SearchResponse struct {
-		Username          string `json:"username"`
-		DisplayName       string `json:"display_name"`
-		ProfilePictureURL string `json:"profile_picture_url"`
+		Username           string      `json:"username"`
+		DisplayName        string      `json:"display_name"`
+		ProfilePictureURL  string      `json:"profile_picture_url"`
+		ProfilePictureURLs *AvatarURLs `json:"profile_picture_urls"`
 	}
 
 	Response struct {
-		Username          string `json:"username"`
-		DisplayName       string `json:"display_name"`
-		Biography         string `json:"biography"`
-		ExternalUrl       string `json:"external_url"`
-		ProfilePictureURL string `json:"profile_picture_url"`
-		IsVerified        bool   `json:"is_verified"`
-		FollowedByViewer  bool   `json:"followed_by_viewer"`
-		FollowerCount     int64  `json:"follower_count"`
-		FollowingCount    int64  `json:"following_count"`
+		Username           string      `json:"username"`
+		DisplayName        string      `json:"display_name"`
+		Biography          string      `json:"biography"`
+		ExternalUrl        string      `json:"external_url"`
+		ProfilePictureURL  string      `json:"profile_picture_url"`
+		ProfilePictureURLs *AvatarURLs `json:"profile_picture_urls"`
+		IsVerified         bool        `json:"is_verified"`
+		FollowedByViewer   bool        `json:"followed_by_viewer"`
+		FollowerCount      int64       `json:"follower_count"`
+		FollowingCount     int64       `json:"following_count"`
 	}
 )
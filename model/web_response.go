@@ -1,8 +1,26 @@
 package model
 
+import (
+	"github.com/gin-gonic/gin"
+	"go-api/app"
+)
+
 type WebResponse struct {
-	Code   int                 `json:"code"`
-	Status string              `json:"status"`
-	Errors []map[string]string `json:"errors"`
-	Data   interface{}         `json:"data"`
+	Code      int                 `json:"code"`
+	Status    string              `json:"status"`
+	Errors    []map[string]string `json:"errors"`
+	Data      interface{}         `json:"data"`
+	RequestID string              `json:"request_id,omitempty"`
+}
+
+// Respond writes res with its Code as the status, tagged with the ID of the request it answers.
+func Respond(ctx *gin.Context, res *WebResponse) {
+	res.RequestID = app.RequestIDFrom(ctx)
+	ctx.IndentedJSON(res.Code, res)
+}
+
+// Abort is Respond for a request whose remaining handlers must not run.
+func Abort(ctx *gin.Context, res *WebResponse) {
+	res.RequestID = app.RequestIDFrom(ctx)
+	ctx.AbortWithStatusJSON(res.Code, res)
 }